@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+// budgetTargets returns the budgets to sync into. Without ynab.budgets the
+// top-level budget_id and accounts form the only one, and save writes
+// changes to it back to those fields.
+func (app *App) budgetTargets() (targets []*config.BudgetConfig, save func()) {
+	y := &app.config.YNAB
+	if len(y.Budgets) > 0 {
+		for i := range y.Budgets {
+			targets = append(targets, &y.Budgets[i])
+		}
+		return targets, func() {}
+	}
+
+	legacy := &config.BudgetConfig{BudgetID: y.BudgetID, Accounts: y.Accounts}
+	return []*config.BudgetConfig{legacy}, func() {
+		y.BudgetID = legacy.BudgetID
+		y.Accounts = legacy.Accounts
+	}
+}
+
+func budgetLabel(b *config.BudgetConfig) string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return b.BudgetID
+}
+
+type budgetLister interface {
+	GetBudgets() (*ynab.GetBudgetsResponse, error)
+}
+
+// chooseBudget picks the YNAB budget for a target without budget_id: the
+// one named or identified by choice, the only budget there is, or one the
+// user picks from a list when interactive.
+func (app *App) chooseBudget(client budgetLister, target *config.BudgetConfig, choice string) (string, error) {
+	resp, err := client.GetBudgets()
+	if err != nil {
+		return "", err
+	}
+	budgets := resp.Data.Budgets
+	if len(budgets) == 0 {
+		return "", fmt.Errorf("no budgets found in YNAB account")
+	}
+
+	if choice != "" {
+		for _, b := range budgets {
+			if b.ID == choice || strings.EqualFold(b.Name, choice) {
+				return b.ID, nil
+			}
+		}
+		return "", fmt.Errorf("no YNAB budget named %q", choice)
+	}
+
+	if len(budgets) == 1 {
+		return budgets[0].ID, nil
+	}
+
+	info, err := os.Stdin.Stat()
+	if !app.interactive || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("found %d YNAB budgets; choose one with --budget or set budget_id", len(budgets))
+	}
+
+	question := "Which YNAB budget should messages sync to?"
+	if target.Name != "" {
+		question = fmt.Sprintf("Which YNAB budget is %q?", target.Name)
+	}
+	return pickBudget(os.Stdin, os.Stdout, question, budgets)
+}
+
+func pickBudget(r io.Reader, w io.Writer, question string, budgets []ynab.Budget) (string, error) {
+	fmt.Fprintln(w, question)
+	for i, b := range budgets {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, b.Name)
+	}
+	fmt.Fprintf(w, "Budget [1-%d]: ", len(budgets))
+
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(budgets) {
+		return "", fmt.Errorf("invalid budget choice %q", strings.TrimSpace(answer))
+	}
+	return budgets[n-1].ID, nil
+}
+
+// routeTransactions splits messages and transactions by budget target,
+// returning for each target the indices it receives. Transactions no
+// budget accepts are returned separately.
+func routeTransactions(targets []*config.BudgetConfig, messages []*message.Message, transactions []*template.Transaction) (routes [][]int, unrouted []int) {
+	routes = make([][]int, len(targets))
+	for i, tx := range transactions {
+		target := routeTransaction(targets, messages[i].Sender, last4Regex.FindString(tx.Card))
+		if target < 0 {
+			unrouted = append(unrouted, i)
+			continue
+		}
+		routes[target] = append(routes[target], i)
+	}
+	return routes, unrouted
+}
+
+func routeTransaction(targets []*config.BudgetConfig, sender, last4 string) int {
+	if last4 != "" {
+		for i, b := range targets {
+			if containsString(b.Cards, last4) {
+				return i
+			}
+		}
+		for i, b := range targets {
+			for _, acc := range b.Accounts {
+				if (acc.Last4 == last4 || containsString(acc.Cards, last4)) &&
+					(acc.Sender == "" || strings.EqualFold(acc.Sender, sender)) {
+					return i
+				}
+			}
+		}
+	}
+
+	for i, b := range targets {
+		for _, s := range b.Senders {
+			if strings.EqualFold(s, sender) {
+				return i
+			}
+		}
+	}
+
+	for i, b := range targets {
+		if len(b.Senders) == 0 && len(b.Cards) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+func TestRouteTransactions(t *testing.T) {
+	targets := []*config.BudgetConfig{
+		{Name: "personal"},
+		{Name: "shared", Senders: []string{"EXIMBANK"}, Cards: []string{"4321"}},
+		{Name: "kids", Accounts: []config.YNABAccount{{YNABAccountID: "acc", Last4: "1111", Cards: []string{"2222"}}}, Senders: []string{"none"}},
+	}
+
+	messages := []*message.Message{
+		{Sender: "102"},
+		{Sender: "eximbank"},
+		{Sender: "102"},
+		{Sender: "102"},
+		{Sender: "EXIMBANK"},
+	}
+	transactions := []*template.Transaction{
+		{Card: "*1234"},
+		{Card: "9..1234"},
+		{Card: "*4321"},
+		{Card: "*2222"},
+		{Card: "9..1111"},
+	}
+
+	routes, unrouted := routeTransactions(targets, messages, transactions)
+
+	want := [][]int{{0}, {1, 2}, {3, 4}}
+	for i := range want {
+		if len(routes[i]) != len(want[i]) {
+			t.Errorf("routes[%s] = %v, want %v", targets[i].Name, routes[i], want[i])
+			continue
+		}
+		for j := range want[i] {
+			if routes[i][j] != want[i][j] {
+				t.Errorf("routes[%s] = %v, want %v", targets[i].Name, routes[i], want[i])
+				break
+			}
+		}
+	}
+	if len(unrouted) != 0 {
+		t.Errorf("unrouted = %v, want none", unrouted)
+	}
+}
+
+func TestRouteTransactions_NoDefaultBudget(t *testing.T) {
+	targets := []*config.BudgetConfig{{Name: "shared", Senders: []string{"EXIMBANK"}}}
+	messages := []*message.Message{{Sender: "102"}}
+	transactions := []*template.Transaction{{Card: "*1234"}}
+
+	_, unrouted := routeTransactions(targets, messages, transactions)
+	if len(unrouted) != 1 {
+		t.Errorf("unrouted = %v, want the MAIB transaction", unrouted)
+	}
+}
+
+type fakeBudgetLister struct {
+	budgets []ynab.Budget
+}
+
+func (f *fakeBudgetLister) GetBudgets() (*ynab.GetBudgetsResponse, error) {
+	resp := &ynab.GetBudgetsResponse{}
+	resp.Data.Budgets = f.budgets
+	return resp, nil
+}
+
+func TestApp_chooseBudget(t *testing.T) {
+	app := NewApp(&config.Config{}, "")
+	two := &fakeBudgetLister{budgets: []ynab.Budget{{ID: "b1", Name: "Personal"}, {ID: "b2", Name: "Shared"}}}
+
+	if id, err := app.chooseBudget(two, &config.BudgetConfig{}, "shared"); err != nil || id != "b2" {
+		t.Errorf("chooseBudget(shared) = %q, %v, want b2", id, err)
+	}
+	if id, err := app.chooseBudget(two, &config.BudgetConfig{}, "b1"); err != nil || id != "b1" {
+		t.Errorf("chooseBudget(b1) = %q, %v, want b1", id, err)
+	}
+	if _, err := app.chooseBudget(two, &config.BudgetConfig{}, "missing"); err == nil {
+		t.Error("chooseBudget(missing) expected error")
+	}
+	if _, err := app.chooseBudget(two, &config.BudgetConfig{}, ""); err == nil || !strings.Contains(err.Error(), "--budget") {
+		t.Errorf("chooseBudget() error = %v, want a hint to use --budget", err)
+	}
+
+	one := &fakeBudgetLister{budgets: []ynab.Budget{{ID: "only", Name: "Only"}}}
+	if id, err := app.chooseBudget(one, &config.BudgetConfig{}, ""); err != nil || id != "only" {
+		t.Errorf("chooseBudget() with one budget = %q, %v, want only", id, err)
+	}
+}
+
+func TestPickBudget(t *testing.T) {
+	budgets := []ynab.Budget{{ID: "b1", Name: "Personal"}, {ID: "b2", Name: "Shared"}}
+
+	var out bytes.Buffer
+	id, err := pickBudget(strings.NewReader("2\n"), &out, "Which?", budgets)
+	if err != nil || id != "b2" {
+		t.Errorf("pickBudget() = %q, %v, want b2", id, err)
+	}
+	if !strings.Contains(out.String(), "  1) Personal\n  2) Shared\n") {
+		t.Errorf("pickBudget() output = %q", out.String())
+	}
+
+	if _, err := pickBudget(strings.NewReader("3\n"), &out, "Which?", budgets); err == nil {
+		t.Error("pickBudget() expected error for out-of-range choice")
+	}
+}
+
+func TestApp_budgetTargets_Legacy(t *testing.T) {
+	app := NewApp(&config.Config{YNAB: config.YNABConfig{BudgetID: "b1"}}, "")
+
+	targets, save := app.budgetTargets()
+	if len(targets) != 1 || targets[0].BudgetID != "b1" {
+		t.Fatalf("budgetTargets() = %+v, want the top-level budget", targets)
+	}
+
+	targets[0].Accounts = append(targets[0].Accounts, config.YNABAccount{YNABAccountID: "acc", Last4: "1234"})
+	save()
+	if len(app.config.YNAB.Accounts) != 1 {
+		t.Errorf("save() did not write accounts back: %+v", app.config.YNAB)
+	}
+}
+
+func TestApp_validateYNABConfig_Budgets(t *testing.T) {
+	app := NewApp(&config.Config{YNAB: config.YNABConfig{Budgets: []config.BudgetConfig{
+		{BudgetID: "b1", StartDate: "2026-01-01"},
+		{BudgetID: "b2"},
+	}}}, "")
+	if err := app.validateYNABConfig(); err == nil {
+		t.Error("validateYNABConfig() expected error for a budget without start date")
+	}
+
+	app.config.YNAB.StartDate = "2025-06-01"
+	if err := app.validateYNABConfig(); err != nil {
+		t.Errorf("validateYNABConfig() error = %v", err)
+	}
+}
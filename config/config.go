@@ -2,28 +2,166 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
+// YNABAccount maps cards to a YNAB account. Cards lists the last 4 digits
+// of further cards (reissued or virtual) booked to the same account, and
+// Sender limits the mapping to one sender. An entry without an account ID
+// is created or looked up on the next sync, with its own Type and Name.
 type YNABAccount struct {
-	YNABAccountID string `json:"ynab_account_id"`
-	Last4         string `json:"last4"`
+	YNABAccountID string   `json:"ynab_account_id,omitempty"`
+	Last4         string   `json:"last4"`
+	Cards         []string `json:"cards,omitempty"`
+	Sender        string   `json:"sender,omitempty"`
+	Type          string   `json:"type,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	// Cleared, Approved and FlagColor set the YNAB state of the account's
+	// transactions, before StateRules.
+	Cleared   string `json:"cleared,omitempty"`
+	Approved  *bool  `json:"approved,omitempty"`
+	FlagColor string `json:"flag_color,omitempty"`
+	// MemoTemplate replaces YNABConfig.MemoTemplate for this account
+	MemoTemplate string `json:"memo_template,omitempty"`
+}
+
+// BudgetConfig is one of several YNAB budgets to sync into. Messages go to
+// the budget whose Cards or account mappings contain their card, else to
+// the one listing their sender, else to the first budget without Senders
+// and Cards.
+type BudgetConfig struct {
+	Name     string        `json:"name,omitempty"`
+	BudgetID string        `json:"budget_id"`
+	Accounts []YNABAccount `json:"accounts"`
+	// StartDate defaults to ynab.start_date
+	StartDate string   `json:"start_date,omitempty"`
+	Senders   []string `json:"senders,omitempty"`
+	Cards     []string `json:"cards,omitempty"`
 }
 
 type YNABConfig struct {
 	BudgetID  string        `json:"budget_id"`
 	Accounts  []YNABAccount `json:"accounts"`
 	StartDate string        `json:"start_date"`
+	// Budgets replaces BudgetID and Accounts when set
+	Budgets []BudgetConfig `json:"budgets,omitempty"`
+	// FeeCategory and CashbackCategory name the categories of the split
+	// parts for fees and cashback. Fees default to "Bank fees"; cashback is
+	// left uncategorized unless set.
+	FeeCategory      string `json:"fee_category,omitempty"`
+	CashbackCategory string `json:"cashback_category,omitempty"`
+	// AccountType and AccountName are used for accounts created for new
+	// cards; AccountName may contain {last4} and {sender}.
+	AccountType string `json:"account_type,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	// ExactAccountMatch only reuses YNAB accounts named exactly as
+	// AccountName renders, instead of any account mentioning the card.
+	ExactAccountMatch bool `json:"exact_account_match,omitempty"`
+	// AutoCreateAccounts creates accounts without asking, e.g. for the
+	// background job.
+	AutoCreateAccounts bool `json:"auto_create_accounts,omitempty"`
+	// MatchWindowDays is how far apart in days a transaction entered by
+	// hand may be from the SMS to be linked to it. Defaults to 3; negative
+	// turns linking off.
+	MatchWindowDays int `json:"match_window_days,omitempty"`
+	// StateRules set the YNAB state of matching transactions; when several
+	// match, later rules win.
+	StateRules []StateRuleConfig `json:"state_rules,omitempty"`
+	// MemoTemplate is a text/template for transaction memos; by default the
+	// memo only names unusual operations and statuses.
+	MemoTemplate string `json:"memo_template,omitempty"`
+}
+
+// StateRuleConfig matches transactions by all of its non-empty conditions
+// and sets the given cleared, approved and flag state.
+type StateRuleConfig struct {
+	Sender    string   `json:"sender,omitempty"`
+	Cards     []string `json:"cards,omitempty"`
+	Kind      string   `json:"kind,omitempty"`
+	Direction string   `json:"direction,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	MinAmount float64  `json:"min_amount,omitempty"`
+	Foreign   bool     `json:"foreign,omitempty"`
+	Cleared   string   `json:"cleared,omitempty"`
+	Approved  *bool    `json:"approved,omitempty"`
+	FlagColor string   `json:"flag_color,omitempty"`
+}
+
+type SourceConfig struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path,omitempty"`
+	Address     string            `json:"address,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	PasswordEnv string            `json:"password_env,omitempty"`
+	Mailbox     string            `json:"mailbox,omitempty"`
+	TLS         bool              `json:"tls,omitempty"`
+	FromSenders map[string]string `json:"from_senders,omitempty"`
+}
+
+type WebhookConfig struct {
+	Listen          string `json:"listen,omitempty"`
+	Mode            string `json:"mode,omitempty"`
+	AllowQueryToken bool   `json:"allow_query_token,omitempty"`
+}
+
+type StatementCSVConfig struct {
+	Delimiter       string `json:"delimiter,omitempty"`
+	Date            string `json:"date"`
+	DateFormat      string `json:"date_format,omitempty"`
+	Amount          string `json:"amount,omitempty"`
+	Debit           string `json:"debit,omitempty"`
+	Credit          string `json:"credit,omitempty"`
+	Currency        string `json:"currency,omitempty"`
+	Payee           string `json:"payee,omitempty"`
+	Account         string `json:"account,omitempty"`
+	DefaultCurrency string `json:"default_currency,omitempty"`
+}
+
+type TemplateConfig struct {
+	Name      string `json:"name"`
+	Pattern   string `json:"pattern"`
+	Operation string `json:"operation,omitempty"`
+	Priority  int    `json:"priority,omitempty"`
+	Direction string `json:"direction"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+type IgnoreConfig struct {
+	Sender   string `json:"sender,omitempty"`
+	Contains string `json:"contains,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+type SinkConfig struct {
+	Type           string            `json:"type"`
+	Path           string            `json:"path,omitempty"`
+	URL            string            `json:"url,omitempty"`
+	TokenEnv       string            `json:"token_env,omitempty"`
+	BudgetID       string            `json:"budget_id,omitempty"`
+	Accounts       map[string]string `json:"accounts,omitempty"`
+	AccountPrefix  string            `json:"account_prefix,omitempty"`
+	ExpenseAccount string            `json:"expense_account,omitempty"`
+	IncomeAccount  string            `json:"income_account,omitempty"`
 }
 
 type Config struct {
-	Senders         []string   `json:"senders"`
-	DBPath          string     `json:"db_path"`
-	DefaultCurrency string     `json:"default_currency"`
-	DataFilePath    string     `json:"data_file_path"`
-	YNAB            YNABConfig `json:"ynab"`
+	Senders         []string            `json:"senders"`
+	DBPath          string              `json:"db_path"`
+	Sources         []SourceConfig      `json:"sources,omitempty"`
+	DefaultCurrency string              `json:"default_currency"`
+	DataFilePath    string              `json:"data_file_path"`
+	YNAB            YNABConfig          `json:"ynab"`
+	Webhook         WebhookConfig       `json:"webhook"`
+	StatementCSV    *StatementCSVConfig `json:"statement_csv,omitempty"`
+	Templates       []TemplateConfig    `json:"templates,omitempty"`
+	Ignore          []IgnoreConfig      `json:"ignore,omitempty"`
+	Sinks           []SinkConfig        `json:"sinks,omitempty"`
 }
 
+const DefaultDataFilePath = "ynab_importer_go_data.json"
+
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -35,11 +173,18 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	// A guessed sign would post incoming money as an outflow without notice
+	for _, tc := range cfg.Templates {
+		if tc.Direction == "" {
+			return nil, fmt.Errorf("template %s has no direction; set \"direction\" to debit, credit, reversal or transfer", tc.Name)
+		}
+	}
+
 	if cfg.DefaultCurrency == "" {
 		cfg.DefaultCurrency = "MDL"
 	}
 	if cfg.DataFilePath == "" {
-		cfg.DataFilePath = "ynab_importer_go_data.json"
+		cfg.DataFilePath = DefaultDataFilePath
 	}
 
 	return &cfg, nil
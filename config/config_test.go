@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -58,6 +59,19 @@ func TestLoad_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestLoad_TemplateWithoutDirection(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	content := `{"templates": [{"name": "Victoriabank", "pattern": "suma (?P<amount>[\\d.]+)"}]}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp config: %v", err)
+	}
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), "template Victoriabank has no direction") {
+		t.Errorf("Load() error = %v, want the template without direction named", err)
+	}
+}
+
 func TestLoad_DefaultCurrencyAndDataFilePath(t *testing.T) {
 	dir := t.TempDir()
 	configPath := filepath.Join(dir, "config.json")
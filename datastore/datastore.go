@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// The data file is shared by several stores, each owning one top-level key.
+// Writes go through WriteSection so a store never drops sections it does not
+// know about.
+
+var mu sync.Mutex
+
+func readSections(path string) (map[string]json.RawMessage, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]json.RawMessage{}
+	if err := json.Unmarshal(content, &sections); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+func ReadSection(path, key string, v interface{}) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sections, err := readSections(path)
+	if err != nil {
+		return err
+	}
+
+	raw, ok := sections[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func WriteSection(path, key string, v interface{}) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sections, err := readSections(path)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sections[key] = raw
+
+	content, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
@@ -0,0 +1,78 @@
+package datastore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSection_PreservesOtherSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	content := `{"rates": [{"currency": "USD"}], "other": {"kept": true}}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := WriteSection(path, "queue", []string{"a", "b"}); err != nil {
+		t.Fatalf("WriteSection() error = %v", err)
+	}
+
+	var rates []map[string]string
+	if err := ReadSection(path, "rates", &rates); err != nil {
+		t.Fatalf("ReadSection() error = %v", err)
+	}
+	if len(rates) != 1 || rates[0]["currency"] != "USD" {
+		t.Errorf("rates section not preserved: %v", rates)
+	}
+
+	var other map[string]bool
+	if err := ReadSection(path, "other", &other); err != nil {
+		t.Fatalf("ReadSection() error = %v", err)
+	}
+	if !other["kept"] {
+		t.Error("other section not preserved")
+	}
+
+	var queue []string
+	if err := ReadSection(path, "queue", &queue); err != nil {
+		t.Fatalf("ReadSection() error = %v", err)
+	}
+	if len(queue) != 2 {
+		t.Errorf("expected 2 queue entries, got %d", len(queue))
+	}
+}
+
+func TestReadSection_MissingFileAndKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	values := []string{"untouched"}
+	if err := ReadSection(path, "queue", &values); err != nil {
+		t.Fatalf("ReadSection() error = %v", err)
+	}
+	if len(values) != 1 {
+		t.Error("ReadSection() should leave value untouched for missing file")
+	}
+
+	if err := WriteSection(path, "rates", []int{}); err != nil {
+		t.Fatalf("WriteSection() error = %v", err)
+	}
+	if err := ReadSection(path, "queue", &values); err != nil {
+		t.Fatalf("ReadSection() error = %v", err)
+	}
+	if len(values) != 1 {
+		t.Error("ReadSection() should leave value untouched for missing key")
+	}
+}
+
+func TestReadSection_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	os.WriteFile(path, []byte("invalid json{{{"), 0600)
+
+	var v []string
+	if err := ReadSection(path, "queue", &v); err == nil {
+		t.Error("ReadSection() should return error for invalid JSON")
+	}
+	if err := WriteSection(path, "queue", v); err == nil {
+		t.Error("WriteSection() should return error for invalid JSON")
+	}
+}
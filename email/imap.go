@@ -0,0 +1,187 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type IMAPConfig struct {
+	Address  string
+	Username string
+	Password string
+	Mailbox  string
+	TLS      bool
+	Since    time.Time
+}
+
+var literalRegex = regexp.MustCompile(`\{(\d+)\}$`)
+
+// IMAPClient is a minimal read-only IMAP4rev1 client: it logs in, selects a
+// mailbox and fetches full messages without marking them as seen.
+type IMAPClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+func DialIMAP(cfg IMAPConfig) (*IMAPClient, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(cfg.Address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", cfg.Address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", cfg.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to IMAP server: %w", err)
+	}
+
+	client := &IMAPClient{conn: conn, reader: bufio.NewReader(conn)}
+
+	greeting, err := client.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting)
+	}
+
+	return client, nil
+}
+
+func FetchIMAP(cfg IMAPConfig) ([]*Email, error) {
+	client, err := DialIMAP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if _, err := client.command("LOGIN %s %s", quote(cfg.Username), quote(cfg.Password)); err != nil {
+		return nil, fmt.Errorf("IMAP login failed: %w", err)
+	}
+
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := client.command("EXAMINE %s", quote(mailbox)); err != nil {
+		return nil, fmt.Errorf("failed to open mailbox %s: %w", mailbox, err)
+	}
+
+	criteria := "ALL"
+	if !cfg.Since.IsZero() {
+		criteria = "SINCE " + cfg.Since.Format("02-Jan-2006")
+	}
+	responses, err := client.command("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, fmt.Errorf("IMAP search failed: %w", err)
+	}
+
+	var uids []string
+	for _, resp := range responses {
+		if strings.HasPrefix(resp.line, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(resp.line, "* SEARCH"))...)
+		}
+	}
+	if len(uids) == 0 {
+		return []*Email{}, nil
+	}
+
+	responses, err = client.command("UID FETCH %s (BODY.PEEK[])", strings.Join(uids, ","))
+	if err != nil {
+		return nil, fmt.Errorf("IMAP fetch failed: %w", err)
+	}
+
+	var emails []*Email
+	for _, resp := range responses {
+		if resp.literal == nil {
+			continue
+		}
+		email, err := Parse(bytes.NewReader(resp.literal))
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	client.command("LOGOUT")
+	return emails, nil
+}
+
+func (c *IMAPClient) Close() error {
+	return c.conn.Close()
+}
+
+type imapResponse struct {
+	line    string
+	literal []byte
+}
+
+// command sends a tagged command and collects untagged responses until the
+// tagged completion, returning an error unless it is OK.
+func (c *IMAPClient) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+
+	c.conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, fmt.Errorf("failed to send IMAP command: %w", err)
+	}
+
+	var responses []imapResponse
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("IMAP server: %s", status)
+			}
+			return responses, nil
+		}
+
+		resp := imapResponse{line: line}
+		if m := literalRegex.FindStringSubmatch(line); m != nil {
+			size, _ := strconv.Atoi(m[1])
+			resp.literal = make([]byte, size)
+			if _, err := io.ReadFull(c.reader, resp.literal); err != nil {
+				return nil, fmt.Errorf("failed to read IMAP literal: %w", err)
+			}
+			// Rest of the response after the literal, usually ")"
+			if _, err := c.readLine(); err != nil {
+				return nil, err
+			}
+		}
+		responses = append(responses, resp)
+	}
+}
+
+func (c *IMAPClient) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read IMAP response: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
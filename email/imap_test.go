@@ -0,0 +1,170 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIMAPServer is a scripted local stand-in that answers the handful of
+// commands FetchIMAP issues.
+type fakeIMAPServer struct {
+	listener net.Listener
+	password string
+	messages []string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeIMAPServer(t *testing.T, password string, messages []string) *fakeIMAPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &fakeIMAPServer{listener: listener, password: password, messages: messages}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeIMAPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		parts := strings.SplitN(line, " ", 2)
+		tag, cmd := parts[0], parts[1]
+
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+
+		switch {
+		case strings.HasPrefix(cmd, "LOGIN"):
+			if !strings.Contains(cmd, `"`+s.password+`"`) {
+				fmt.Fprintf(conn, "%s NO [AUTHENTICATIONFAILED] Invalid credentials\r\n", tag)
+				continue
+			}
+			fmt.Fprintf(conn, "%s OK LOGIN completed\r\n", tag)
+		case strings.HasPrefix(cmd, "EXAMINE"):
+			fmt.Fprintf(conn, "* %d EXISTS\r\n%s OK [READ-ONLY] EXAMINE completed\r\n", len(s.messages), tag)
+		case strings.HasPrefix(cmd, "UID SEARCH"):
+			var uids []string
+			for i := range s.messages {
+				uids = append(uids, fmt.Sprint(i+1))
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n%s OK SEARCH completed\r\n", strings.Join(uids, " "), tag)
+		case strings.HasPrefix(cmd, "UID FETCH"):
+			for i, msg := range s.messages {
+				fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", i+1, i+1, len(msg), msg)
+			}
+			fmt.Fprintf(conn, "%s OK FETCH completed\r\n", tag)
+		case cmd == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+		}
+	}
+}
+
+func (s *fakeIMAPServer) receivedCommands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func TestFetchIMAP(t *testing.T) {
+	messages := []string{
+		"From: alerts@maib.md\r\nDate: Sat, 10 Jan 2026 10:00:00 +0000\r\n\r\nOp: Tovary i uslugi\r\n",
+		"From: news@maib.md\r\n\r\nNewsletter\r\n",
+	}
+	server := newFakeIMAPServer(t, "secret", messages)
+
+	emails, err := FetchIMAP(IMAPConfig{
+		Address:  server.listener.Addr().String(),
+		Username: "me@example.com",
+		Password: "secret",
+		Since:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("FetchIMAP() error = %v", err)
+	}
+
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(emails))
+	}
+	if emails[0].From != "alerts@maib.md" || emails[0].Text != "Op: Tovary i uslugi" {
+		t.Errorf("unexpected first email %+v", emails[0])
+	}
+
+	commands := server.receivedCommands()
+	if len(commands) < 4 {
+		t.Fatalf("expected at least 4 commands, got %v", commands)
+	}
+	if commands[1] != `EXAMINE "INBOX"` {
+		t.Errorf("expected read-only EXAMINE of INBOX, got %q", commands[1])
+	}
+	if commands[2] != "UID SEARCH SINCE 01-Jan-2026" {
+		t.Errorf("unexpected search command %q", commands[2])
+	}
+	if commands[3] != "UID FETCH 1,2 (BODY.PEEK[])" {
+		t.Errorf("unexpected fetch command %q", commands[3])
+	}
+}
+
+func TestFetchIMAP_LoginFailure(t *testing.T) {
+	server := newFakeIMAPServer(t, "secret", nil)
+
+	_, err := FetchIMAP(IMAPConfig{
+		Address:  server.listener.Addr().String(),
+		Username: "me@example.com",
+		Password: "wrong",
+	})
+	if err == nil || !strings.Contains(err.Error(), "login failed") {
+		t.Errorf("expected login failure, got %v", err)
+	}
+}
+
+func TestFetchIMAP_EmptyMailbox(t *testing.T) {
+	server := newFakeIMAPServer(t, "secret", nil)
+
+	emails, err := FetchIMAP(IMAPConfig{
+		Address:  server.listener.Addr().String(),
+		Username: "me@example.com",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("FetchIMAP() error = %v", err)
+	}
+	if len(emails) != 0 {
+		t.Errorf("expected 0 emails, got %d", len(emails))
+	}
+}
+
+func TestFetchIMAP_ConnectionRefused(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if _, err := FetchIMAP(IMAPConfig{Address: addr}); err == nil {
+		t.Error("FetchIMAP() should return error when server is unreachable")
+	}
+}
@@ -0,0 +1,110 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func ReadMboxFile(path string) ([]*Email, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mbox: %w", err)
+	}
+	defer f.Close()
+
+	return ReadMbox(f)
+}
+
+// ReadMbox splits an mbox stream on "From " separator lines and undoes the
+// ">From " quoting used by mboxrd writers.
+func ReadMbox(r io.Reader) ([]*Email, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var emails []*Email
+	var current bytes.Buffer
+	started := false
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		email, err := Parse(bytes.NewReader(current.Bytes()))
+		if err != nil {
+			return err
+		}
+		emails = append(emails, email)
+		current.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			started = true
+			continue
+		}
+		if !started {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\r\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mbox: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// ReadMaildir reads every message in the cur and new subdirectories, in
+// file name order (maildir names start with the delivery timestamp).
+func ReadMaildir(dir string) ([]*Email, error) {
+	var paths []string
+	for _, sub := range []string{"cur", "new"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read maildir: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				paths = append(paths, filepath.Join(dir, sub, entry.Name()))
+			}
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return filepath.Base(paths[i]) < filepath.Base(paths[j])
+	})
+
+	var emails []*Email
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read maildir message: %w", err)
+		}
+		email, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
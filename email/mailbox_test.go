@@ -0,0 +1,86 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMbox = `From alerts@bank.md Sat Jan 10 10:00:00 2026
+From: alerts@bank.md
+Date: Sat, 10 Jan 2026 10:00:00 +0000
+
+First alert
+>From the bank
+
+From news@bank.md Sat Jan 10 11:00:00 2026
+From: news@bank.md
+Date: Sat, 10 Jan 2026 11:00:00 +0000
+
+Second message
+`
+
+func TestReadMbox(t *testing.T) {
+	emails, err := ReadMbox(strings.NewReader(testMbox))
+	if err != nil {
+		t.Fatalf("ReadMbox() error = %v", err)
+	}
+
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(emails))
+	}
+	if emails[0].Text != "First alert\nFrom the bank" {
+		t.Errorf("unexpected first text %q", emails[0].Text)
+	}
+	if emails[1].From != "news@bank.md" {
+		t.Errorf("unexpected second sender %q", emails[1].From)
+	}
+}
+
+func TestReadMboxFile_NotFound(t *testing.T) {
+	if _, err := ReadMboxFile("/nonexistent/mbox"); err == nil {
+		t.Error("ReadMboxFile() should return error for missing file")
+	}
+}
+
+func TestReadMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("failed to create maildir: %v", err)
+		}
+	}
+
+	write := func(path, body string) {
+		content := "From: alerts@bank.md\r\n\r\n" + body + "\r\n"
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+	}
+	write("new/1768040000.M2.host", "second")
+	write("cur/1768030000.M1.host:2,S", "first")
+	write("tmp/1768050000.M3.host", "in delivery")
+
+	emails, err := ReadMaildir(dir)
+	if err != nil {
+		t.Fatalf("ReadMaildir() error = %v", err)
+	}
+
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(emails))
+	}
+	if emails[0].Text != "first" || emails[1].Text != "second" {
+		t.Errorf("unexpected order: %q, %q", emails[0].Text, emails[1].Text)
+	}
+}
+
+func TestReadMaildir_Empty(t *testing.T) {
+	emails, err := ReadMaildir(t.TempDir())
+	if err != nil {
+		t.Fatalf("ReadMaildir() error = %v", err)
+	}
+	if len(emails) != 0 {
+		t.Errorf("expected 0 emails, got %d", len(emails))
+	}
+}
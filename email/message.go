@@ -0,0 +1,194 @@
+package email
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type Email struct {
+	From    string
+	Subject string
+	Date    time.Time
+	Text    string
+}
+
+var (
+	htmlBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</tr>`)
+	htmlTagRegex   = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlDropRegex  = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	blankRunRegex  = regexp.MustCompile(`\n{3,}`)
+)
+
+var headerDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+func Parse(r io.Reader) (*Email, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+
+	from := ""
+	if addr, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		from = strings.ToLower(addr.Address)
+	}
+
+	subject, err := headerDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	date, _ := msg.Header.Date()
+
+	text, err := extractText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		From:    from,
+		Subject: subject,
+		Date:    date.UTC(),
+		Text:    text,
+	}, nil
+}
+
+// extractText walks the MIME tree and returns the first text/plain part,
+// falling back to a tag-stripped text/html part.
+func extractText(contentType, encoding string, body io.Reader) (string, error) {
+	plain, htmlText, err := walkPart(contentType, encoding, body)
+	if err != nil {
+		return "", err
+	}
+	if plain != "" {
+		return normalizeText(plain), nil
+	}
+	return normalizeText(stripHTML(htmlText)), nil
+}
+
+func walkPart(contentType, encoding string, body io.Reader) (string, string, error) {
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var plain, htmlText string
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read MIME part: %w", err)
+			}
+			p, h, err := walkPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", "", err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+		return plain, htmlText, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	decoded, err := decodeBody(encoding, params["charset"], body)
+	if err != nil {
+		return "", "", err
+	}
+
+	if mediaType == "text/html" {
+		return "", decoded, nil
+	}
+	return decoded, "", nil
+}
+
+func decodeBody(encoding, charset string, body io.Reader) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	}
+
+	if charset != "" {
+		converted, err := charsetReader(charset, body)
+		if err != nil {
+			return "", err
+		}
+		body = converted
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode body: %w", err)
+	}
+	return string(data), nil
+}
+
+// charsetReader decodes any charset known by its MIME or HTML name, such as
+// windows-1251 or koi8-r used by Russian-language alerts and iso-8859-2 for
+// Romanian. An unknown charset is an error rather than garbled text that no
+// template matches.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(strings.TrimSpace(charset))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+func stripHTML(s string) string {
+	s = htmlDropRegex.ReplaceAllString(s, "")
+	s = htmlBreakRegex.ReplaceAllString(s, "\n")
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
+
+func normalizeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.ReplaceAll(line, "\u00a0", " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = blankRunRegex.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
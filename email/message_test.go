@@ -0,0 +1,149 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func TestParse_PlainText(t *testing.T) {
+	raw := crlf(`From: "Eximbank" <Alerts@Eximbank.md>
+To: me@example.com
+Subject: Tranzactie
+Date: Sat, 10 Jan 2026 10:00:00 +0200
+Content-Type: text/plain; charset=utf-8
+
+Debitare cont Card 9..7890, Data 10.01.2026 10:00:00, Suma 9.65 MDL, Detalii Shop, Disponibil 100.00 MDL
+`)
+
+	email, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if email.From != "alerts@eximbank.md" {
+		t.Errorf("expected lowercased from address, got %q", email.From)
+	}
+	if email.Subject != "Tranzactie" {
+		t.Errorf("expected subject 'Tranzactie', got %q", email.Subject)
+	}
+	expected := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+	if !email.Date.Equal(expected) {
+		t.Errorf("expected date %v, got %v", expected, email.Date)
+	}
+	if !strings.HasPrefix(email.Text, "Debitare cont Card 9..7890") || strings.HasSuffix(email.Text, "\n") {
+		t.Errorf("unexpected text %q", email.Text)
+	}
+}
+
+func TestParse_MultipartPrefersPlain(t *testing.T) {
+	raw := crlf(`From: alerts@bank.md
+Subject: =?UTF-8?B?T3BlcmHIm2ll?=
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/html; charset=utf-8
+
+<p>HTML version</p>
+--b1
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Suma 10.00 MDL, Locatie SHOP=
+ CHISINAU
+--b1--
+`)
+
+	email, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if email.Text != "Suma 10.00 MDL, Locatie SHOP CHISINAU" {
+		t.Errorf("unexpected text %q", email.Text)
+	}
+	if email.Subject != "Operație" {
+		t.Errorf("expected decoded subject, got %q", email.Subject)
+	}
+}
+
+func TestParse_HTMLOnlyBase64(t *testing.T) {
+	// <html><head><style>p{}</style></head><body><p>Suma&nbsp;5 MDL</p><br>Card *1234</body></html>
+	raw := crlf(`From: alerts@bank.md
+Content-Type: multipart/mixed; boundary=outer
+
+--outer
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: base64
+
+PGh0bWw+PGhlYWQ+PHN0eWxlPnB7fTwvc3R5bGU+PC9oZWFkPjxib2R5PjxwPlN1bWEmbmJzcDs1
+IE1ETDwvcD48YnI+Q2FyZCAqMTIzNDwvYm9keT48L2h0bWw+
+--outer
+Content-Type: application/pdf
+
+binary
+--outer--
+`)
+
+	email, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if email.Text != "Suma 5 MDL\n\nCard *1234" {
+		t.Errorf("unexpected text %q", email.Text)
+	}
+}
+
+func TestParse_Latin1(t *testing.T) {
+	raw := "From: a@b.md\r\nContent-Type: text/plain; charset=iso-8859-1\r\n\r\nCaf\xe9\r\n"
+
+	email, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if email.Text != "Café" {
+		t.Errorf("expected 'Café', got %q", email.Text)
+	}
+}
+
+func TestParse_Charsets(t *testing.T) {
+	tests := []struct {
+		charset string
+		body    string
+		want    string
+	}{
+		// "Оплата" and "Plată în" as sent by Moldovan banks
+		{"windows-1251", "\xce\xef\xeb\xe0\xf2\xe0", "Оплата"},
+		{"koi8-r", "\xef\xd0\xcc\xc1\xd4\xc1", "Оплата"},
+		{"iso-8859-2", "Plat\xe3 \xeen", "Plată în"},
+	}
+	for _, tt := range tests {
+		raw := "From: a@b.md\r\nContent-Type: text/plain; charset=" + tt.charset + "\r\n\r\n" + tt.body + "\r\n"
+		email, err := Parse(strings.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.charset, err)
+		}
+		if email.Text != tt.want {
+			t.Errorf("Parse(%s) text = %q, want %q", tt.charset, email.Text, tt.want)
+		}
+	}
+
+	raw := "From: a@b.md\r\nContent-Type: text/plain; charset=x-unknown\r\n\r\nbody\r\n"
+	if _, err := Parse(strings.NewReader(raw)); err == nil {
+		t.Error("Parse() should return error for an unknown charset")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("not an email")); err == nil {
+		t.Error("Parse() should return error for input without headers")
+	}
+
+	raw := "From: a@b.md\r\nContent-Type: ;;;\r\n\r\nbody\r\n"
+	if _, err := Parse(strings.NewReader(raw)); err == nil {
+		t.Error("Parse() should return error for invalid content type")
+	}
+}
@@ -0,0 +1,52 @@
+package email
+
+import (
+	"strings"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+// SenderMap maps From addresses to the logical sender names used in the
+// config's senders list. Keys are either full addresses or "@domain".
+type SenderMap map[string]string
+
+func (m SenderMap) Resolve(from string) string {
+	from = strings.ToLower(from)
+	for key, sender := range m {
+		if strings.ToLower(key) == from {
+			return sender
+		}
+	}
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain := from[at:]
+		for key, sender := range m {
+			if strings.ToLower(key) == domain {
+				return sender
+			}
+		}
+	}
+	return from
+}
+
+// ToMessages converts emails into messages, keeping only those whose
+// resolved sender is listed in senders.
+func ToMessages(emails []*Email, senderMap SenderMap, senders []string) []*message.Message {
+	allowed := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		allowed[s] = true
+	}
+
+	var messages []*message.Message
+	for _, e := range emails {
+		sender := senderMap.Resolve(e.From)
+		if !allowed[sender] || e.Text == "" {
+			continue
+		}
+		messages = append(messages, &message.Message{
+			Timestamp: e.Date,
+			Sender:    sender,
+			Content:   e.Text,
+		})
+	}
+	return messages
+}
@@ -0,0 +1,47 @@
+package email
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSenderMap_Resolve(t *testing.T) {
+	senderMap := SenderMap{
+		"alerts@maib.md": "102",
+		"@Eximbank.md":   "EXIMBANK",
+	}
+
+	testCases := []struct {
+		from string
+		want string
+	}{
+		{"alerts@maib.md", "102"},
+		{"ALERTS@MAIB.MD", "102"},
+		{"estatements@eximbank.md", "EXIMBANK"},
+		{"someone@example.com", "someone@example.com"},
+	}
+
+	for _, tc := range testCases {
+		if got := senderMap.Resolve(tc.from); got != tc.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestToMessages(t *testing.T) {
+	date := time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC)
+	emails := []*Email{
+		{From: "alerts@maib.md", Date: date, Text: "Op: Tovary i uslugi"},
+		{From: "news@maib.md", Date: date, Text: "Newsletter"},
+		{From: "alerts@maib.md", Date: date, Text: ""},
+	}
+
+	messages := ToMessages(emails, SenderMap{"alerts@maib.md": "102"}, []string{"102"})
+
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Sender != "102" || messages[0].Content != "Op: Tovary i uslugi" || !messages[0].Timestamp.Equal(date) {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -76,6 +77,7 @@ type Valute struct {
 func (f *Fetcher) FetchRates(date time.Time) ([]*Rate, error) {
 	dateStr := date.Format("02.01.2006")
 	url := fmt.Sprintf("%s?get_xml=1&date=%s", f.baseURL, dateStr)
+	slog.Debug("fetching BNM exchange rates", "date", dateStr)
 
 	data, err := f.client.Get(url)
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
 )
 
 var ErrRateNotFound = errors.New("exchange rate not found")
@@ -28,7 +30,9 @@ type dataFile struct {
 
 type Store struct {
 	filePath string
-	mu       sync.RWMutex
+	// mu covers a whole read-modify-write, since rates are fetched
+	// concurrently
+	mu sync.RWMutex
 }
 
 func NewStore(filePath string) (*Store, error) {
@@ -52,9 +56,6 @@ func (s *Store) ensureFileExists() error {
 }
 
 func (s *Store) readFile() (*dataFile, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	content, err := os.ReadFile(s.filePath)
 	if err != nil {
 		return nil, err
@@ -69,18 +70,13 @@ func (s *Store) readFile() (*dataFile, error) {
 }
 
 func (s *Store) writeFile(data *dataFile) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	content, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(s.filePath, content, 0600)
+	return datastore.WriteSection(s.filePath, "rates", data.Rates)
 }
 
 func (s *Store) SaveRate(rate *Rate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	data, err := s.readFile()
 	if err != nil {
 		return err
@@ -109,6 +105,9 @@ func (s *Store) SaveRate(rate *Rate) error {
 }
 
 func (s *Store) GetRate(date time.Time, currency string) (*Rate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	data, err := s.readFile()
 	if err != nil {
 		return nil, err
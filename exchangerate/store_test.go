@@ -3,6 +3,7 @@ package exchangerate
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -56,6 +57,33 @@ func TestStore_SaveAndGetRate(t *testing.T) {
 	}
 }
 
+func TestStore_SaveRate_Concurrent(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer store.Close()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := store.SaveRate(&Rate{Date: start.AddDate(0, 0, i), Currency: "EUR", Value: 19}); err != nil {
+				t.Errorf("SaveRate() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		if _, err := store.GetRate(start.AddDate(0, 0, i), "EUR"); err != nil {
+			t.Errorf("rate for day %d lost: %v", i, err)
+		}
+	}
+}
+
 func TestStore_GetRate_NotFound(t *testing.T) {
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/redact"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+type exportCorpusOptions struct {
+	output  string
+	matched bool
+}
+
+// corpusRecord uses the same fields as the json message source, so an
+// exported corpus can be read back as a source.
+type corpusRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Sender    string    `json:"sender"`
+	Content   string    `json:"content"`
+	Template  string    `json:"template,omitempty"`
+}
+
+func parseExportCorpusArgs(args []string) (*exportCorpusOptions, error) {
+	opts := &exportCorpusOptions{}
+
+	for len(args) > 0 {
+		if args[0] == "--matched" {
+			opts.matched = true
+			args = args[1:]
+		} else if args[0] == "--output" && len(args) > 1 {
+			opts.output = args[1]
+			args = args[2:]
+		} else {
+			return nil, fmt.Errorf("usage: export_corpus [--matched] [--output <file>]")
+		}
+	}
+	return opts, nil
+}
+
+func (app *App) runExportCorpus(args []string) error {
+	opts, err := parseExportCorpusArgs(args)
+	if err != nil {
+		return err
+	}
+
+	messages, cleanup, err := app.fetchMessages()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	out := io.Writer(os.Stdout)
+	if opts.output != "" {
+		f, err := os.OpenFile(opts.output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create corpus file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	exported, dropped, err := app.writeCorpus(out, messages, opts.matched)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("exported redacted messages", "count", exported)
+	if dropped > 0 {
+		slog.Warn("left out messages whose redacted text no longer matches the same template", "count", dropped)
+	}
+	return nil
+}
+
+// writeCorpus writes redacted unmatched messages, and matched ones when
+// includeMatched is set. Ignored messages such as OTPs are never written.
+// A message is left out when redaction changes which template matches it.
+func (app *App) writeCorpus(w io.Writer, messages []*message.Message, includeMatched bool) (int, int, error) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	exported, dropped := 0, 0
+	for _, msg := range messages {
+		if msg.Sender == "Me" {
+			continue
+		}
+		original := app.matcher.Classify(msg.Sender, msg.Content)
+		if original.Ignored() || (original.Template != nil && !includeMatched) {
+			continue
+		}
+
+		content := redact.Redact(msg.Content)
+		redacted := app.matcher.Classify(msg.Sender, content)
+		if templateName(original.Template) != templateName(redacted.Template) || redacted.Ignored() {
+			dropped++
+			continue
+		}
+
+		record := corpusRecord{
+			Timestamp: msg.Timestamp,
+			Sender:    msg.Sender,
+			Content:   content,
+			Template:  templateName(redacted.Template),
+		}
+		if err := encoder.Encode(record); err != nil {
+			return exported, dropped, fmt.Errorf("failed to write corpus: %w", err)
+		}
+		exported++
+	}
+	return exported, dropped, nil
+}
+
+func templateName(tmpl template.Template) string {
+	if tmpl == nil {
+		return ""
+	}
+	return tmpl.Name()
+}
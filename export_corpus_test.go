@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestParseExportCorpusArgs(t *testing.T) {
+	opts, err := parseExportCorpusArgs([]string{"--matched", "--output", "corpus.ndjson"})
+	if err != nil {
+		t.Fatalf("parseExportCorpusArgs() error = %v", err)
+	}
+	if !opts.matched || opts.output != "corpus.ndjson" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseExportCorpusArgs([]string{"extra"}); err == nil {
+		t.Error("parseExportCorpusArgs() should reject unknown arguments")
+	}
+}
+
+func TestApp_writeCorpus(t *testing.T) {
+	app := NewAppWithFetcher(&config.Config{DataFilePath: filepath.Join(t.TempDir(), "data.json")}, &MockFetcher{})
+	now := time.Date(2024, 4, 8, 9, 27, 0, 0, time.UTC)
+	messages := []*message.Message{
+		{Timestamp: now, Sender: "EXIMBANK", Content: "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL"},
+		{Timestamp: now, Sender: "EXIMBANK", Content: "Parola Dvs. 482913"},
+		{Timestamp: now, Sender: "VICTORIABANK", Content: "Ati primit 500 MDL de la Ion Popescu pe card 4*1234"},
+	}
+
+	tests := []struct {
+		name           string
+		includeMatched bool
+		want           []string
+	}{
+		{
+			name: "unmatched only",
+			want: []string{"Ati primit 123 MDL de la Xxx Xxxxxxx pe card 1*1234"},
+		},
+		{
+			name:           "with matched",
+			includeMatched: true,
+			want: []string{
+				"Debitare cont Card 1..1234, Data 08.04.2024 09:27:01, Suma 1.12 MDL, Detalii LINELLA, Disponibil 12345.12 MDL",
+				"Ati primit 123 MDL de la Xxx Xxxxxxx pe card 1*1234",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			exported, dropped, err := app.writeCorpus(&out, messages, tt.includeMatched)
+			if err != nil {
+				t.Fatalf("writeCorpus() error = %v", err)
+			}
+			if exported != len(tt.want) || dropped != 0 {
+				t.Errorf("exported = %d, dropped = %d, want %d, 0", exported, dropped, len(tt.want))
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tt.want), out.String())
+			}
+			for i, line := range lines {
+				var record corpusRecord
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("invalid JSON line: %v", err)
+				}
+				if record.Content != tt.want[i] {
+					t.Errorf("content = %q, want %q", record.Content, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ module github.com/apmyp/ynab_importer_go
 
 go 1.24.0
 
-require modernc.org/sqlite v1.43.0
+require (
+	golang.org/x/text v0.34.0
+	modernc.org/sqlite v1.43.0
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -0,0 +1,161 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+const section = "sync_runs"
+
+// Only the most recent runs are kept, so the data file does not grow with
+// every hourly sync.
+const maxRuns = 200
+
+// Run records one sync: what was read, how far it got and what failed.
+type Run struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Sources counts the messages read per source type
+	Sources   map[string]int `json:"sources,omitempty"`
+	Messages  int            `json:"messages"`
+	Parsed    int            `json:"parsed"`
+	Ignored   int            `json:"ignored"`
+	Unmatched int            `json:"unmatched"`
+	Converted int            `json:"converted"`
+	Synced    int            `json:"synced"`
+	Skipped   int            `json:"skipped"`
+	Failed    int            `json:"failed"`
+	// Error is why the run stopped; Errors are the transactions that failed
+	Error  string   `json:"error,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+	// NewestMessages is the time of the newest message read per sender
+	NewestMessages map[string]time.Time `json:"newest_messages,omitempty"`
+}
+
+func NewRun(id, command string, startedAt time.Time) *Run {
+	return &Run{ID: id, Command: command, StartedAt: startedAt.UTC()}
+}
+
+// Succeeded reports whether the run finished without stopping on an error.
+// Transactions that failed on their own are counted in Failed instead.
+func (r *Run) Succeeded() bool {
+	return r.Error == ""
+}
+
+// AddMessages counts messages read from a source of the given type.
+func (r *Run) AddMessages(source string, messages []*message.Message) {
+	if r.Sources == nil {
+		r.Sources = make(map[string]int)
+	}
+	r.Sources[source] += len(messages)
+	r.Messages += len(messages)
+
+	for _, msg := range messages {
+		if r.NewestMessages == nil {
+			r.NewestMessages = make(map[string]time.Time)
+		}
+		if msg.Timestamp.After(r.NewestMessages[msg.Sender]) {
+			r.NewestMessages[msg.Sender] = msg.Timestamp.UTC()
+		}
+	}
+}
+
+// Finish ends the run with the error it stopped on, if any.
+func (r *Run) Finish(finishedAt time.Time, err error) {
+	r.FinishedAt = finishedAt.UTC()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+type Store struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+func (s *Store) read() ([]*Run, error) {
+	var runs []*Run
+	if err := datastore.ReadSection(s.filePath, section, &runs); err != nil {
+		return nil, fmt.Errorf("failed to read sync runs: %w", err)
+	}
+	return runs, nil
+}
+
+// Runs returns the recorded runs, oldest first.
+func (s *Store) Runs() ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+func (s *Store) Append(run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	runs = append(runs, run)
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+
+	if err := datastore.WriteSection(s.filePath, section, runs); err != nil {
+		return fmt.Errorf("failed to write sync runs: %w", err)
+	}
+	return nil
+}
+
+// LastSuccess returns the most recent run that succeeded, or nil.
+func LastSuccess(runs []*Run) *Run {
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Succeeded() {
+			return runs[i]
+		}
+	}
+	return nil
+}
+
+type SenderActivity struct {
+	Sender string
+	Newest time.Time
+}
+
+// NewestMessages returns the newest message time per sender over all runs,
+// most recent first.
+func NewestMessages(runs []*Run) []SenderActivity {
+	newest := make(map[string]time.Time)
+	for _, run := range runs {
+		for sender, at := range run.NewestMessages {
+			if at.After(newest[sender]) {
+				newest[sender] = at
+			}
+		}
+	}
+
+	activity := make([]SenderActivity, 0, len(newest))
+	for sender, at := range newest {
+		activity = append(activity, SenderActivity{Sender: sender, Newest: at})
+	}
+	sort.Slice(activity, func(i, j int) bool {
+		if !activity[i].Newest.Equal(activity[j].Newest) {
+			return activity[i].Newest.After(activity[j].Newest)
+		}
+		return activity[i].Sender < activity[j].Sender
+	})
+	return activity
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestStore_AppendAndRuns(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	ok := NewRun("a1", "ynab_sync", start)
+	ok.AddMessages("chatdb", []*message.Message{
+		{Sender: "102", Timestamp: start.Add(-2 * time.Hour)},
+		{Sender: "102", Timestamp: start.Add(-time.Hour)},
+		{Sender: "EXIMBANK", Timestamp: start.Add(-3 * time.Hour)},
+	})
+	ok.Synced = 2
+	ok.Failed = 1
+	ok.Finish(start.Add(time.Second), nil)
+
+	broken := NewRun("b2", "ynab_sync", start.Add(time.Hour))
+	broken.AddMessages("imap", []*message.Message{{Sender: "EXIMBANK", Timestamp: start.Add(30 * time.Minute)}})
+	broken.Finish(start.Add(time.Hour+time.Second), errors.New("YNAB API error 401"))
+
+	for _, run := range []*Run{ok, broken} {
+		if err := store.Append(run); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	runs, err := store.Runs()
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != "a1" || runs[1].ID != "b2" {
+		t.Fatalf("Runs() = %+v, want a1 then b2", runs)
+	}
+	if runs[0].Messages != 3 || runs[0].Sources["chatdb"] != 3 {
+		t.Errorf("first run counts = %+v", runs[0])
+	}
+	if runs[1].Succeeded() || runs[1].Error != "YNAB API error 401" {
+		t.Errorf("second run should have failed, got %+v", runs[1])
+	}
+
+	if last := LastSuccess(runs); last == nil || last.ID != "a1" {
+		t.Errorf("LastSuccess() = %+v, want a1 despite its failed transaction", last)
+	}
+
+	newest := NewestMessages(runs)
+	if len(newest) != 2 {
+		t.Fatalf("NewestMessages() = %+v, want 2 senders", newest)
+	}
+	if newest[0].Sender != "EXIMBANK" || !newest[0].Newest.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("newest sender = %+v, want EXIMBANK at 10:30", newest[0])
+	}
+	if newest[1].Sender != "102" || !newest[1].Newest.Equal(start.Add(-time.Hour)) {
+		t.Errorf("second sender = %+v, want 102 at 09:00", newest[1])
+	}
+}
+
+func TestStore_KeepsRecentRuns(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+
+	for i := 0; i < maxRuns+5; i++ {
+		if err := store.Append(&Run{ID: string(rune('a' + i%26)), Command: "ynab_sync"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	runs, err := store.Runs()
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	if len(runs) != maxRuns {
+		t.Errorf("kept %d runs, want %d", len(runs), maxRuns)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/statement"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const (
+	statementSender = "statement"
+	// Card postings usually appear on the statement a few days after the SMS
+	statementMatchWindow = 3 * 24 * time.Hour
+)
+
+var last4Regex = regexp.MustCompile(`\d{4}$`)
+
+type importStatementOptions struct {
+	path    string
+	format  string
+	account string
+}
+
+func parseImportStatementArgs(args []string) (*importStatementOptions, error) {
+	opts := &importStatementOptions{}
+
+	for len(args) > 0 {
+		if args[0] == "--format" && len(args) > 1 {
+			opts.format = args[1]
+			args = args[2:]
+		} else if args[0] == "--account" && len(args) > 1 {
+			opts.account = args[1]
+			args = args[2:]
+		} else if opts.path == "" {
+			opts.path = args[0]
+			args = args[1:]
+		} else {
+			return nil, fmt.Errorf("unexpected argument: %s", args[0])
+		}
+	}
+
+	if opts.path == "" {
+		return nil, fmt.Errorf("usage: import_statement [--format csv|ofx|mt940|camt053] [--account <last4>] <file>")
+	}
+	return opts, nil
+}
+
+func (app *App) statementCSVConfig() statement.CSVConfig {
+	if app.config.StatementCSV == nil {
+		return statement.CSVConfig{DefaultCurrency: app.config.DefaultCurrency}
+	}
+	cfg := statement.CSVConfig(*app.config.StatementCSV)
+	if cfg.DefaultCurrency == "" {
+		cfg.DefaultCurrency = app.config.DefaultCurrency
+	}
+	return cfg
+}
+
+// statementMessages wraps statement entries in synthetic messages so they can
+// go through the same mapper and syncer as SMS. Identical entries on the same
+// day are spread over consecutive seconds to keep their import IDs distinct
+// and stable across re-imports of the same file.
+func statementMessages(entries []statement.Entry, account string) []*ParsedMessage {
+	seen := make(map[string]int)
+	parsed := make([]*ParsedMessage, len(entries))
+
+	for i, entry := range entries {
+		tx := entry.Transaction
+		if account != "" {
+			tx.Card = account
+		}
+
+		key := fmt.Sprintf("%s|%s|%.2f|%s|%s", entry.Date.Format("2006-01-02"), tx.Card, tx.Original.Value, tx.Original.Currency, tx.Address)
+		offset := seen[key]
+		seen[key]++
+
+		parsed[i] = &ParsedMessage{
+			Message: &message.Message{
+				Timestamp: entry.Date.UTC().Add(time.Duration(offset) * time.Second),
+				Sender:    statementSender,
+				Content:   tx.RawMessage,
+			},
+			Transaction: tx,
+			HasTemplate: true,
+		}
+	}
+
+	return parsed
+}
+
+// dedupeStatement drops statement entries that were already seen as SMS:
+// same card, same converted amount, within statementMatchWindow. Each SMS
+// can cover at most one statement entry.
+func dedupeStatement(entries, sms []*ParsedMessage) ([]*ParsedMessage, int) {
+	used := make([]bool, len(sms))
+	var fresh []*ParsedMessage
+	duplicates := 0
+
+	for _, entry := range entries {
+		matched := false
+		for i, pm := range sms {
+			if used[i] || !pm.HasTemplate || pm.Transaction == nil {
+				continue
+			}
+			if !sameStatementTransaction(entry, pm) {
+				continue
+			}
+			used[i] = true
+			matched = true
+			break
+		}
+
+		if matched {
+			duplicates++
+			continue
+		}
+		fresh = append(fresh, entry)
+	}
+
+	return fresh, duplicates
+}
+
+func sameStatementTransaction(entry, sms *ParsedMessage) bool {
+	entryLast4 := last4Regex.FindString(entry.Transaction.Card)
+	if entryLast4 == "" || entryLast4 != last4Regex.FindString(sms.Transaction.Card) {
+		return false
+	}
+
+	if milliunits(entry.Transaction.Converted.Value) != milliunits(sms.Transaction.Converted.Value) {
+		return false
+	}
+
+	smsDate := sms.Message.Timestamp.UTC().Truncate(24 * time.Hour)
+	diff := entry.Message.Timestamp.Truncate(24 * time.Hour).Sub(smsDate)
+	return diff >= -24*time.Hour && diff <= statementMatchWindow
+}
+
+func milliunits(value float64) int64 {
+	return int64(math.Round(math.Abs(value) * 1000))
+}
+
+func (app *App) runImportStatement(args []string) error {
+	opts, err := parseImportStatementArgs(args)
+	if err != nil {
+		return err
+	}
+	app.interactive = true
+
+	entries, err := statement.ParseFile(opts.path, opts.format, app.statementCSVConfig())
+	if err != nil {
+		return err
+	}
+	slog.Info("parsed statement", "path", opts.path, "entries", len(entries))
+
+	apiKey, startDate, err := app.prepareYNABSync()
+	if err != nil {
+		return err
+	}
+
+	messages, cleanup, err := app.fetchMessages()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	smsParsed, _ := app.runParsed(newParsedPipeline(app.parseStage(), app.convertStage()), newParsedMessages(messages))
+
+	statementParsed, metrics := app.runParsed(newParsedPipeline(app.convertStage(), currencyStage(app.config.DefaultCurrency)),
+		statementMessages(entries, opts.account))
+	app.recordStatement(len(entries), metrics)
+
+	fresh, duplicates := dedupeStatement(statementParsed, smsParsed)
+	slog.Info("skipped statement entries already imported from SMS", "count", duplicates)
+
+	var filteredMessages []*message.Message
+	var filteredTransactions []*template.Transaction
+	for _, pm := range fresh {
+		filteredMessages = append(filteredMessages, pm.Message)
+		filteredTransactions = append(filteredTransactions, pm.Transaction)
+	}
+
+	slog.Info("found statement transactions to sync", "count", len(filteredTransactions))
+
+	if err := app.syncTransactions(apiKey, startDate, filteredMessages, filteredTransactions); err != nil {
+		return err
+	}
+
+	app.syncSinksAfterYNAB(startDate, filteredMessages, filteredTransactions)
+	return nil
+}
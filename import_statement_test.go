@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/statement"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func TestParseImportStatementArgs(t *testing.T) {
+	opts, err := parseImportStatementArgs([]string{"--format", "mt940", "statement.sta", "--account", "7890"})
+	if err != nil {
+		t.Fatalf("parseImportStatementArgs() error = %v", err)
+	}
+	if opts.path != "statement.sta" || opts.format != "mt940" || opts.account != "7890" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	if _, err := parseImportStatementArgs(nil); err == nil {
+		t.Error("parseImportStatementArgs() should require a file")
+	}
+	if _, err := parseImportStatementArgs([]string{"a.csv", "b.csv"}); err == nil {
+		t.Error("parseImportStatementArgs() should reject extra arguments")
+	}
+}
+
+func TestApp_statementCSVConfig(t *testing.T) {
+	app := NewAppWithFetcher(&config.Config{DefaultCurrency: "MDL"}, &MockFetcher{})
+	if got := app.statementCSVConfig(); got.DefaultCurrency != "MDL" {
+		t.Errorf("expected default currency MDL, got %q", got.DefaultCurrency)
+	}
+
+	app.config.StatementCSV = &config.StatementCSVConfig{Date: "Data", Amount: "Suma"}
+	got := app.statementCSVConfig()
+	if got.Date != "Data" || got.Amount != "Suma" || got.DefaultCurrency != "MDL" {
+		t.Errorf("unexpected CSV config %+v", got)
+	}
+}
+
+func statementEntry(date time.Time, amount float64, payee string) statement.Entry {
+	return statement.Entry{
+		Date: date,
+		Transaction: &template.Transaction{
+			Operation: statement.OperationDebit,
+			Card:      "MD24AG000225100013107890",
+			Original:  template.Amount{Value: amount, Currency: "MDL"},
+			Address:   payee,
+		},
+	}
+}
+
+func TestStatementMessages_DistinctTimestampsForIdenticalEntries(t *testing.T) {
+	date := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	entries := []statement.Entry{
+		statementEntry(date, 25, "COFFEE"),
+		statementEntry(date, 25, "COFFEE"),
+		statementEntry(date, 30, "COFFEE"),
+	}
+
+	parsed := statementMessages(entries, "1234")
+
+	if !parsed[0].Message.Timestamp.Equal(date) || !parsed[1].Message.Timestamp.Equal(date.Add(time.Second)) {
+		t.Errorf("identical entries should get consecutive timestamps, got %v and %v",
+			parsed[0].Message.Timestamp, parsed[1].Message.Timestamp)
+	}
+	if !parsed[2].Message.Timestamp.Equal(date) {
+		t.Errorf("different entry should keep the statement date, got %v", parsed[2].Message.Timestamp)
+	}
+	if parsed[0].Transaction.Card != "1234" || parsed[0].Message.Sender != statementSender {
+		t.Errorf("unexpected parsed message %+v", parsed[0])
+	}
+}
+
+func TestDedupeStatement(t *testing.T) {
+	date := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	entries := statementMessages([]statement.Entry{
+		statementEntry(date, 9.65, "MAIB GROCERY STORE"),
+		statementEntry(date, 9.65, "MAIB GROCERY STORE"),
+		statementEntry(date, 120, "PHARMACY"),
+		statementEntry(date.Add(10*24*time.Hour), 50, "LATE"),
+	}, "")
+	for _, e := range entries {
+		e.Transaction.Converted = e.Transaction.Original
+	}
+
+	sms := []*ParsedMessage{
+		{
+			Message:     &message.Message{Timestamp: time.Date(2026, 1, 10, 9, 27, 0, 0, time.UTC)},
+			Transaction: &template.Transaction{Card: "9..7890", Converted: template.Amount{Value: 9.65, Currency: "MDL"}},
+			HasTemplate: true,
+		},
+		{
+			Message:     &message.Message{Timestamp: time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)},
+			Transaction: &template.Transaction{Card: "*1234", Converted: template.Amount{Value: 120, Currency: "MDL"}},
+			HasTemplate: true,
+		},
+		{
+			Message:     &message.Message{Timestamp: time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)},
+			Transaction: &template.Transaction{Card: "9..7890", Converted: template.Amount{Value: 50, Currency: "MDL"}},
+			HasTemplate: true,
+		},
+		{
+			Message:     &message.Message{Timestamp: time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)},
+			HasTemplate: false,
+		},
+	}
+
+	fresh, duplicates := dedupeStatement(entries, sms)
+
+	if duplicates != 1 {
+		t.Errorf("expected 1 duplicate, got %d", duplicates)
+	}
+	// Second identical purchase, different card and outside window remain
+	if len(fresh) != 3 {
+		t.Fatalf("expected 3 fresh entries, got %d", len(fresh))
+	}
+	if fresh[1].Transaction.Address != "PHARMACY" || fresh[2].Transaction.Address != "LATE" {
+		t.Errorf("unexpected fresh entries %q, %q", fresh[1].Transaction.Address, fresh[2].Transaction.Address)
+	}
+}
+
+func TestApp_runImportStatement_MissingFile(t *testing.T) {
+	app := NewAppWithFetcher(&config.Config{}, &MockFetcher{})
+
+	if err := app.runImportStatement([]string{"/nonexistent/statement.csv"}); err == nil {
+		t.Error("runImportStatement() should return error for missing file")
+	}
+	if err := app.runImportStatement(nil); err == nil {
+		t.Error("runImportStatement() should return usage error without file")
+	}
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/apmyp/ynab_importer_go/redact"
+)
+
+const redacted = "[redacted]"
+
+// Attributes under these keys are replaced when redacting.
+var sensitiveKeys = map[string]bool{
+	"card":    true,
+	"amount":  true,
+	"balance": true,
+}
+
+// Attributes under these keys are free text that may mention cards or
+// amounts, so their digits are masked when redacting. Account names default
+// to "Card 1234" and errors name the card they failed on.
+var sensitiveTextKeys = map[string]bool{
+	"sms":     true,
+	"detail":  true,
+	"account": true,
+	"error":   true,
+}
+
+type Options struct {
+	// Level is debug, info, warn or error; empty means info
+	Level string
+	// Format is text or json; empty means text
+	Format string
+	// Unredacted logs card numbers and amounts as they are
+	Unredacted bool
+}
+
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: use debug, info, warn or error", opts.Level)
+		}
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if !opts.Unredacted {
+		handlerOpts.ReplaceAttr = redactAttr
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: use text or json", opts.Format)
+	}
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch {
+	case sensitiveKeys[a.Key]:
+		a.Value = slog.StringValue(redacted)
+	case sensitiveTextKeys[a.Key]:
+		a.Value = slog.StringValue(redact.Redact(a.Value.String()))
+	}
+	return a
+}
+
+// NewRunID returns a random ID to tell apart the log lines of runs, or of
+// requests served by one run.
+func NewRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNew_RedactsByDefault(t *testing.T) {
+	var b bytes.Buffer
+	logger, err := New(&b, Options{Format: "json"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Info("synced", "card", "*1234", "amount", -34500, "sms", "Karta *5678 Summa 34.50 MDL", "count", 2)
+
+	var line map[string]any
+	if err := json.Unmarshal(b.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if line["card"] != redacted || line["amount"] != redacted {
+		t.Errorf("card and amount should be redacted, got %v", line)
+	}
+	if sms := line["sms"].(string); strings.Contains(sms, "5678") || strings.Contains(sms, "34.50") {
+		t.Errorf("sms digits should be masked, got %q", sms)
+	}
+	if line["count"] != float64(2) {
+		t.Errorf("count = %v, want 2", line["count"])
+	}
+}
+
+func TestNew_RedactsAccountsAndErrors(t *testing.T) {
+	var b bytes.Buffer
+	logger, err := New(&b, Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Error("sync failed", "account", "Card 5678", "error", errors.New("failed to create account for card 5678"))
+
+	if strings.Contains(b.String(), "5678") {
+		t.Errorf("card digits should be masked, got %q", b.String())
+	}
+	if !strings.Contains(b.String(), "failed to create account for card") {
+		t.Errorf("error text should be kept, got %q", b.String())
+	}
+}
+
+func TestNew_Unredacted(t *testing.T) {
+	var b bytes.Buffer
+	logger, err := New(&b, Options{Unredacted: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Info("synced", "card", "*1234")
+
+	if !strings.Contains(b.String(), "card=*1234") {
+		t.Errorf("card should be logged as is, got %q", b.String())
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var b bytes.Buffer
+	logger, err := New(&b, Options{Level: "warn"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(b.String(), "hidden") || !strings.Contains(b.String(), "shown") {
+		t.Errorf("only warnings should be logged, got %q", b.String())
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Level: "loud"}); err == nil {
+		t.Error("New() should reject an unknown level")
+	}
+	if _, err := New(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("New() should reject an unknown format")
+	}
+}
@@ -1,21 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/apmyp/ynab_importer_go/chatdb"
 	"github.com/apmyp/ynab_importer_go/config"
 	"github.com/apmyp/ynab_importer_go/exchangerate"
+	"github.com/apmyp/ynab_importer_go/history"
+	"github.com/apmyp/ynab_importer_go/logging"
 	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/redact"
 	"github.com/apmyp/ynab_importer_go/system"
 	"github.com/apmyp/ynab_importer_go/template"
-	"github.com/apmyp/ynab_importer_go/worker"
 	"github.com/apmyp/ynab_importer_go/ynab"
 )
 
@@ -25,12 +29,14 @@ type MessageFetcher interface {
 }
 
 type ChatDBFetcher struct {
-	config *config.Config
+	dbPath  string
+	senders []string
 }
 
 func NewChatDBFetcher(cfg *config.Config) *ChatDBFetcher {
 	return &ChatDBFetcher{
-		config: cfg,
+		dbPath:  cfg.DBPath,
+		senders: cfg.Senders,
 	}
 }
 
@@ -52,7 +58,7 @@ func expandPath(path string) (string, error) {
 }
 
 func (f *ChatDBFetcher) CheckDependencies() error {
-	dbPath, err := expandPath(f.config.DBPath)
+	dbPath, err := expandPath(f.dbPath)
 	if err != nil {
 		return err
 	}
@@ -64,12 +70,12 @@ func (f *ChatDBFetcher) CheckDependencies() error {
 }
 
 func (f *ChatDBFetcher) FetchMessages() ([]*message.Message, func(), error) {
-	dbPath, err := expandPath(f.config.DBPath)
+	dbPath, err := expandPath(f.dbPath)
 	if err != nil {
 		return nil, func() {}, err
 	}
 
-	reader, err := chatdb.NewReader(dbPath, f.config.Senders)
+	reader, err := chatdb.NewReader(dbPath, f.senders)
 	if err != nil {
 		return nil, func() {}, fmt.Errorf("failed to open chat.db: %w", err)
 	}
@@ -82,11 +88,11 @@ func (f *ChatDBFetcher) FetchMessages() ([]*message.Message, func(), error) {
 
 	cleanup := func() {
 		if err := reader.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close chat.db: %v\n", err)
+			slog.Warn("failed to close chat.db", "error", err)
 		}
 	}
 
-	fmt.Printf("Loaded %d messages from chat.db\n", len(messages))
+	slog.Info("loaded messages", "source", "chat.db", "count", len(messages))
 	return messages, cleanup, nil
 }
 
@@ -95,26 +101,59 @@ type App struct {
 	configPath string
 	fetcher    MessageFetcher
 	matcher    *template.Matcher
-	pool       *worker.Pool
 	converter  *exchangerate.Converter
+	// interactive commands may ask before creating YNAB accounts;
+	// assumeYes creates them without asking
+	interactive bool
+	assumeYes   bool
+	// budgetChoice names the YNAB budget for targets without budget_id
+	budgetChoice string
+	// showMetrics logs pipeline stage metrics at info level, for commands
+	// run by hand
+	showMetrics bool
+	runID       string
+	// run collects the counts of a sync command for its history
+	run *history.Run
 }
 
 func createExchangeRateStore(dataFilePath string) *exchangerate.Store {
 	store, err := exchangerate.NewStore(dataFilePath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to initialize exchange rate store: %v\n", err)
+		slog.Warn("failed to initialize exchange rate store", "error", err)
 		return nil
 	}
 	return store
 }
 
+// newMatcher adds the templates and ignore rules declared in the config
+// after the built-in ones. Invalid declarations are reported and skipped.
+func newMatcher(cfg *config.Config) *template.Matcher {
+	matcher := template.NewMatcher()
+	for _, tc := range cfg.Templates {
+		tmpl, err := template.NewRegexTemplate(template.Definition(tc))
+		if err != nil {
+			slog.Warn("skipping template", "error", err)
+			continue
+		}
+		matcher.AddTemplates(tmpl)
+	}
+	for _, ic := range cfg.Ignore {
+		rule, err := template.NewIgnoreRule(ic.Sender, ic.Contains, ic.Regex, ic.Priority)
+		if err != nil {
+			slog.Warn("skipping ignore rule", "error", err)
+			continue
+		}
+		matcher.AddIgnoreRules(rule)
+	}
+	return matcher
+}
+
 func NewApp(cfg *config.Config, configPath string) *App {
 	return &App{
 		config:     cfg,
 		configPath: configPath,
-		fetcher:    NewChatDBFetcher(cfg),
-		matcher:    template.NewMatcher(),
-		pool:       worker.NewPool(runtime.NumCPU()),
+		fetcher:    NewMultiFetcher(cfg),
+		matcher:    newMatcher(cfg),
 		converter:  exchangerate.NewConverter(createExchangeRateStore(cfg.DataFilePath), exchangerate.NewFetcher(), cfg.DefaultCurrency),
 	}
 }
@@ -123,8 +162,7 @@ func NewAppWithFetcher(cfg *config.Config, fetcher MessageFetcher) *App {
 	return &App{
 		config:    cfg,
 		fetcher:   fetcher,
-		matcher:   template.NewMatcher(),
-		pool:      worker.NewPool(runtime.NumCPU()),
+		matcher:   newMatcher(cfg),
 		converter: exchangerate.NewConverter(createExchangeRateStore(cfg.DataFilePath), exchangerate.NewFetcher(), cfg.DefaultCurrency),
 	}
 }
@@ -133,27 +171,57 @@ type ParsedMessage struct {
 	Message     *message.Message
 	Transaction *template.Transaction
 	HasTemplate bool
+	// match is the message's classification, if already known
+	match *template.Match
 }
 
 func Run(args []string) error {
 	configPath := "config.json"
 	dataFilePath := ""
+	var logOpts logging.Options
 
 	for len(args) > 0 {
-		if args[0] == "--config" && len(args) > 1 {
-			configPath = args[1]
-			args = args[2:]
-		} else if args[0] == "--data-file" && len(args) > 1 {
-			dataFilePath = args[1]
-			args = args[2:]
+		if value, rest, ok := globalOption(args, "--config"); ok {
+			configPath, args = value, rest
+		} else if value, rest, ok := globalOption(args, "--data-file"); ok {
+			dataFilePath, args = value, rest
+		} else if value, rest, ok := globalOption(args, "--log-level"); ok {
+			logOpts.Level, args = value, rest
+		} else if value, rest, ok := globalOption(args, "--log-format"); ok {
+			logOpts.Format, args = value, rest
+		} else if args[0] == "--log-unredacted" {
+			logOpts.Unredacted = true
+			args = args[1:]
 		} else {
 			break
 		}
 	}
 
+	logger, err := logging.New(os.Stderr, logOpts)
+	if err != nil {
+		return err
+	}
+	runID := logging.NewRunID()
+	slog.SetDefault(logger.With("run_id", runID))
+
+	command := "ynab_sync"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	// Sync runs are recorded from here on, so that the history also shows
+	// the ones that failed before reading anything
+	var run *history.Run
+	if recordedCommands[command] {
+		run = history.NewRun(runID, command, time.Now())
+	}
+
 	cfg, err := config.Load(configPath)
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		if dataFilePath == "" {
+			dataFilePath = config.DefaultDataFilePath
+		}
+		return recordRun(dataFilePath, run, fmt.Errorf("loading config: %w", err))
 	}
 
 	if dataFilePath != "" {
@@ -161,21 +229,42 @@ func Run(args []string) error {
 	}
 
 	app := NewApp(cfg, configPath)
+	app.runID = runID
+	app.run = run
 
-	if err := app.fetcher.CheckDependencies(); err != nil {
-		return err
-	}
+	return recordRun(cfg.DataFilePath, run, app.runCommand(command, args))
+}
 
-	command := "ynab_sync"
-	if len(args) > 0 {
-		command = args[0]
+func (app *App) runCommand(command string, args []string) error {
+	// The webhook receiver gets its messages over HTTP, parse reads a single
+	// message and status only reads the data file, so none of them needs
+	// access to the configured sources.
+	if command != "serve" && command != "parse" && command != "status" {
+		if err := app.fetcher.CheckDependencies(); err != nil {
+			return err
+		}
 	}
 
 	switch command {
 	case "missing_templates":
+		if len(args) > 1 && args[1] == "--suggest" {
+			return app.runSuggestTemplates()
+		}
 		return app.runMissingTemplates()
+	case "export_corpus":
+		return app.runExportCorpus(args[1:])
+	case "parse":
+		return app.runParse(args[1:])
 	case "ynab_sync":
-		return app.runYNABSync()
+		return app.runYNABSync(args[1:])
+	case "sink_sync":
+		return app.runSinkSync()
+	case "import_statement":
+		return app.runImportStatement(args[1:])
+	case "serve":
+		return app.runServe()
+	case "status":
+		return app.runStatus(args[1:])
 	case "system_install":
 		return app.runSystemInstall()
 	case "system_uninstall":
@@ -192,31 +281,19 @@ func (app *App) runMissingTemplates() error {
 	}
 	defer cleanup()
 
+	app.showMetrics = true
+	matches := app.classifyMessages(messages)
+
 	fmt.Println("Messages without matching templates:")
 	fmt.Println("=====================================")
 
-	type checkResult struct {
-		hasTemplate  bool
-		shouldIgnore bool
-	}
-	results := make([]checkResult, len(messages))
-	app.pool.Map(len(messages), func(i int) {
-		content := messages[i].Content
-		results[i] = checkResult{
-			hasTemplate:  app.matcher.FindTemplate(content) != nil,
-			shouldIgnore: app.matcher.ShouldIgnore(content),
-		}
-	})
-
-	count := 0
-	for i, msg := range messages {
-		if msg.Sender == "Me" {
+	unmatched := 0
+	for i, match := range matches {
+		if !isUnmatched(match) {
 			continue
 		}
-		if results[i].hasTemplate || results[i].shouldIgnore {
-			continue
-		}
-		count++
+		unmatched++
+		msg := messages[i]
 		fmt.Printf("\n[%s] %s: [%d chars]\n",
 			msg.Timestamp.Format("2006-01-02 15:04:05"),
 			msg.Sender,
@@ -224,135 +301,353 @@ func (app *App) runMissingTemplates() error {
 		fmt.Println("---")
 	}
 
-	fmt.Printf("\nTotal messages without templates: %d\n", count)
+	fmt.Printf("\nTotal messages without templates: %d\n", unmatched)
+
+	ambiguous := 0
+	for i, match := range matches {
+		if match == nil || !match.Ambiguous {
+			continue
+		}
+		if ambiguous == 0 {
+			fmt.Println("\nAmbiguous matches (same priority):")
+			fmt.Println("=====================================")
+		}
+		ambiguous++
+
+		msg := messages[i]
+		winner := "ignored by " + fmt.Sprint(match.Ignore)
+		if match.Template != nil {
+			winner = match.Template.Name()
+		}
+		fmt.Printf("\n[%s] %s: resolved to %s\n",
+			msg.Timestamp.Format("2006-01-02 15:04:05"), msg.Sender, winner)
+		for _, candidate := range match.Candidates() {
+			fmt.Printf("  - %s\n", candidate)
+		}
+		fmt.Println(redact.Redact(msg.Content))
+		fmt.Println("---")
+	}
+	if ambiguous > 0 {
+		fmt.Printf("\nTotal ambiguous messages: %d\n", ambiguous)
+	}
 	return nil
 }
 
+// classifyMessages checks received messages against every template and
+// ignore rule. Messages sent by the user get a nil entry.
+func (app *App) classifyMessages(messages []*message.Message) []*template.Match {
+	items := make([]*classifiedMessage, len(messages))
+	for i, msg := range messages {
+		items[i] = &classifiedMessage{msg: msg}
+	}
+
+	// Every message passes the stage, so the matches line up with messages
+	classified, metrics, _ := app.classifyPipeline().Collect(context.Background(), items)
+	app.writeMetrics(metrics)
+
+	matches := make([]*template.Match, len(classified))
+	for i, c := range classified {
+		matches[i] = c.match
+	}
+	return matches
+}
+
+// unmatchedMessages returns received messages that match no template and
+// no ignore rule.
+func (app *App) unmatchedMessages(messages []*message.Message) []*message.Message {
+	var unmatched []*message.Message
+	for i, match := range app.classifyMessages(messages) {
+		if isUnmatched(match) {
+			unmatched = append(unmatched, messages[i])
+		}
+	}
+	return unmatched
+}
+
+func isUnmatched(match *template.Match) bool {
+	return match != nil && match.Template == nil && !match.Ignored()
+}
+
 func (app *App) fetchMessages() ([]*message.Message, func(), error) {
-	return app.fetcher.FetchMessages()
+	messages, cleanup, err := app.fetcher.FetchMessages()
+	if err != nil || app.run == nil {
+		return messages, cleanup, err
+	}
+
+	if multi, ok := app.fetcher.(*MultiFetcher); ok {
+		for source, fetched := range multi.Fetched() {
+			app.run.AddMessages(source, fetched)
+		}
+	} else {
+		app.run.AddMessages("default", messages)
+	}
+	return messages, cleanup, nil
 }
 
 func (app *App) parseMessage(msg *message.Message) *ParsedMessage {
-	tx, err := app.matcher.Parse(msg.Content)
-	return &ParsedMessage{
-		Message:     msg,
-		Transaction: tx,
-		HasTemplate: err == nil,
+	return parseMatch(msg, app.matcher.Classify(msg.Sender, msg.Content))
+}
+
+func parseMatch(msg *message.Message, match *template.Match) *ParsedMessage {
+	pm := &ParsedMessage{Message: msg}
+	if match.Template == nil {
+		return pm
 	}
+
+	tx, err := match.Template.Parse(msg.Content)
+	pm.Transaction = tx
+	pm.HasTemplate = err == nil
+	return pm
 }
 
 func (app *App) validateYNABConfig() error {
-	if app.config.YNAB.BudgetID == "" {
-		return fmt.Errorf("YNAB budget_id not configured")
+	targets, _ := app.budgetTargets()
+	for _, target := range targets {
+		if target.BudgetID == "" {
+			return fmt.Errorf("YNAB budget_id not configured")
+		}
+		if target.StartDate == "" && app.config.YNAB.StartDate == "" {
+			return fmt.Errorf("YNAB start_date not configured")
+		}
+		for _, acc := range target.Accounts {
+			if err := accountState(acc).Validate(); err != nil {
+				return fmt.Errorf("YNAB account %s: %w", acc.Last4, err)
+			}
+		}
+		if err := app.setMemoTemplates(ynab.NewMapper(nil), target.Accounts); err != nil {
+			return err
+		}
 	}
-	if app.config.YNAB.StartDate == "" {
-		return fmt.Errorf("YNAB start_date not configured")
+	for i, rule := range app.stateRules() {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("YNAB state rule %d: %w", i+1, err)
+		}
 	}
 	return nil
 }
 
-func (app *App) fetchFirstBudgetID(client *ynab.HTTPClient) (string, error) {
-	resp, err := client.GetBudgets()
-	if err != nil {
-		return "", err
+// setMemoTemplates gives the mapper the configured memo formats.
+func (app *App) setMemoTemplates(mapper *ynab.Mapper, accounts []config.YNABAccount) error {
+	if text := app.config.YNAB.MemoTemplate; text != "" {
+		memo, err := ynab.NewMemoTemplate(text)
+		if err != nil {
+			return fmt.Errorf("YNAB memo_template: %w", err)
+		}
+		mapper.SetMemoTemplate("", memo)
 	}
-	if len(resp.Data.Budgets) == 0 {
-		return "", fmt.Errorf("no budgets found in YNAB account")
+	for _, acc := range accounts {
+		if acc.MemoTemplate == "" || acc.YNABAccountID == "" {
+			continue
+		}
+		memo, err := ynab.NewMemoTemplate(acc.MemoTemplate)
+		if err != nil {
+			return fmt.Errorf("YNAB account %s memo_template: %w", acc.Last4, err)
+		}
+		mapper.SetMemoTemplate(acc.YNABAccountID, memo)
 	}
-	return resp.Data.Budgets[0].ID, nil
+	return nil
+}
+
+func accountState(acc config.YNABAccount) ynab.State {
+	return ynab.State{Cleared: acc.Cleared, Approved: acc.Approved, FlagColor: acc.FlagColor}
+}
+
+func (app *App) stateRules() []ynab.StateRule {
+	rules := make([]ynab.StateRule, 0, len(app.config.YNAB.StateRules))
+	for _, r := range app.config.YNAB.StateRules {
+		rules = append(rules, ynab.StateRule{
+			Sender:    r.Sender,
+			Cards:     r.Cards,
+			Kind:      r.Kind,
+			Direction: r.Direction,
+			Status:    r.Status,
+			MinAmount: r.MinAmount,
+			Foreign:   r.Foreign,
+			State:     ynab.State{Cleared: r.Cleared, Approved: r.Approved, FlagColor: r.FlagColor},
+		})
+	}
+	return rules
 }
 
-func (app *App) convertTransactions(parsedMessages []*ParsedMessage) {
+// convertTransaction sets tx.Converted, falling back to the original amount
+// when no rate is available.
+func (app *App) convertTransaction(msg *message.Message, tx *template.Transaction) (float64, error) {
 	if app.converter == nil {
-		return
+		tx.Converted = tx.Original
+		for i := range tx.Components {
+			tx.Components[i].Converted = tx.Components[i].Original
+		}
+		return 1, nil
 	}
 
-	for _, pm := range parsedMessages {
-		if pm == nil || !pm.HasTemplate || pm.Transaction == nil {
-			continue
-		}
+	date := msg.Timestamp.UTC().Truncate(24 * time.Hour)
 
-		tx := pm.Transaction
-		date := pm.Message.Timestamp.UTC().Truncate(24 * time.Hour)
+	rate, err := app.converter.GetOrFetchRate(date, tx.Original.Currency)
+	if err != nil {
+		tx.Converted = tx.Original
+		return 0, fmt.Errorf("failed to get exchange rate for %s on %s: %w",
+			tx.Original.Currency, date.Format("2006-01-02"), err)
+	}
 
-		rate, err := app.converter.GetOrFetchRate(date, tx.Original.Currency)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get exchange rate for %s on %s: %v\n",
-				tx.Original.Currency, date.Format("2006-01-02"), err)
-			tx.Converted = tx.Original
-			continue
-		}
+	tx.Converted = template.Amount{
+		Value:    tx.Original.Value * rate,
+		Currency: app.config.DefaultCurrency,
+	}
 
-		tx.Converted = template.Amount{
-			Value:    tx.Original.Value * rate,
+	for i := range tx.Components {
+		c := &tx.Components[i]
+		componentRate := rate
+		if c.Original.Currency != tx.Original.Currency {
+			componentRate, err = app.converter.GetOrFetchRate(date, c.Original.Currency)
+			if err != nil {
+				c.Converted = c.Original
+				return 0, fmt.Errorf("failed to get exchange rate for %s on %s: %w",
+					c.Original.Currency, date.Format("2006-01-02"), err)
+			}
+		}
+		c.Converted = template.Amount{
+			Value:    c.Original.Value * componentRate,
 			Currency: app.config.DefaultCurrency,
 		}
 	}
+	return rate, nil
 }
 
-func (app *App) runYNABSync() error {
+func (app *App) runYNABSync(args []string) error {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--yes" || args[i] == "-y":
+			app.assumeYes = true
+		case args[i] == "--budget" && i+1 < len(args):
+			app.budgetChoice = args[i+1]
+			i++
+		default:
+			return fmt.Errorf("unknown ynab_sync argument: %s", args[i])
+		}
+	}
+	app.interactive = true
+	app.showMetrics = true
+
+	apiKey, startDate, err := app.prepareYNABSync()
+	if err != nil {
+		return err
+	}
+
+	messages, cleanup, err := app.fetchMessages()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return app.syncMessages(apiKey, startDate, messages)
+}
+
+func (app *App) prepareYNABSync() (string, time.Time, error) {
 	apiKey := os.Getenv("YNAB_API_KEY")
 	if apiKey == "" {
-		return fmt.Errorf("YNAB_API_KEY environment variable not set")
+		return "", time.Time{}, fmt.Errorf("YNAB_API_KEY environment variable not set")
+	}
+
+	if err := app.chooseMissingBudgets(apiKey); err != nil {
+		return "", time.Time{}, err
 	}
 
-	if app.config.YNAB.BudgetID == "" {
-		client := ynab.NewHTTPClient(apiKey)
-		budgetID, err := app.fetchFirstBudgetID(client)
-		client.ClearAPIKey()
+	if err := app.validateYNABConfig(); err != nil {
+		return "", time.Time{}, err
+	}
+
+	// Sinks get everything since the earliest budget's start date
+	var startDate time.Time
+	targets, _ := app.budgetTargets()
+	for _, target := range targets {
+		date, err := app.budgetStartDate(target)
 		if err != nil {
-			return fmt.Errorf("failed to fetch budget ID: %w", err)
+			return "", time.Time{}, err
 		}
-		app.config.YNAB.BudgetID = budgetID
-		if err := app.config.Save(app.configPath); err != nil {
-			return fmt.Errorf("failed to save config: %w", err)
+		if startDate.IsZero() || date.Before(startDate) {
+			startDate = date
 		}
-		fmt.Printf("Saved budget ID %s to config\n", budgetID)
 	}
 
-	if err := app.validateYNABConfig(); err != nil {
-		return err
+	return apiKey, startDate, nil
+}
+
+func (app *App) chooseMissingBudgets(apiKey string) error {
+	targets, save := app.budgetTargets()
+
+	var missing []*config.BudgetConfig
+	for _, target := range targets {
+		if target.BudgetID == "" {
+			missing = append(missing, target)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if app.budgetChoice != "" && len(missing) > 1 {
+		return fmt.Errorf("--budget is ambiguous: %d budgets have no budget_id", len(missing))
 	}
 
-	startDate, err := time.Parse("2006-01-02", app.config.YNAB.StartDate)
-	if err != nil {
-		return fmt.Errorf("invalid YNAB start_date format: %w", err)
+	client := ynab.NewHTTPClient(apiKey)
+	defer client.ClearAPIKey()
+
+	for _, target := range missing {
+		budgetID, err := app.chooseBudget(client, target, app.budgetChoice)
+		if err != nil {
+			return fmt.Errorf("failed to fetch budget ID: %w", err)
+		}
+		target.BudgetID = budgetID
+		slog.Info("saved budget ID to config", "budget_id", budgetID)
 	}
 
-	messages, cleanup, err := app.fetchMessages()
+	save()
+	if err := app.config.Save(app.configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+func (app *App) budgetStartDate(target *config.BudgetConfig) (time.Time, error) {
+	value := target.StartDate
+	if value == "" {
+		value = app.config.YNAB.StartDate
+	}
+	startDate, err := time.Parse("2006-01-02", value)
 	if err != nil {
-		return err
+		return time.Time{}, fmt.Errorf("invalid YNAB start_date format: %w", err)
 	}
-	defer cleanup()
+	return startDate, nil
+}
 
-	parsedMessages := make([]*ParsedMessage, len(messages))
-	var mu sync.Mutex
+func (app *App) syncMessages(apiKey string, startDate time.Time, messages []*message.Message) error {
+	filteredMessages, filteredTransactions := app.syncableTransactions(messages)
 
-	app.pool.Map(len(messages), func(i int) {
-		parsed := app.parseMessage(messages[i])
-		mu.Lock()
-		parsedMessages[i] = parsed
-		mu.Unlock()
-	})
+	if err := app.syncTransactions(apiKey, startDate, filteredMessages, filteredTransactions); err != nil {
+		return err
+	}
 
-	app.convertTransactions(parsedMessages)
+	app.syncSinksAfterYNAB(startDate, filteredMessages, filteredTransactions)
+	return nil
+}
 
-	var filteredMessages []*message.Message
-	var filteredTransactions []*template.Transaction
-	for _, pm := range parsedMessages {
-		if pm != nil && pm.HasTemplate && pm.Transaction != nil {
-			if strings.HasPrefix(pm.Transaction.Status, "Decline") {
-				continue
-			}
+func (app *App) syncableTransactions(messages []*message.Message) ([]*message.Message, []*template.Transaction) {
+	passed, metrics := app.runParsed(app.syncPipeline(), newParsedMessages(messages))
+	app.recordMetrics(metrics)
+	passed = app.dropAggregatorCopies(passed)
 
-			if pm.Transaction.Converted.Currency == "MDL" {
-				filteredMessages = append(filteredMessages, pm.Message)
-				filteredTransactions = append(filteredTransactions, pm.Transaction)
-			}
-		}
+	filteredMessages := make([]*message.Message, len(passed))
+	filteredTransactions := make([]*template.Transaction, len(passed))
+	for i, pm := range passed {
+		filteredMessages[i] = pm.Message
+		filteredTransactions[i] = pm.Transaction
 	}
 
-	fmt.Printf("Found %d MDL transactions to sync\n", len(filteredTransactions))
+	slog.Info("found transactions to sync", "count", len(filteredTransactions), "currency", "MDL")
+
+	return filteredMessages, filteredTransactions
+}
+
+func (app *App) syncTransactions(apiKey string, startDate time.Time, filteredMessages []*message.Message, filteredTransactions []*template.Transaction) error {
 
 	syncStore, err := ynab.NewSyncStore(app.config.DataFilePath)
 	if err != nil {
@@ -363,52 +658,223 @@ func (app *App) runYNABSync() error {
 	client := ynab.NewHTTPClient(apiKey)
 	defer client.ClearAPIKey()
 
-	accountManager := ynab.NewAccountManager(client)
+	targets, save := app.budgetTargets()
+	routes, unrouted := routeTransactions(targets, filteredMessages, filteredTransactions)
+	if len(unrouted) > 0 {
+		slog.Warn("transactions match no budget's senders or cards", "count", len(unrouted))
+	}
+
+	for i, target := range targets {
+		if len(routes[i]) == 0 && len(targets) > 1 {
+			continue
+		}
+
+		messages := make([]*message.Message, len(routes[i]))
+		transactions := make([]*template.Transaction, len(routes[i]))
+		for j, idx := range routes[i] {
+			messages[j] = filteredMessages[idx]
+			transactions[j] = filteredTransactions[idx]
+		}
+
+		budgetStart := startDate
+		if target.StartDate != "" {
+			if budgetStart, err = app.budgetStartDate(target); err != nil {
+				return err
+			}
+		}
+
+		if err := app.syncBudget(client, syncStore, target, save, budgetStart, messages, transactions, len(targets) > 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *App) syncBudget(client *ynab.HTTPClient, syncStore *ynab.SyncStore, target *config.BudgetConfig, save func(), startDate time.Time, filteredMessages []*message.Message, filteredTransactions []*template.Transaction, labelled bool) error {
+	accountManager := ynab.NewAccountManager(client, ynab.AccountOptions{
+		Type:         app.config.YNAB.AccountType,
+		NameTemplate: app.config.YNAB.AccountName,
+		ExactMatch:   app.config.YNAB.ExactAccountMatch,
+		Confirm:      app.confirmAccount,
+	})
 	updatedAccounts, err := accountManager.EnsureAccounts(
-		app.config.YNAB.BudgetID,
-		app.config.YNAB.Accounts,
+		target.BudgetID,
+		target.Accounts,
+		filteredMessages,
 		filteredTransactions,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to ensure accounts: %w", err)
 	}
 
-	if len(updatedAccounts) > len(app.config.YNAB.Accounts) {
-		numNewAccounts := len(updatedAccounts) - len(app.config.YNAB.Accounts)
-		app.config.YNAB.Accounts = updatedAccounts
+	if changed := countAccountChanges(target.Accounts, updatedAccounts); changed > 0 {
+		target.Accounts = updatedAccounts
+		save()
 		if err := app.config.Save(app.configPath); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
-		fmt.Printf("Added %d new account(s) to config\n", numNewAccounts)
+		slog.Info("added new accounts to config", "budget_id", target.BudgetID, "count", changed)
 	}
 
-	ynabAccounts := make([]ynab.YNABAccount, len(updatedAccounts))
-	for i, acc := range updatedAccounts {
-		ynabAccounts[i] = ynab.YNABAccount{
-			YNABAccountID: acc.YNABAccountID,
-			Last4:         acc.Last4,
-		}
+	// The mirror is only an aid, so a failed refresh does not stop the sync
+	mirror := ynab.NewMirror(app.config.DataFilePath)
+	mirrored, changes, err := mirror.Refresh(client, target.BudgetID)
+	if err != nil {
+		slog.Warn("failed to refresh YNAB mirror", "budget_id", target.BudgetID, "error", err)
+	} else {
+		slog.Debug("refreshed YNAB mirror", "budget_id", target.BudgetID, "changes", changes)
 	}
 
-	mapper := ynab.NewMapper(ynabAccounts)
-	syncer := ynab.NewSyncer(syncStore, client, mapper, app.config.YNAB.BudgetID, startDate)
+	mapper := ynab.NewMapper(mapperAccounts(updatedAccounts, ""))
+	mapper.SetStateRules(app.stateRules())
+	if err := app.setMemoTemplates(mapper, updatedAccounts); err != nil {
+		return err
+	}
+	if err := app.setComponentCategories(client, mapper, target.BudgetID, filteredTransactions); err != nil {
+		return err
+	}
+	syncer := ynab.NewSyncer(syncStore, client, mapper, target.BudgetID, startDate)
+	syncer.SetLifecycleStore(ynab.NewLifecycleStore(app.config.DataFilePath))
+	if window := app.matchWindowDays(); mirrored != nil && window >= 0 {
+		syncer.SetManualTransactions(mirrored.Transactions, window)
+	}
 
 	result, err := syncer.Sync(filteredMessages, filteredTransactions)
 	if err != nil {
 		return fmt.Errorf("sync failed: %w", err)
 	}
 
-	fmt.Printf("\nSync Results:\n")
-	fmt.Printf("  Total transactions: %d\n", result.Total)
-	fmt.Printf("  Synced: %d\n", result.Synced)
-	fmt.Printf("  Skipped: %d\n", result.Skipped)
-	if len(result.Failed) > 0 {
-		fmt.Printf("  Failed: %d\n", len(result.Failed))
-		for _, failure := range result.Failed {
-			fmt.Printf("    - %s\n", failure)
+	name := "YNAB"
+	if labelled {
+		name = "YNAB " + budgetLabel(target)
+	}
+	logSyncResult(name, result)
+	app.recordSyncResult(result)
+
+	return nil
+}
+
+const defaultMatchWindowDays = 3
+
+func (app *App) matchWindowDays() int {
+	if app.config.YNAB.MatchWindowDays == 0 {
+		return defaultMatchWindowDays
+	}
+	return app.config.YNAB.MatchWindowDays
+}
+
+// countAccountChanges counts mappings that are new or got an account ID.
+func countAccountChanges(before, after []config.YNABAccount) int {
+	changed := len(after) - len(before)
+	for i := range before {
+		if before[i].YNABAccountID == "" && after[i].YNABAccountID != "" {
+			changed++
+		}
+	}
+	return changed
+}
+
+// mapperAccounts converts the configured mappings for the YNAB mapper.
+// Entries still without an account get placeholder as their ID, or are
+// left out if it is empty.
+func mapperAccounts(accounts []config.YNABAccount, placeholder string) []ynab.YNABAccount {
+	result := make([]ynab.YNABAccount, 0, len(accounts))
+	for _, acc := range accounts {
+		id := acc.YNABAccountID
+		if id == "" {
+			id = placeholder
 		}
+		if id == "" {
+			continue
+		}
+		result = append(result, ynab.YNABAccount{
+			YNABAccountID: id,
+			Last4:         acc.Last4,
+			Cards:         acc.Cards,
+			Sender:        acc.Sender,
+			State:         accountState(acc),
+		})
 	}
+	return result
+}
 
+// confirmAccount asks before an account is created for a new card. Without
+// a terminal to ask on, e.g. in the background job or the webhook
+// receiver, only auto_create_accounts or --yes allows it.
+func (app *App) confirmAccount(name, accountType string) bool {
+	if app.config.YNAB.AutoCreateAccounts || app.assumeYes {
+		return true
+	}
+
+	info, err := os.Stdin.Stat()
+	if !app.interactive || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		slog.Warn("not creating YNAB account; run ynab_sync interactively, with --yes, or set ynab.auto_create_accounts", "account", name)
+		return false
+	}
+
+	return confirm(os.Stdin, os.Stdout, fmt.Sprintf("Create YNAB %s account %q?", accountType, name))
+}
+
+func confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+const defaultFeeCategory = "Bank fees"
+
+type categoryLister interface {
+	GetCategories(budgetID string) (*ynab.GetCategoriesResponse, error)
+}
+
+// setComponentCategories looks up the fee and cashback categories, only
+// when some transaction has such components. A missing default category
+// leaves those split parts uncategorized; only a configured one must exist.
+func (app *App) setComponentCategories(client categoryLister, mapper *ynab.Mapper, budgetID string, transactions []*template.Transaction) error {
+	kinds := make(map[template.Kind]bool)
+	for _, tx := range transactions {
+		for _, c := range tx.Components {
+			kinds[c.Kind] = true
+		}
+	}
+
+	feeCategory := app.config.YNAB.FeeCategory
+	if feeCategory == "" {
+		feeCategory = defaultFeeCategory
+	}
+	names := map[template.Kind]string{
+		template.KindFee:      feeCategory,
+		template.KindCashback: app.config.YNAB.CashbackCategory,
+	}
+	configured := map[template.Kind]bool{
+		template.KindFee:      app.config.YNAB.FeeCategory != "",
+		template.KindCashback: app.config.YNAB.CashbackCategory != "",
+	}
+
+	var categories *ynab.GetCategoriesResponse
+	for kind, name := range names {
+		if !kinds[kind] || name == "" {
+			continue
+		}
+		if categories == nil {
+			var err error
+			categories, err = client.GetCategories(budgetID)
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+		}
+		id, err := categories.FindCategoryID(name)
+		if err != nil && !configured[kind] {
+			slog.Warn("default category not found, leaving split part uncategorized", "kind", kind, "category", name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find %s category: %w", kind, err)
+		}
+		mapper.SetCategory(kind, id)
+	}
 	return nil
 }
 
@@ -478,9 +944,21 @@ func (app *App) runSystemUninstall() error {
 	return nil
 }
 
+// globalOption reads an option given as "--name value" or "--name=value"
+// from the start of args and returns its value and the remaining args.
+func globalOption(args []string, name string) (string, []string, bool) {
+	if args[0] == name && len(args) > 1 {
+		return args[1], args[2:], true
+	}
+	if value, ok := strings.CutPrefix(args[0], name+"="); ok {
+		return value, args[1:], true
+	}
+	return "", args, false
+}
+
 func main() {
 	if err := Run(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		slog.Error("command failed", "error", err)
 		os.Exit(1)
 	}
 }
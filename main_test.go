@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
 	"errors"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/apmyp/ynab_importer_go/config"
 	"github.com/apmyp/ynab_importer_go/message"
 	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
 	_ "modernc.org/sqlite"
 )
 
@@ -52,9 +54,6 @@ func TestNewApp(t *testing.T) {
 	if app.matcher == nil {
 		t.Error("App.matcher should not be nil")
 	}
-	if app.pool == nil {
-		t.Error("App.pool should not be nil")
-	}
 }
 
 func TestNewAppWithFetcher(t *testing.T) {
@@ -162,6 +161,34 @@ func TestRun_UnknownCommand(t *testing.T) {
 	}
 }
 
+func TestRun_InvalidLogLevel(t *testing.T) {
+	err := Run([]string{"--log-level=loud", "--config", "nonexistent.json"})
+	if err == nil || !strings.Contains(err.Error(), "invalid log level") {
+		t.Errorf("Run() error = %v, want invalid log level", err)
+	}
+}
+
+func TestGlobalOption(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantValue string
+		wantRest  int
+		wantOK    bool
+	}{
+		{[]string{"--log-format", "json", "ynab_sync"}, "json", 1, true},
+		{[]string{"--log-format=json", "ynab_sync"}, "json", 1, true},
+		{[]string{"--log-format"}, "", 1, false},
+		{[]string{"ynab_sync"}, "", 1, false},
+	}
+
+	for _, tt := range tests {
+		value, rest, ok := globalOption(tt.args, "--log-format")
+		if value != tt.wantValue || len(rest) != tt.wantRest || ok != tt.wantOK {
+			t.Errorf("globalOption(%v) = %q, %v, %v", tt.args, value, rest, ok)
+		}
+	}
+}
+
 func TestApp_parseMessage_WithMatchingTemplate(t *testing.T) {
 	cfg := &config.Config{
 		Senders: []string{"102"},
@@ -676,7 +703,7 @@ func TestApp_runYNABSync_MissingStartDate(t *testing.T) {
 	}
 
 	app := NewApp(cfg, "")
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	if err == nil {
 		t.Error("runYNABSync() should return error for missing start_date")
 	}
@@ -707,7 +734,7 @@ func TestApp_runYNABSync_InvalidStartDate(t *testing.T) {
 	}
 
 	app := NewApp(cfg, "")
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	if err == nil {
 		t.Error("runYNABSync() should return error for invalid start date")
 	}
@@ -728,7 +755,7 @@ func TestApp_runYNABSync_FetchError(t *testing.T) {
 	}
 
 	app := NewAppWithFetcher(cfg, mockFetcher)
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	if err == nil {
 		t.Error("runYNABSync() should return error when fetch fails")
 	}
@@ -775,7 +802,7 @@ Adres: TEST SHOP`,
 	}
 
 	app := NewAppWithFetcher(cfg, mockFetcher)
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	if err == nil {
 		t.Error("runYNABSync() should return error when YNAB_API_KEY is not set")
 	}
@@ -814,7 +841,7 @@ func TestApp_runYNABSync_NoTransactions(t *testing.T) {
 	}
 
 	app := NewAppWithFetcher(cfg, mockFetcher)
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	// Should succeed with 0 transactions
 	if err != nil {
 		t.Errorf("runYNABSync() should succeed with no transactions, got error: %v", err)
@@ -856,7 +883,7 @@ func TestApp_runYNABSync_OnlyNonMDLTransactions(t *testing.T) {
 	}
 
 	app := NewAppWithFetcher(cfg, mockFetcher)
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	// Should succeed with 0 MDL transactions
 	if err != nil {
 		t.Errorf("runYNABSync() should succeed with no MDL transactions, got error: %v", err)
@@ -915,7 +942,7 @@ Adres: DECLINED SHOP`,
 	}
 
 	app := NewAppWithFetcher(cfg, mockFetcher)
-	err := app.runYNABSync()
+	err := app.runYNABSync(nil)
 	// Should fail trying to get accounts (since no mock YNAB client and will use real API)
 	// This is expected behavior - when accounts don't exist, system tries to create them via API
 	if err == nil {
@@ -923,7 +950,7 @@ Adres: DECLINED SHOP`,
 	}
 }
 
-func TestApp_convertTransactions_NilConverter(t *testing.T) {
+func TestApp_convertStage_NilConverter(t *testing.T) {
 	cfg := &config.Config{
 		Senders: []string{"102"},
 	}
@@ -945,11 +972,11 @@ func TestApp_convertTransactions_NilConverter(t *testing.T) {
 	}
 
 	// Should not panic with nil converter
-	app.convertTransactions(parsedMessages)
+	converted, _ := app.runParsed(newParsedPipeline(app.convertStage()), parsedMessages)
 
-	// Transaction should remain unchanged
-	if parsedMessages[0].Transaction.Converted.Currency != "" {
-		t.Error("Transaction should not be converted when converter is nil")
+	// Transaction should keep its original amount
+	if len(converted) != 1 || converted[0].Transaction.Converted != converted[0].Transaction.Original {
+		t.Error("Transaction should keep its original amount when converter is nil")
 	}
 }
 
@@ -977,3 +1004,109 @@ func TestRun_YNABSyncCommand(t *testing.T) {
 		t.Skip("Expected error from db or missing API key")
 	}
 }
+
+func TestNewMatcher_ConfigIgnoreRules(t *testing.T) {
+	cfg := &config.Config{
+		Ignore: []config.IgnoreConfig{
+			{Sender: "VICTORIABANK", Regex: `^Codul \d+`},
+			{Contains: "both", Regex: "both"},
+		},
+	}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	now := time.Now()
+	messages := []*message.Message{
+		{Timestamp: now, Sender: "VICTORIABANK", Content: "Codul 1234 este valabil 5 minute"},
+		{Timestamp: now, Sender: "102", Content: "Codul 1234 este valabil 5 minute"},
+	}
+
+	unmatched := app.unmatchedMessages(messages)
+	if len(unmatched) != 1 || unmatched[0].Sender != "102" {
+		t.Errorf("unmatchedMessages() = %v, want only the message from 102", unmatched)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(tt.input), &out, "Create?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if out.String() != "Create? [y/N] " {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
+
+func TestCountAccountChanges(t *testing.T) {
+	before := []config.YNABAccount{{Last4: "1234"}, {YNABAccountID: "a", Last4: "5678"}}
+	after := []config.YNABAccount{{YNABAccountID: "b", Last4: "1234"}, {YNABAccountID: "a", Last4: "5678"}, {YNABAccountID: "c", Last4: "9999"}}
+
+	if got := countAccountChanges(before, after); got != 2 {
+		t.Errorf("countAccountChanges() = %d, want 2", got)
+	}
+}
+
+func TestApp_runYNABSync_UnknownArgument(t *testing.T) {
+	app := NewApp(&config.Config{}, "")
+	if err := app.runYNABSync([]string{"--bogus"}); err == nil {
+		t.Error("runYNABSync() expected error for unknown argument")
+	}
+}
+
+type fakeCategoryLister struct {
+	categories []ynab.Category
+}
+
+func (f *fakeCategoryLister) GetCategories(budgetID string) (*ynab.GetCategoriesResponse, error) {
+	resp := &ynab.GetCategoriesResponse{}
+	resp.Data.CategoryGroups = []ynab.CategoryGroup{{ID: "g1", Name: "Monthly", Categories: f.categories}}
+	return resp, nil
+}
+
+func TestApp_setComponentCategories_MissingDefault(t *testing.T) {
+	lister := &fakeCategoryLister{categories: []ynab.Category{{ID: "c1", Name: "Groceries"}}}
+	tx := &template.Transaction{
+		Direction: template.DirectionDebit,
+		Card:      "1234",
+		Original:  template.Amount{Value: 100, Currency: "MDL"},
+		Converted: template.Amount{Value: 100, Currency: "MDL"},
+		Components: []template.Component{{
+			Kind:      template.KindFee,
+			Original:  template.Amount{Value: 5, Currency: "MDL"},
+			Converted: template.Amount{Value: 5, Currency: "MDL"},
+		}},
+	}
+	transactions := []*template.Transaction{tx}
+
+	// Without "Bank fees" in the budget the fee stays uncategorized
+	app := NewApp(&config.Config{}, "")
+	mapper := ynab.NewMapper([]ynab.YNABAccount{{YNABAccountID: "acc", Last4: "1234"}})
+	if err := app.setComponentCategories(lister, mapper, "budget", transactions); err != nil {
+		t.Fatalf("setComponentCategories() error = %v", err)
+	}
+	payload, err := mapper.MapTransaction(&message.Message{Timestamp: time.Now()}, tx)
+	if err != nil {
+		t.Fatalf("MapTransaction() error = %v", err)
+	}
+	if len(payload.Subtransactions) != 2 || payload.Subtransactions[1].CategoryID != "" {
+		t.Errorf("Subtransactions = %+v, want an uncategorized fee", payload.Subtransactions)
+	}
+
+	// A configured category must exist
+	app = NewApp(&config.Config{YNAB: config.YNABConfig{FeeCategory: "Commissions"}}, "")
+	if err := app.setComponentCategories(lister, mapper, "budget", transactions); err == nil {
+		t.Error("setComponentCategories() expected error for missing configured category")
+	}
+}
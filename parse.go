@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+type parseOptions struct {
+	path   string
+	sender string
+	date   time.Time
+	// trial is a template given on the command line, tried next to the
+	// configured ones before it is saved
+	trial template.Definition
+}
+
+// trialTemplateName names the template built from --pattern in the report.
+const trialTemplateName = "--pattern"
+
+const parseUsage = "usage: parse [--sender <sender>] [--date <YYYY-MM-DD HH:MM>] [--pattern <regex> --direction <direction> [--kind <kind>] [--priority <n>]] [<file>|-]"
+
+var parseDateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+func parseParseArgs(args []string) (*parseOptions, error) {
+	opts := &parseOptions{date: time.Now()}
+
+	for len(args) > 0 {
+		if args[0] == "--sender" && len(args) > 1 {
+			opts.sender = args[1]
+			args = args[2:]
+		} else if args[0] == "--date" && len(args) > 1 {
+			date, err := parseMessageDate(args[1])
+			if err != nil {
+				return nil, err
+			}
+			opts.date = date
+			args = args[2:]
+		} else if args[0] == "--pattern" && len(args) > 1 {
+			opts.trial.Pattern = args[1]
+			args = args[2:]
+		} else if args[0] == "--direction" && len(args) > 1 {
+			opts.trial.Direction = args[1]
+			args = args[2:]
+		} else if args[0] == "--kind" && len(args) > 1 {
+			opts.trial.Kind = args[1]
+			args = args[2:]
+		} else if args[0] == "--priority" && len(args) > 1 {
+			priority, err := strconv.Atoi(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid priority: %s", args[1])
+			}
+			opts.trial.Priority = priority
+			args = args[2:]
+		} else if opts.path == "" {
+			opts.path = args[0]
+			args = args[1:]
+		} else {
+			return nil, fmt.Errorf(parseUsage)
+		}
+	}
+
+	if opts.trial != (template.Definition{}) {
+		if opts.trial.Pattern == "" || opts.trial.Direction == "" {
+			return nil, fmt.Errorf("--pattern and --direction are needed to try a template; %s", parseUsage)
+		}
+		opts.trial.Name = trialTemplateName
+	}
+	return opts, nil
+}
+
+func parseMessageDate(value string) (time.Time, error) {
+	for _, layout := range parseDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", value)
+}
+
+func (app *App) runParse(args []string) error {
+	opts, err := parseParseArgs(args)
+	if err != nil {
+		return err
+	}
+	if opts.trial.Pattern != "" {
+		tmpl, err := template.NewRegexTemplate(opts.trial)
+		if err != nil {
+			return err
+		}
+		app.matcher.AddTemplates(tmpl)
+	}
+
+	var content []byte
+	if opts.path == "" || opts.path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(opts.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+
+	sender := opts.sender
+	if sender == "" && len(app.config.Senders) > 0 {
+		sender = app.config.Senders[0]
+	}
+
+	msg := &message.Message{
+		Timestamp: opts.date,
+		Sender:    sender,
+		Content:   strings.TrimRight(string(content), "\r\n"),
+	}
+	return app.writeParseReport(os.Stdout, msg)
+}
+
+func (app *App) writeParseReport(w io.Writer, msg *message.Message) error {
+	fmt.Fprintf(w, "Message from %s at %s:\n", msg.Sender, msg.Timestamp.Format("2006-01-02 15:04:05"))
+	for _, line := range strings.Split(msg.Content, "\n") {
+		fmt.Fprintf(w, "  | %s\n", line)
+	}
+
+	match := app.matcher.Classify(msg.Sender, msg.Content)
+
+	fmt.Fprintf(w, "\nTemplates:\n")
+	for _, tmpl := range app.matcher.Templates() {
+		switch {
+		case !tmpl.Match(msg.Content):
+			fmt.Fprintf(w, "  ✗ %s: %s\n", tmpl.Name(), template.Explain(tmpl, msg.Content))
+		case tmpl == match.Template:
+			fmt.Fprintf(w, "  ✓ %s (selected)\n", tmpl.Name())
+		default:
+			fmt.Fprintf(w, "  ✓ %s (not selected)\n", tmpl.Name())
+		}
+	}
+
+	if len(match.IgnoreRules) > 0 {
+		fmt.Fprintf(w, "\nIgnore rules:\n")
+		for _, rule := range match.IgnoreRules {
+			fmt.Fprintf(w, "  ✓ %s (priority %d)\n", rule, rule.Priority)
+		}
+	}
+
+	if match.Ambiguous {
+		fmt.Fprintf(w, "\nAmbiguous: several candidates share the highest priority:\n")
+		for _, candidate := range match.Candidates() {
+			fmt.Fprintf(w, "  - %s\n", candidate)
+		}
+	}
+
+	if match.Ignored() {
+		fmt.Fprintf(w, "\nIgnored by %s.\n", match.Ignore)
+		return nil
+	}
+
+	matched := match.Template
+	if matched == nil {
+		fmt.Fprintf(w, "\nNo template matched.\n")
+		return nil
+	}
+
+	tx, err := matched.Parse(msg.Content)
+	if err != nil {
+		fmt.Fprintf(w, "\n%s matched but failed to parse: %v\n", matched.Name(), err)
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nTransaction (%s):\n", matched.Name())
+	writeTransactionFields(w, tx)
+
+	rate, err := app.convertTransaction(msg, tx)
+	if err != nil {
+		fmt.Fprintf(w, "\nConversion failed: %v\n", err)
+	} else {
+		fmt.Fprintf(w, "\nConverted: %.2f %s (rate %.4f)\n", tx.Converted.Value, tx.Converted.Currency, rate)
+	}
+
+	return app.writeYNABPayload(w, msg, tx)
+}
+
+func writeTransactionFields(w io.Writer, tx *template.Transaction) {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"Operation", tx.Operation},
+		{"Direction", string(tx.Direction)},
+		{"Kind", string(tx.Kind)},
+		{"Card", tx.Card},
+		{"Status", tx.Status},
+		{"Amount", fmt.Sprintf("%.2f %s", tx.Original.Value, tx.Original.Currency)},
+		{"Balance", fmt.Sprintf("%.2f", tx.Balance)},
+		{"DateTime", tx.DateTime},
+		{"Address", tx.Address},
+		{"Support", tx.Support},
+		{"FromAccount", tx.FromAccount},
+		{"ToAccount", tx.ToAccount},
+	}
+	for _, c := range tx.Components {
+		fields = append(fields, struct {
+			name  string
+			value string
+		}{"Component", fmt.Sprintf("%s %.2f %s", c.Kind, c.Original.Value, c.Original.Currency)})
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		fmt.Fprintf(w, "  %-12s %s\n", f.name+":", f.value)
+	}
+}
+
+func (app *App) writeYNABPayload(w io.Writer, msg *message.Message, tx *template.Transaction) error {
+	switch template.StageOf(tx) {
+	case template.StageDeclined:
+		fmt.Fprintf(w, "\nynab_sync only uses declined transactions to cancel an earlier authorization.\n")
+	case template.StageAuthorized:
+		fmt.Fprintf(w, "\nAuthorization: created uncleared and updated when the purchase settles.\n")
+	}
+	if tx.Converted.Currency != "MDL" {
+		fmt.Fprintf(w, "\nynab_sync skips transactions not converted to MDL.\n")
+	}
+
+	targets, _ := app.budgetTargets()
+	target := routeTransaction(targets, msg.Sender, last4Regex.FindString(tx.Card))
+	if target < 0 {
+		fmt.Fprintf(w, "\nNo YNAB payload: the message matches no budget's senders or cards.\n")
+		return nil
+	}
+	if len(targets) > 1 {
+		fmt.Fprintf(w, "\nBudget: %s\n", budgetLabel(targets[target]))
+	}
+
+	accounts := mapperAccounts(targets[target].Accounts, "<new account>")
+	mapper := ynab.NewMapper(accounts)
+
+	if _, err := mapper.MatchAccount(msg.Sender, tx); err != nil {
+		last4 := last4Regex.FindString(tx.Card)
+		if last4 == "" {
+			fmt.Fprintf(w, "\nNo YNAB payload: %v\n", err)
+			return nil
+		}
+		fmt.Fprintf(w, "\nNo YNAB account for card ending in %s yet; ynab_sync will create one.\n", last4)
+		accounts = append(accounts, ynab.YNABAccount{YNABAccountID: "<new account>", Last4: last4})
+		mapper = ynab.NewMapper(accounts)
+	}
+
+	mapper.SetStateRules(app.stateRules())
+	if err := app.setMemoTemplates(mapper, targets[target].Accounts); err != nil {
+		fmt.Fprintf(w, "\nNo YNAB payload: %v\n", err)
+		return nil
+	}
+	payload, err := mapper.MapTransaction(msg, tx)
+	if err != nil {
+		fmt.Fprintf(w, "\nNo YNAB payload: %v\n", err)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	fmt.Fprintf(w, "\nYNAB payload:\n%s\n", data)
+	return nil
+}
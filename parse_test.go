@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func TestParseParseArgs(t *testing.T) {
+	opts, err := parseParseArgs([]string{"--sender", "EXIMBANK", "--date", "2024-04-08 09:27", "msg.txt"})
+	if err != nil {
+		t.Fatalf("parseParseArgs() error = %v", err)
+	}
+	if opts.sender != "EXIMBANK" || opts.path != "msg.txt" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.date.Format("2006-01-02 15:04") != "2024-04-08 09:27" {
+		t.Errorf("unexpected date: %v", opts.date)
+	}
+
+	if _, err := parseParseArgs([]string{"--date", "yesterday"}); err == nil {
+		t.Error("parseParseArgs() should reject an invalid date")
+	}
+	if _, err := parseParseArgs([]string{"a.txt", "b.txt"}); err == nil {
+		t.Error("parseParseArgs() should reject a second file")
+	}
+}
+
+func TestParseParseArgs_Pattern(t *testing.T) {
+	opts, err := parseParseArgs([]string{"--pattern", `Plata (?P<amount>[\d.]+) (?P<currency>[A-Z]{3})`, "--direction", "debit", "msg.txt"})
+	if err != nil {
+		t.Fatalf("parseParseArgs() error = %v", err)
+	}
+	if opts.trial.Name != trialTemplateName || opts.trial.Direction != "debit" || opts.path != "msg.txt" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseParseArgs([]string{"--pattern", "Plata (?P<amount>[\\d.]+)"}); err == nil {
+		t.Error("parseParseArgs() should require a direction for --pattern")
+	}
+	if _, err := parseParseArgs([]string{"--priority", "high"}); err == nil {
+		t.Error("parseParseArgs() should reject an invalid priority")
+	}
+}
+
+func TestApp_writeParseReport_TrialTemplate(t *testing.T) {
+	cfg := &config.Config{DefaultCurrency: "MDL", DataFilePath: filepath.Join(t.TempDir(), "data.json")}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	opts, err := parseParseArgs([]string{"--pattern", `Plata (?P<amount>[\d.]+) (?P<currency>[A-Z]{3}) la (?P<merchant>.+)`, "--direction", "debit"})
+	if err != nil {
+		t.Fatalf("parseParseArgs() error = %v", err)
+	}
+	tmpl, err := template.NewRegexTemplate(opts.trial)
+	if err != nil {
+		t.Fatalf("NewRegexTemplate() error = %v", err)
+	}
+	app.matcher.AddTemplates(tmpl)
+
+	msg := &message.Message{Timestamp: time.Date(2024, 4, 8, 9, 27, 0, 0, time.UTC), Sender: "NEWBANK", Content: "Plata 120.50 MDL la KAUFLAND"}
+	var out bytes.Buffer
+	if err := app.writeParseReport(&out, msg); err != nil {
+		t.Fatalf("writeParseReport() error = %v", err)
+	}
+
+	report := out.String()
+	for _, want := range []string{"✓ --pattern (selected)", "Transaction (--pattern):", "Address:     KAUFLAND", "Amount:      120.50 MDL"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestApp_writeParseReport_Matched(t *testing.T) {
+	cfg := &config.Config{
+		DefaultCurrency: "MDL",
+		DataFilePath:    filepath.Join(t.TempDir(), "data.json"),
+		YNAB: config.YNABConfig{
+			Accounts: []config.YNABAccount{{YNABAccountID: "acct-7890", Last4: "7890"}},
+		},
+	}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	msg := &message.Message{
+		Timestamp: time.Date(2024, 4, 8, 9, 27, 1, 0, time.UTC),
+		Sender:    "EXIMBANK",
+		Content:   "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL",
+	}
+
+	var out bytes.Buffer
+	if err := app.writeParseReport(&out, msg); err != nil {
+		t.Fatalf("writeParseReport() error = %v", err)
+	}
+
+	report := out.String()
+	for _, want := range []string{
+		"✓ Debitare",
+		"✗ MAIB: expected `Op|",
+		"Address:     LINELLA",
+		"Converted: 9.65 MDL (rate 1.0000)",
+		`"account_id": "acct-7890"`,
+		`"amount": -9650`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestApp_writeParseReport_NoMatch(t *testing.T) {
+	cfg := &config.Config{DefaultCurrency: "MDL", DataFilePath: filepath.Join(t.TempDir(), "data.json")}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	msg := &message.Message{
+		Timestamp: time.Now(),
+		Sender:    "102",
+		Content:   "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9,65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL",
+	}
+
+	var out bytes.Buffer
+	if err := app.writeParseReport(&out, msg); err != nil {
+		t.Fatalf("writeParseReport() error = %v", err)
+	}
+
+	report := out.String()
+	if !strings.Contains(report, "No template matched.") {
+		t.Errorf("report should say no template matched:\n%s", report)
+	}
+	if !strings.Contains(report, `found ",65 MDL`) {
+		t.Errorf("report should point at the decimal comma:\n%s", report)
+	}
+	if strings.Contains(report, "YNAB payload") {
+		t.Errorf("report should not include a payload:\n%s", report)
+	}
+}
+
+func TestApp_writeParseReport_NewAccount(t *testing.T) {
+	cfg := &config.Config{DefaultCurrency: "MDL", DataFilePath: filepath.Join(t.TempDir(), "data.json")}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	msg := &message.Message{
+		Timestamp: time.Now(),
+		Sender:    "102",
+		Content:   "Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 100.00 MDL, Detalii Plata salariala",
+	}
+
+	var out bytes.Buffer
+	if err := app.writeParseReport(&out, msg); err != nil {
+		t.Fatalf("writeParseReport() error = %v", err)
+	}
+
+	report := out.String()
+	if !strings.Contains(report, "No YNAB account for card ending in 7890") {
+		t.Errorf("report should mention the missing account:\n%s", report)
+	}
+	if !strings.Contains(report, `"amount": 100000`) {
+		t.Errorf("report should include a positive payload amount:\n%s", report)
+	}
+}
+
+func TestApp_writeParseReport_Ambiguous(t *testing.T) {
+	cfg := &config.Config{
+		DefaultCurrency: "MDL",
+		DataFilePath:    filepath.Join(t.TempDir(), "data.json"),
+		Ignore:          []config.IgnoreConfig{{Contains: "Detalii Comision"}},
+	}
+	app := NewAppWithFetcher(cfg, &MockFetcher{})
+
+	msg := &message.Message{
+		Timestamp: time.Now(),
+		Sender:    "EXIMBANK",
+		Content:   "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii Comision SMS, Disponibil 38400.60 MDL",
+	}
+
+	var out bytes.Buffer
+	if err := app.writeParseReport(&out, msg); err != nil {
+		t.Fatalf("writeParseReport() error = %v", err)
+	}
+
+	report := out.String()
+	for _, want := range []string{
+		"✓ Debitare (selected)",
+		`✓ "Detalii Comision" (priority 0)`,
+		"Ambiguous:",
+		"template Debitare (priority 0)",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/worker"
+)
+
+// Stage processes one item at a time, with up to Workers calls running at
+// once. Process returns the item for the next stage, or keep false to drop
+// it. An error drops the item and is passed to the pipeline's OnError.
+type Stage[T any] struct {
+	Name    string
+	Workers int
+	Process func(ctx context.Context, item T) (out T, keep bool, err error)
+}
+
+type StageMetrics struct {
+	Name    string
+	In      int64
+	Out     int64
+	Dropped int64
+	Failed  int64
+	// Busy is the time spent in Process, summed over all workers
+	Busy time.Duration
+}
+
+type Metrics struct {
+	Stages  []*StageMetrics
+	Elapsed time.Duration
+}
+
+// Stage returns the metrics of the named stage, or nil.
+func (m *Metrics) Stage(name string) *StageMetrics {
+	for _, s := range m.Stages {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Log writes one line per stage to logger at the given level.
+func (m *Metrics) Log(logger *slog.Logger, level slog.Level) {
+	for _, s := range m.Stages {
+		logger.Log(context.Background(), level, "pipeline stage finished",
+			"stage", s.Name, "in", s.In, "out", s.Out, "dropped", s.Dropped, "failed", s.Failed,
+			"busy", s.Busy.Round(time.Millisecond), "elapsed", m.Elapsed.Round(time.Millisecond))
+	}
+}
+
+// Pipeline passes items through its stages over bounded channels, keeping
+// their order. Each stage holds at most a few items per worker, so a slow
+// stage holds back the ones before it instead of buffering everything.
+type Pipeline[T any] struct {
+	stages []Stage[T]
+	// OnError is called for every item a stage fails on
+	OnError func(stage string, item T, err error)
+}
+
+func New[T any](stages ...Stage[T]) *Pipeline[T] {
+	return &Pipeline[T]{stages: stages}
+}
+
+type result[T any] struct {
+	item T
+	keep bool
+}
+
+// Run feeds the items read from source through the stages and calls sink
+// with every item that passes all of them. It stops at the first sink
+// error or when ctx is done.
+func (p *Pipeline[T]) Run(ctx context.Context, source <-chan T, sink func(T) error) (*Metrics, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	metrics := &Metrics{}
+	var errMu sync.Mutex
+
+	in := source
+	for _, stage := range p.stages {
+		m := &StageMetrics{Name: stage.Name}
+		metrics.Stages = append(metrics.Stages, m)
+
+		workers := stage.Workers
+		if workers < 1 {
+			workers = 1
+		}
+		process := stage.Process
+		results := worker.Stream(ctx, worker.NewPool(workers), in, func(ctx context.Context, item T) (result[T], error) {
+			atomic.AddInt64(&m.In, 1)
+			began := time.Now()
+			out, keep, err := process(ctx, item)
+			atomic.AddInt64((*int64)(&m.Busy), int64(time.Since(began)))
+			if err != nil {
+				atomic.AddInt64(&m.Failed, 1)
+				if p.OnError != nil {
+					errMu.Lock()
+					p.OnError(m.Name, item, err)
+					errMu.Unlock()
+				}
+				return result[T]{}, nil
+			}
+			if !keep {
+				atomic.AddInt64(&m.Dropped, 1)
+				return result[T]{}, nil
+			}
+			atomic.AddInt64(&m.Out, 1)
+			return result[T]{item: out, keep: true}, nil
+		})
+
+		next := make(chan T)
+		go func() {
+			defer close(next)
+			for r := range results {
+				if !r.Value.keep {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case next <- r.Value.item:
+				}
+			}
+		}()
+		in = next
+	}
+
+	var sinkErr error
+	for item := range in {
+		if sinkErr != nil {
+			continue
+		}
+		if err := sink(item); err != nil {
+			sinkErr = err
+			cancel()
+		}
+	}
+	metrics.Elapsed = time.Since(start)
+
+	if sinkErr != nil {
+		return metrics, sinkErr
+	}
+	return metrics, ctx.Err()
+}
+
+// FromSlice returns a channel that yields the items and is closed after
+// the last one or once ctx is done.
+func FromSlice[T any](ctx context.Context, items []T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- item:
+			}
+		}
+	}()
+	return out
+}
+
+// Collect runs the pipeline over items and returns the ones that pass all
+// stages, in order.
+func (p *Pipeline[T]) Collect(ctx context.Context, items []T) ([]T, *Metrics, error) {
+	var collected []T
+	metrics, err := p.Run(ctx, FromSlice(ctx, items), func(item T) error {
+		collected = append(collected, item)
+		return nil
+	})
+	return collected, metrics, err
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipeline_StagesKeepOrder(t *testing.T) {
+	var failed []int
+	p := New(
+		Stage[int]{Name: "square", Workers: 4, Process: func(_ context.Context, n int) (int, bool, error) {
+			time.Sleep(time.Duration(10-n) * time.Millisecond)
+			return n * n, true, nil
+		}},
+		Stage[int]{Name: "odd", Process: func(_ context.Context, n int) (int, bool, error) {
+			return n, n%2 == 1, nil
+		}},
+		Stage[int]{Name: "check", Workers: 2, Process: func(_ context.Context, n int) (int, bool, error) {
+			if n == 25 {
+				return 0, false, errors.New("too big")
+			}
+			return n, true, nil
+		}},
+	)
+	p.OnError = func(stage string, item int, err error) {
+		if stage != "check" {
+			t.Errorf("OnError stage = %q, want check", stage)
+		}
+		failed = append(failed, item)
+	}
+
+	got, metrics, err := p.Collect(context.Background(), []int{1, 2, 3, 4, 5, 6, 7})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	want := []int{1, 9, 49}
+	if len(got) != len(want) {
+		t.Fatalf("Collect() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Collect()[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+	if len(failed) != 1 || failed[0] != 25 {
+		t.Errorf("failed = %v, want [25]", failed)
+	}
+
+	odd := metrics.Stages[1]
+	if odd.In != 7 || odd.Out != 4 || odd.Dropped != 3 {
+		t.Errorf("odd metrics = %+v, want in 7, out 4, dropped 3", odd)
+	}
+	if check := metrics.Stages[2]; check.In != 4 || check.Failed != 1 || check.Out != 3 {
+		t.Errorf("check metrics = %+v, want in 4, out 3, failed 1", check)
+	}
+
+	var b bytes.Buffer
+	metrics.Log(slog.New(slog.NewTextHandler(&b, nil)), slog.LevelInfo)
+	if !strings.Contains(b.String(), "stage=odd") || !strings.Contains(b.String(), "dropped=3") {
+		t.Errorf("Log() = %q", b.String())
+	}
+}
+
+func TestPipeline_SinkErrorStops(t *testing.T) {
+	p := New(Stage[int]{Name: "pass", Workers: 2, Process: func(_ context.Context, n int) (int, bool, error) {
+		return n, true, nil
+	}})
+
+	items := make(chan int)
+	go func() {
+		defer close(items)
+		for i := 0; ; i++ {
+			select {
+			case items <- i:
+			case <-time.After(time.Second):
+				return
+			}
+		}
+	}()
+
+	sinkErr := errors.New("disk full")
+	var seen int
+	_, err := p.Run(context.Background(), items, func(int) error {
+		seen++
+		if seen == 3 {
+			return sinkErr
+		}
+		return nil
+	})
+	if !errors.Is(err, sinkErr) {
+		t.Errorf("Run() error = %v, want the sink error", err)
+	}
+	if seen != 3 {
+		t.Errorf("sink called %d times, want 3", seen)
+	}
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(Stage[int]{Name: "slow", Process: func(ctx context.Context, n int) (int, bool, error) {
+		if n == 2 {
+			cancel()
+		}
+		return n, true, nil
+	}})
+
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := p.Collect(ctx, items)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Collect() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Collect() did not stop after cancel")
+	}
+}
@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Dates and times are kept so redacted samples still convert with the
+// right exchange rate; every other digit is replaced.
+var sensitiveRegex = regexp.MustCompile(
+	`(\b[A-Z]{2}\d{2}[A-Z0-9]{10,30}\b)` +
+		`|(\d{4}-\d{2}-\d{2}|\d{2}[./-]\d{2}[./-]\d{2,4}|\d{2}/\d{2}/\d{4})` +
+		`|(\d{2}:\d{2}(?::\d{2})?)` +
+		`|(\d+)`)
+
+var nameRegex = regexp.MustCompile(
+	`((?:[Dd]e la|[Cc][aă]tre|[Bb]eneficiar(?:ul)?|[Pp]l[aă]titor(?:ul)?|[Dd]estinatar(?:ul)?|[Ee]xpeditor|[Ff]rom|[Оо]т|[Пп]олучатель|[Оо]тправитель):?\s+)` +
+		`(\p{Lu}[\p{L}'-]*(?:\s+\p{Lu}[\p{L}'.-]*){0,3})`)
+
+// Redact replaces card numbers, account numbers, IBANs, amounts, balances,
+// codes and personal names after transfer keywords with placeholders of
+// the same shape: digits stay digits, letters stay letters of the same
+// case, and separators are kept, so templates match the result the same
+// way they match the original.
+func Redact(content string) string {
+	content = nameRegex.ReplaceAllStringFunc(content, func(match string) string {
+		parts := nameRegex.FindStringSubmatch(match)
+		return parts[1] + maskLetters(parts[2])
+	})
+
+	return sensitiveRegex.ReplaceAllStringFunc(content, func(match string) string {
+		parts := sensitiveRegex.FindStringSubmatch(match)
+		switch {
+		case parts[1] != "":
+			return parts[1][:2] + maskLetters(maskDigits(parts[1][2:]))
+		case parts[2] != "", parts[3] != "":
+			return match
+		default:
+			return maskDigits(match)
+		}
+	})
+}
+
+// maskDigits replaces digits with 1234567890 repeated, so numbers keep
+// their length and never start with zero.
+func maskDigits(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			b.WriteByte("1234567890"[n%10])
+			n++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func maskLetters(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsUpper(r):
+			return 'X'
+		case unicode.IsLower(r):
+			return 'x'
+		}
+		return r
+	}, s)
+}
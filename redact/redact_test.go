@@ -0,0 +1,72 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "card and amounts",
+			input: "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL",
+			want:  "Debitare cont Card 1..1234, Data 08.04.2024 09:27:01, Suma 1.12 MDL, Detalii LINELLA, Disponibil 12345.12 MDL",
+		},
+		{
+			name:  "iban",
+			input: "Transfer din contul MD24AG000225100013104168",
+			want:  "Transfer din contul MD12XX345678901234567890",
+		},
+		{
+			name:  "personal name",
+			input: "Ati primit 500 MDL de la Ion Popescu pe card *1234",
+			want:  "Ati primit 123 MDL de la Xxx Xxxxxxx pe card *1234",
+		},
+		{
+			name:  "otp",
+			input: "Parola de unica folosinta: 482913",
+			want:  "Parola de unica folosinta: 123456",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.input); got != tt.want {
+				t.Errorf("Redact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact_KeepsTemplateMatches(t *testing.T) {
+	matcher := template.NewMatcher()
+	samples := []string{
+		"Op: Tovary i uslugi\nKarta: *1234\nStatus: Odobrena\nSumma: 34 MDL\nDost: 12500,50\nData/vremya: 03.05.23 16:21\nAdres: COFFEE SHOP ALPHA\nPodderzhka: +12025551234",
+		"Tranzactia din 01/02/2024 din contul 2251000123456 in contul 2259000654321 in suma de 150.00 MDL a fost efectuata",
+		"Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL",
+		"Tranzactie reusita, Data 13.04.2024 13:20:30, Card 9..7890, Suma 91.91 MDL, Locatie MAIB GROCERY STORE>CHISINAU, MDA, Disponibil 31200.80 MDL",
+		"Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 93719.33 MDL, Detalii Plata salariala",
+	}
+
+	for _, sample := range samples {
+		redacted := Redact(sample)
+		original := matcher.FindTemplate(sample)
+		got := matcher.FindTemplate(redacted)
+		if original == nil || got == nil || original.Name() != got.Name() {
+			t.Errorf("redacted sample matches %v, original matches %v:\n%s", got, original, redacted)
+			continue
+		}
+		if _, err := got.Parse(redacted); err != nil {
+			t.Errorf("%s failed to parse redacted sample: %v", got.Name(), err)
+		}
+		if strings.Contains(redacted, "9..7890") || strings.Contains(redacted, "12500,50") || strings.Contains(redacted, "2251000123456") {
+			t.Errorf("sample not redacted: %s", redacted)
+		}
+	}
+}
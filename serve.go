@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/webhook"
+)
+
+const (
+	WebhookModeQueue = "queue"
+	WebhookModeSync  = "sync"
+
+	defaultWebhookListen = ":8787"
+
+	// Forwarded messages often arrive in bursts, so sync mode waits this long
+	// after the last one and syncs them together.
+	webhookSyncDelay = 30 * time.Second
+)
+
+func (app *App) webhookHandler(mode string) (webhook.HandlerFunc, error) {
+	switch mode {
+	case WebhookModeQueue:
+		return webhook.NewQueue(app.config.DataFilePath).Enqueue, nil
+	case WebhookModeSync:
+		apiKey, startDate, err := app.prepareYNABSync()
+		if err != nil {
+			return nil, err
+		}
+		// Messages are queued first, so a failed sync loses nothing: the
+		// next one retries them, and already synced ones are skipped.
+		queue := webhook.NewQueue(app.config.DataFilePath)
+		debounced := newDebouncer(webhookSyncDelay, func() {
+			messages, err := queue.Messages()
+			if err != nil {
+				slog.Error("failed to read webhook queue", "error", err)
+				return
+			}
+			if err := app.syncMessages(apiKey, startDate, messages); err != nil {
+				slog.Error("webhook sync failed; queued messages are retried on the next one", "error", err)
+			}
+		})
+		return func(msg *message.Message) error {
+			if err := queue.Enqueue(msg); err != nil {
+				return err
+			}
+			debounced.trigger()
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook mode: %q", mode)
+	}
+}
+
+// debouncer runs fn once calls to trigger have paused for delay, never
+// running two fn calls at once.
+type debouncer struct {
+	delay   time.Duration
+	fn      func()
+	mu      sync.Mutex
+	timer   *time.Timer
+	running sync.Mutex
+}
+
+func newDebouncer(delay time.Duration, fn func()) *debouncer {
+	return &debouncer{delay: delay, fn: fn}
+}
+
+func (d *debouncer) trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(d.delay, func() {
+		d.running.Lock()
+		defer d.running.Unlock()
+		d.fn()
+	})
+}
+
+func readsWebhookQueue(cfg *config.Config) bool {
+	for _, src := range configuredSources(cfg) {
+		if src.Type == SourceWebhook {
+			return true
+		}
+	}
+	return false
+}
+
+func (app *App) runServe() error {
+	token := os.Getenv("WEBHOOK_TOKEN")
+	if token == "" {
+		return fmt.Errorf("WEBHOOK_TOKEN environment variable not set")
+	}
+
+	listen := app.config.Webhook.Listen
+	if listen == "" {
+		listen = defaultWebhookListen
+	}
+	mode := app.config.Webhook.Mode
+	if mode == "" {
+		mode = WebhookModeQueue
+	}
+	if mode == WebhookModeQueue && !readsWebhookQueue(app.config) {
+		slog.Warn(`queued webhook messages are not synced until {"type": "webhook_queue"} is added to sources`)
+	}
+
+	handle, err := app.webhookHandler(mode)
+	if err != nil {
+		return err
+	}
+
+	handler, err := webhook.NewServer(token, app.config.Senders, app.matcher, handle)
+	if err != nil {
+		return err
+	}
+	if app.config.Webhook.AllowQueryToken {
+		handler.AllowQueryToken = true
+		slog.Warn("accepting the webhook token as a query parameter; it will show up in access logs")
+	}
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+	}
+
+	slog.Info("listening for webhooks", "address", listen, "mode", mode)
+	return server.ListenAndServe()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestApp_webhookHandler_QueueMode(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data.json")
+	cfg := &config.Config{
+		Senders:      []string{"102"},
+		DataFilePath: dataPath,
+		Sources:      []config.SourceConfig{{Type: SourceWebhook}},
+	}
+	app := NewApp(cfg, "")
+
+	handle, err := app.webhookHandler(WebhookModeQueue)
+	if err != nil {
+		t.Fatalf("webhookHandler() error = %v", err)
+	}
+
+	msg := &message.Message{
+		Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC),
+		Sender:    "102",
+		Content:   "Op: Tovary i uslugi",
+	}
+	if err := handle(msg); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+
+	// Queued messages are read back through the webhook_queue source
+	messages, cleanup, err := app.fetcher.FetchMessages()
+	if err != nil {
+		t.Fatalf("FetchMessages() error = %v", err)
+	}
+	defer cleanup()
+
+	if len(messages) != 1 || messages[0].Content != msg.Content {
+		t.Errorf("expected queued message, got %v", messages)
+	}
+}
+
+func TestApp_webhookHandler_UnknownMode(t *testing.T) {
+	app := NewApp(&config.Config{}, "")
+
+	if _, err := app.webhookHandler("carrier_pigeon"); err == nil {
+		t.Error("webhookHandler() should return error for unknown mode")
+	}
+}
+
+func TestApp_webhookHandler_SyncModeRequiresAPIKey(t *testing.T) {
+	origKey := os.Getenv("YNAB_API_KEY")
+	defer os.Setenv("YNAB_API_KEY", origKey)
+	os.Unsetenv("YNAB_API_KEY")
+
+	app := NewApp(&config.Config{}, "")
+
+	if _, err := app.webhookHandler(WebhookModeSync); err == nil {
+		t.Error("webhookHandler() should return error when YNAB_API_KEY is not set")
+	}
+}
+
+func TestApp_runServe_MissingToken(t *testing.T) {
+	origToken := os.Getenv("WEBHOOK_TOKEN")
+	defer os.Setenv("WEBHOOK_TOKEN", origToken)
+	os.Unsetenv("WEBHOOK_TOKEN")
+
+	app := NewApp(&config.Config{}, "")
+
+	err := app.runServe()
+	if err == nil || err.Error() != "WEBHOOK_TOKEN environment variable not set" {
+		t.Errorf("runServe() error = %v, want missing token error", err)
+	}
+}
+
+func TestQueueFetcher_FiltersSenders(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data.json")
+	cfg := &config.Config{Senders: []string{"102", "OTHER"}, DataFilePath: dataPath}
+	app := NewApp(cfg, "")
+
+	handle, err := app.webhookHandler(WebhookModeQueue)
+	if err != nil {
+		t.Fatalf("webhookHandler() error = %v", err)
+	}
+	handle(&message.Message{Timestamp: time.Now(), Sender: "102", Content: "a"})
+	handle(&message.Message{Timestamp: time.Now(), Sender: "OTHER", Content: "b"})
+
+	fetcher := NewQueueFetcher(dataPath, []string{"102"})
+	if err := fetcher.CheckDependencies(); err != nil {
+		t.Fatalf("CheckDependencies() error = %v", err)
+	}
+	messages, _, err := fetcher.FetchMessages()
+	if err != nil {
+		t.Fatalf("FetchMessages() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].Sender != "102" {
+		t.Errorf("expected only messages from 102, got %v", messages)
+	}
+}
+
+func TestDebouncer_CoalescesBursts(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{}, 2)
+	d := newDebouncer(20*time.Millisecond, func() {
+		calls.Add(1)
+		done <- struct{}{}
+	})
+
+	for i := 0; i < 3; i++ {
+		d.trigger()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("debounced function was not called")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("function called %d times, want 1", n)
+	}
+}
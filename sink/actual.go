@@ -0,0 +1,103 @@
+package sink
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type ActualConfig struct {
+	URL      string
+	APIKey   string
+	BudgetID string
+	// Accounts maps a card's last 4 digits to an Actual Budget account ID.
+	Accounts map[string]string
+}
+
+// ActualSink imports transactions into Actual Budget through an
+// actual-http-api server, which handles reconciliation by imported_id.
+type ActualSink struct {
+	cfg        ActualConfig
+	httpClient *http.Client
+}
+
+type actualImportRequest struct {
+	Transactions []actualTransaction `json:"transactions"`
+}
+
+type actualTransaction struct {
+	Account    string `json:"account"`
+	Date       string `json:"date"`
+	Amount     int64  `json:"amount"`
+	PayeeName  string `json:"payee_name"`
+	ImportedID string `json:"imported_id"`
+	Notes      string `json:"notes,omitempty"`
+	Cleared    bool   `json:"cleared"`
+}
+
+func NewActualSink(cfg ActualConfig) (*ActualSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("actual sink requires a url")
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("actual sink requires an api key")
+	}
+	if cfg.BudgetID == "" {
+		return nil, fmt.Errorf("actual sink requires a budget_id")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &ActualSink{cfg: cfg, httpClient: newHTTPClient()}, nil
+}
+
+func (a *ActualSink) Name() string {
+	return "actual:" + a.cfg.URL + "/" + a.cfg.BudgetID
+}
+
+// Validate rejects transactions of cards without a configured account, so
+// that the rest of the batch is still imported.
+func (a *ActualSink) Validate(tx Transaction) error {
+	if _, ok := a.cfg.Accounts[tx.Last4]; !ok {
+		return fmt.Errorf("no Actual Budget account configured for card %s", tx.Last4)
+	}
+	return nil
+}
+
+func (a *ActualSink) Write(transactions []Transaction) error {
+	byAccount := make(map[string][]actualTransaction)
+	var order []string
+
+	for _, tx := range transactions {
+		if err := a.Validate(tx); err != nil {
+			return err
+		}
+		accountID := a.cfg.Accounts[tx.Last4]
+		if _, seen := byAccount[accountID]; !seen {
+			order = append(order, accountID)
+		}
+
+		at := actualTransaction{
+			Account:    accountID,
+			Date:       tx.Date.Format("2006-01-02"),
+			Amount:     int64(math.Round(tx.Amount * 100)),
+			PayeeName:  tx.Payee,
+			ImportedID: tx.ImportID,
+			Cleared:    true,
+		}
+		if tx.Original.Currency != "" && tx.Original.Currency != tx.Currency {
+			at.Notes = fmt.Sprintf("%.2f %s", tx.Original.Value, tx.Original.Currency)
+		}
+		byAccount[accountID] = append(byAccount[accountID], at)
+	}
+
+	headers := map[string]string{"x-api-key": a.cfg.APIKey}
+	for _, accountID := range order {
+		endpoint := fmt.Sprintf("%s/v1/budgets/%s/accounts/%s/transactions/import",
+			a.cfg.URL, url.PathEscape(a.cfg.BudgetID), url.PathEscape(accountID))
+		if err := postJSON(a.httpClient, endpoint, headers, actualImportRequest{Transactions: byAccount[accountID]}); err != nil {
+			return fmt.Errorf("failed to import into account %s: %w", accountID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ExportSink appends transactions to a CSV file (with a header on first
+// write) or to an NDJSON file.
+type ExportSink struct {
+	path   string
+	format string
+}
+
+type exportRecord struct {
+	ImportID         string  `json:"import_id"`
+	Date             string  `json:"date"`
+	Card             string  `json:"card"`
+	Payee            string  `json:"payee"`
+	Amount           float64 `json:"amount"`
+	Currency         string  `json:"currency"`
+	OriginalAmount   float64 `json:"original_amount"`
+	OriginalCurrency string  `json:"original_currency"`
+	Sender           string  `json:"sender"`
+}
+
+var exportHeader = []string{"import_id", "date", "card", "payee", "amount", "currency", "original_amount", "original_currency", "sender"}
+
+func NewExportSink(path, format string) (*ExportSink, error) {
+	if format != ExportCSV && format != ExportJSON {
+		return nil, fmt.Errorf("unknown export format: %q", format)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("%s sink requires a path", format)
+	}
+	return &ExportSink{path: path, format: format}, nil
+}
+
+func (e *ExportSink) Name() string {
+	return e.format + ":" + e.path
+}
+
+func (e *ExportSink) Write(transactions []Transaction) error {
+	info, statErr := os.Stat(e.path)
+	isNew := statErr != nil || info.Size() == 0
+
+	f, err := os.OpenFile(e.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if e.format == ExportJSON {
+		encoder := json.NewEncoder(f)
+		for _, tx := range transactions {
+			if err := encoder.Encode(newExportRecord(tx)); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		return f.Close()
+	}
+
+	writer := csv.NewWriter(f)
+	if isNew {
+		writer.Write(exportHeader)
+	}
+	for _, tx := range transactions {
+		r := newExportRecord(tx)
+		writer.Write([]string{
+			r.ImportID,
+			r.Date,
+			r.Card,
+			r.Payee,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+			r.Currency,
+			strconv.FormatFloat(r.OriginalAmount, 'f', 2, 64),
+			r.OriginalCurrency,
+			r.Sender,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newExportRecord(tx Transaction) exportRecord {
+	sender := ""
+	if tx.Message != nil {
+		sender = tx.Message.Sender
+	}
+	return exportRecord{
+		ImportID:         tx.ImportID,
+		Date:             tx.Date.Format("2006-01-02"),
+		Card:             tx.Last4,
+		Payee:            tx.Payee,
+		Amount:           tx.Amount,
+		Currency:         tx.Currency,
+		OriginalAmount:   tx.Original.Value,
+		OriginalCurrency: tx.Original.Currency,
+		Sender:           sender,
+	}
+}
@@ -0,0 +1,57 @@
+package sink
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSink_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	sk, err := NewExportSink(path, ExportCSV)
+	if err != nil {
+		t.Fatalf("NewExportSink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	sk.Write([]Transaction{st})
+	sk.Write([]Transaction{st})
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d lines:\n%s", len(lines), data)
+	}
+	if !strings.HasPrefix(lines[0], "import_id,date,card") {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], ",2026-01-10,1234,LINELLA,-9.65,MDL,9.65,MDL,MAIB") {
+		t.Errorf("unexpected row: %s", lines[1])
+	}
+}
+
+func TestExportSink_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.ndjson")
+	sk, err := NewExportSink(path, ExportJSON)
+	if err != nil {
+		t.Fatalf("NewExportSink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var record exportRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if record.ImportID != st.ImportID || record.Amount != -9.65 || record.Card != "1234" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
@@ -0,0 +1,119 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type FireflyConfig struct {
+	URL   string
+	Token string
+	// Accounts maps a card's last 4 digits to a Firefly III asset account name.
+	Accounts map[string]string
+}
+
+// FireflySink creates transactions through the Firefly III REST API.
+type FireflySink struct {
+	cfg        FireflyConfig
+	httpClient *http.Client
+}
+
+type fireflyTransactionRequest struct {
+	ErrorIfDuplicateHash bool                 `json:"error_if_duplicate_hash"`
+	ApplyRules           bool                 `json:"apply_rules"`
+	Transactions         []fireflyTransaction `json:"transactions"`
+}
+
+type fireflyTransaction struct {
+	Type                string `json:"type"`
+	Date                string `json:"date"`
+	Amount              string `json:"amount"`
+	Description         string `json:"description"`
+	CurrencyCode        string `json:"currency_code"`
+	ForeignAmount       string `json:"foreign_amount,omitempty"`
+	ForeignCurrencyCode string `json:"foreign_currency_code,omitempty"`
+	SourceName          string `json:"source_name"`
+	DestinationName     string `json:"destination_name"`
+	ExternalID          string `json:"external_id"`
+	Notes               string `json:"notes,omitempty"`
+}
+
+func NewFireflySink(cfg FireflyConfig) (*FireflySink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("firefly sink requires a url")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("firefly sink requires a token")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &FireflySink{cfg: cfg, httpClient: newHTTPClient()}, nil
+}
+
+func (f *FireflySink) Name() string {
+	return "firefly:" + f.cfg.URL
+}
+
+// Write posts one transaction group per transaction, since Firefly III
+// rejects the whole group when any split is a duplicate. A duplicate was
+// created by an earlier run that failed before recording it, so it counts
+// as written.
+func (f *FireflySink) Write(transactions []Transaction) error {
+	headers := map[string]string{"Authorization": "Bearer " + f.cfg.Token}
+	url := f.cfg.URL + "/api/v1/transactions"
+
+	for _, tx := range transactions {
+		err := postJSON(f.httpClient, url, headers, f.request(tx))
+		if isDuplicateHash(err) {
+			slog.Debug("transaction already in Firefly III", "import_id", tx.ImportID)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create transaction %s: %w", tx.ImportID, err)
+		}
+	}
+	return nil
+}
+
+func isDuplicateHash(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity &&
+		strings.Contains(apiErr.Body, "Duplicate of transaction")
+}
+
+func (f *FireflySink) request(tx Transaction) fireflyTransactionRequest {
+	account := accountName(f.cfg.Accounts, tx.Last4)
+
+	ft := fireflyTransaction{
+		Date:         tx.Date.Format("2006-01-02T15:04:05-07:00"),
+		Amount:       strconv.FormatFloat(math.Abs(tx.Amount), 'f', 2, 64),
+		Description:  tx.Payee,
+		CurrencyCode: tx.Currency,
+		ExternalID:   tx.ImportID,
+	}
+	if tx.Amount < 0 {
+		ft.Type = "withdrawal"
+		ft.SourceName = account
+		ft.DestinationName = tx.Payee
+	} else {
+		ft.Type = "deposit"
+		ft.SourceName = tx.Payee
+		ft.DestinationName = account
+	}
+	if tx.Original.Currency != "" && tx.Original.Currency != tx.Currency {
+		ft.ForeignAmount = strconv.FormatFloat(tx.Original.Value, 'f', 2, 64)
+		ft.ForeignCurrencyCode = tx.Original.Currency
+	}
+	if tx.Message != nil {
+		ft.Notes = tx.Message.Content
+	}
+
+	return fireflyTransactionRequest{
+		ErrorIfDuplicateHash: true,
+		Transactions:         []fireflyTransaction{ft},
+	}
+}
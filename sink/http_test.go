@@ -0,0 +1,148 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func TestFireflySink_Write(t *testing.T) {
+	var got fireflyTransactionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/transactions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	sk, err := NewFireflySink(FireflyConfig{URL: server.URL + "/", Token: "secret"})
+	if err != nil {
+		t.Fatalf("NewFireflySink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	tx.Original = template.Amount{Value: 5, Currency: "EUR"}
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(got.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(got.Transactions))
+	}
+	ft := got.Transactions[0]
+	if ft.Type != "withdrawal" || ft.Amount != "9.65" || ft.SourceName != "Card 1234" || ft.DestinationName != "LINELLA" {
+		t.Errorf("unexpected transaction: %+v", ft)
+	}
+	if ft.ExternalID != st.ImportID || ft.ForeignAmount != "5.00" || ft.ForeignCurrencyCode != "EUR" {
+		t.Errorf("unexpected transaction: %+v", ft)
+	}
+	if !got.ErrorIfDuplicateHash {
+		t.Error("error_if_duplicate_hash should be set")
+	}
+}
+
+func TestFireflySink_Write_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"The given data was invalid."}`))
+	}))
+	defer server.Close()
+
+	sk, _ := NewFireflySink(FireflyConfig{URL: server.URL, Token: "secret"})
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err == nil {
+		t.Error("Write() expected error for 422 response")
+	}
+}
+
+func TestFireflySink_Write_Duplicate(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		if posts == 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"Duplicate of transaction #1.","errors":{"transactions.0.description":["Duplicate of transaction #1."]}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// The first transaction was created by a run that failed before
+	// recording it
+	sk, _ := NewFireflySink(FireflyConfig{URL: server.URL, Token: "secret"})
+	msg, tx := testPurchase()
+	first, _ := NewTransaction(msg, tx)
+	second := first
+	second.ImportID = "other"
+	if err := sk.Write([]Transaction{first, second}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if posts != 2 {
+		t.Errorf("posts = %d, want 2", posts)
+	}
+}
+
+func TestActualSink_Write(t *testing.T) {
+	var path string
+	var got actualImportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("x-api-key") != "secret" {
+			t.Errorf("unexpected x-api-key header: %s", r.Header.Get("x-api-key"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"data":{"added":[],"updated":[]}}`))
+	}))
+	defer server.Close()
+
+	sk, err := NewActualSink(ActualConfig{
+		URL:      server.URL,
+		APIKey:   "secret",
+		BudgetID: "budget-1",
+		Accounts: map[string]string{"1234": "acct-1"},
+	})
+	if err != nil {
+		t.Fatalf("NewActualSink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if path != "/v1/budgets/budget-1/accounts/acct-1/transactions/import" {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if len(got.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(got.Transactions))
+	}
+	at := got.Transactions[0]
+	if at.Amount != -965 || at.ImportedID != st.ImportID || at.Date != "2026-01-10" || at.PayeeName != "LINELLA" {
+		t.Errorf("unexpected transaction: %+v", at)
+	}
+}
+
+func TestActualSink_Write_UnknownAccount(t *testing.T) {
+	sk, _ := NewActualSink(ActualConfig{URL: "http://localhost", APIKey: "secret", BudgetID: "b"})
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err == nil {
+		t.Error("Write() expected error for unmapped card")
+	}
+	if err := sk.Validate(st); err == nil {
+		t.Error("Validate() expected error for unmapped card")
+	}
+}
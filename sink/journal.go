@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	JournalLedger    = "ledger"
+	JournalHledger   = "hledger"
+	JournalBeancount = "beancount"
+)
+
+type JournalConfig struct {
+	Path           string
+	Dialect        string
+	AccountPrefix  string
+	ExpenseAccount string
+	IncomeAccount  string
+	// Accounts overrides the journal account for a card's last 4 digits.
+	Accounts map[string]string
+}
+
+// JournalSink appends transactions to a plain-text accounting journal.
+type JournalSink struct {
+	cfg JournalConfig
+}
+
+func NewJournalSink(cfg JournalConfig) (*JournalSink, error) {
+	switch cfg.Dialect {
+	case JournalLedger, JournalHledger, JournalBeancount:
+	default:
+		return nil, fmt.Errorf("unknown journal dialect: %q", cfg.Dialect)
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("%s sink requires a path", cfg.Dialect)
+	}
+
+	if cfg.AccountPrefix == "" {
+		cfg.AccountPrefix = "Assets:Cards"
+	}
+	if cfg.ExpenseAccount == "" {
+		cfg.ExpenseAccount = "Expenses:Uncategorized"
+	}
+	if cfg.IncomeAccount == "" {
+		cfg.IncomeAccount = "Income:Uncategorized"
+	}
+
+	return &JournalSink{cfg: cfg}, nil
+}
+
+func (j *JournalSink) Name() string {
+	return j.cfg.Dialect + ":" + j.cfg.Path
+}
+
+func (j *JournalSink) Write(transactions []Transaction) error {
+	var b strings.Builder
+	for _, tx := range transactions {
+		b.WriteString("\n")
+		if j.cfg.Dialect == JournalBeancount {
+			j.writeBeancount(&b, tx)
+		} else {
+			j.writeLedger(&b, tx)
+		}
+	}
+
+	f, err := os.OpenFile(j.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (j *JournalSink) account(last4 string) string {
+	if account, ok := j.cfg.Accounts[last4]; ok {
+		return account
+	}
+	// Beancount account components must start with a capital letter
+	return j.cfg.AccountPrefix + ":Card" + last4
+}
+
+func (j *JournalSink) counterAccount(tx Transaction) string {
+	if tx.Amount < 0 {
+		return j.cfg.ExpenseAccount
+	}
+	return j.cfg.IncomeAccount
+}
+
+func (j *JournalSink) writeLedger(b *strings.Builder, tx Transaction) {
+	fmt.Fprintf(b, "%s * %s\n", tx.Date.Format("2006-01-02"), tx.Payee)
+	fmt.Fprintf(b, "    ; import_id: %s\n", tx.ImportID)
+	if tx.Original.Currency != tx.Currency {
+		fmt.Fprintf(b, "    ; original: %.2f %s\n", tx.Original.Value, tx.Original.Currency)
+	}
+	fmt.Fprintf(b, "    %-40s  %.2f %s\n", j.account(tx.Last4), tx.Amount, tx.Currency)
+	fmt.Fprintf(b, "    %s\n", j.counterAccount(tx))
+}
+
+func (j *JournalSink) writeBeancount(b *strings.Builder, tx Transaction) {
+	fmt.Fprintf(b, "%s * %s \"\"\n", tx.Date.Format("2006-01-02"), beancountString(tx.Payee))
+	fmt.Fprintf(b, "  import_id: %s\n", beancountString(tx.ImportID))
+	if tx.Original.Currency != tx.Currency {
+		fmt.Fprintf(b, "  original: %s\n", beancountString(fmt.Sprintf("%.2f %s", tx.Original.Value, tx.Original.Currency)))
+	}
+	fmt.Fprintf(b, "  %-40s  %.2f %s\n", j.account(tx.Last4), tx.Amount, tx.Currency)
+	fmt.Fprintf(b, "  %s\n", j.counterAccount(tx))
+}
+
+func beancountString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
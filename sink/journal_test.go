@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalSink_Ledger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.ledger")
+	sk, err := NewJournalSink(JournalConfig{Path: path, Dialect: JournalLedger})
+	if err != nil {
+		t.Fatalf("NewJournalSink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	journal := string(data)
+	for _, want := range []string{
+		"2026-01-10 * LINELLA",
+		"; import_id: " + st.ImportID,
+		"Assets:Cards:Card1234",
+		"-9.65 MDL",
+		"Expenses:Uncategorized",
+	} {
+		if !strings.Contains(journal, want) {
+			t.Errorf("journal missing %q:\n%s", want, journal)
+		}
+	}
+}
+
+func TestJournalSink_Beancount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	sk, err := NewJournalSink(JournalConfig{
+		Path:     path,
+		Dialect:  JournalBeancount,
+		Accounts: map[string]string{"1234": "Assets:MAIB:Visa"},
+	})
+	if err != nil {
+		t.Fatalf("NewJournalSink() error = %v", err)
+	}
+
+	msg, tx := testPurchase()
+	tx.Address = `Cafe "Roma"`
+	tx.Original = tx.Converted
+	st, _ := NewTransaction(msg, tx)
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sk.Write([]Transaction{st}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	journal := string(data)
+	if !strings.Contains(journal, `2026-01-10 * "Cafe \"Roma\"" ""`) {
+		t.Errorf("journal missing escaped payee:\n%s", journal)
+	}
+	if !strings.Contains(journal, `import_id: "`+st.ImportID+`"`) {
+		t.Errorf("journal missing import_id metadata:\n%s", journal)
+	}
+	if !strings.Contains(journal, "Assets:MAIB:Visa") {
+		t.Errorf("journal missing mapped account:\n%s", journal)
+	}
+	if strings.Count(journal, "import_id") != 2 {
+		t.Errorf("Write() should append, got:\n%s", journal)
+	}
+}
+
+func TestNewJournalSink_Invalid(t *testing.T) {
+	if _, err := NewJournalSink(JournalConfig{Path: "x", Dialect: "gnucash"}); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+	if _, err := NewJournalSink(JournalConfig{Dialect: JournalLedger}); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
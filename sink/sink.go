@@ -0,0 +1,200 @@
+package sink
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+// Transaction is the target-neutral form of a parsed, converted transaction.
+type Transaction struct {
+	ImportID string
+	Date     time.Time
+	Last4    string
+	Payee    string
+	Amount   float64 // Signed, in the converted currency
+	Currency string
+	Original template.Amount
+	Message  *message.Message
+}
+
+type Sink interface {
+	// Name identifies the sink's sync records, so it must be stable
+	// across runs.
+	Name() string
+	Write(transactions []Transaction) error
+}
+
+// Validator is implemented by sinks that cannot take some transactions.
+// Rejected transactions are reported as failed, left out of the write and
+// tried again on the next run.
+type Validator interface {
+	Validate(tx Transaction) error
+}
+
+var last4Regex = regexp.MustCompile(`\d{4}$`)
+
+func NewTransaction(msg *message.Message, tx *template.Transaction) (Transaction, error) {
+	last4 := last4Regex.FindString(tx.Card)
+	if last4 == "" {
+		return Transaction{}, fmt.Errorf("could not extract last4 from card: %s", tx.Card)
+	}
+
+	amount, err := ynab.TotalAmount(tx)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	payee := tx.Address
+	if payee == "" {
+		payee = "Unknown"
+	}
+
+	return Transaction{
+		ImportID: ynab.GenerateImportID(msg, tx),
+		Date:     msg.Timestamp,
+		Last4:    last4,
+		Payee:    payee,
+		Amount:   amount,
+		Currency: tx.Converted.Currency,
+		Original: tx.Original,
+		Message:  msg,
+	}, nil
+}
+
+type Syncer struct {
+	store     *Store
+	startDate time.Time
+}
+
+func NewSyncer(store *Store, startDate time.Time) *Syncer {
+	return &Syncer{
+		store:     store,
+		startDate: startDate,
+	}
+}
+
+func (s *Syncer) Sync(sk Sink, messages []*message.Message, transactions []*template.Transaction) (*ynab.SyncResult, error) {
+	if len(messages) != len(transactions) {
+		return nil, fmt.Errorf("messages and transactions length mismatch: %d vs %d", len(messages), len(transactions))
+	}
+
+	result := &ynab.SyncResult{Total: len(transactions)}
+	actions := s.lifecycleActions(messages, transactions)
+
+	var toWrite []Transaction
+	for i, tx := range transactions {
+		msg := messages[i]
+		if msg.Timestamp.Before(s.startDate) || actions[i] == skipEvent {
+			result.Skipped++
+			continue
+		}
+
+		st, err := NewTransaction(msg, tx)
+		if err != nil {
+			result.Skipped++
+			result.Failed = append(result.Failed, fmt.Sprintf("Failed to map: %v", err))
+			continue
+		}
+		if actions[i] == cancelEvent {
+			st.Amount = -st.Amount
+		}
+		if v, ok := sk.(Validator); ok {
+			if err := v.Validate(st); err != nil {
+				result.Skipped++
+				result.Failed = append(result.Failed, fmt.Sprintf("Failed to map: %v", err))
+				continue
+			}
+		}
+
+		synced, err := s.store.IsSynced(sk.Name(), st.ImportID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check sync status: %w", err)
+		}
+		if synced {
+			result.Skipped++
+			continue
+		}
+
+		toWrite = append(toWrite, st)
+	}
+
+	if len(toWrite) == 0 {
+		return result, nil
+	}
+
+	if err := sk.Write(toWrite); err != nil {
+		return result, fmt.Errorf("failed to write to %s: %w", sk.Name(), err)
+	}
+
+	importIDs := make([]string, len(toWrite))
+	for i, st := range toWrite {
+		importIDs[i] = st.ImportID
+	}
+	if err := s.store.RecordSync(sk.Name(), importIDs); err != nil {
+		return result, fmt.Errorf("failed to record sync: %w", err)
+	}
+	result.Synced = len(toWrite)
+
+	return result, nil
+}
+
+type lifecycleAction int
+
+const (
+	writeEvent lifecycleAction = iota
+	skipEvent
+	// cancelEvent writes the event with the opposite sign, offsetting an
+	// authorization written earlier
+	cancelEvent
+)
+
+// lifecycleActions decides how each event of a card purchase reaches a sink,
+// which cannot update what it has written. The first event is written; a
+// settlement of an authorization written before is skipped, a decline
+// offsets it and a decline without one is skipped. Reversals are inflows
+// and are always written.
+func (s *Syncer) lifecycleActions(messages []*message.Message, transactions []*template.Transaction) []lifecycleAction {
+	order := make([]int, len(messages))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return messages[order[a]].Timestamp.Before(messages[order[b]].Timestamp)
+	})
+
+	actions := make([]lifecycleAction, len(messages))
+	authorized := make(map[string]time.Time)
+	for _, i := range order {
+		msg, tx := messages[i], transactions[i]
+		key := ynab.PurchaseKey(msg.Sender, tx)
+		at, open := authorized[key]
+		open = open && msg.Timestamp.Sub(at) <= ynab.LifecycleWindow
+
+		switch template.StageOf(tx) {
+		case template.StageAuthorized:
+			if !msg.Timestamp.Before(s.startDate) {
+				authorized[key] = msg.Timestamp
+			}
+		case template.StageSettled:
+			if open {
+				actions[i] = skipEvent
+			}
+			delete(authorized, key)
+		case template.StageDeclined:
+			actions[i] = skipEvent
+			if open {
+				actions[i] = cancelEvent
+			}
+			delete(authorized, key)
+		case template.StageReversed:
+			delete(authorized, key)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,205 @@
+package sink
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+type recordingSink struct {
+	name    string
+	written [][]Transaction
+}
+
+func (r *recordingSink) Name() string { return r.name }
+
+func (r *recordingSink) Write(transactions []Transaction) error {
+	r.written = append(r.written, transactions)
+	return nil
+}
+
+func testPurchase() (*message.Message, *template.Transaction) {
+	msg := &message.Message{
+		Timestamp: time.Date(2026, 1, 10, 12, 30, 0, 0, time.UTC),
+		Sender:    "MAIB",
+		Content:   "Tranzactie reusita\nCard: 5*1234\nSuma: 9.65 MDL",
+	}
+	tx := &template.Transaction{
+		Operation: "Tranzactie reusita",
+		Direction: template.DirectionDebit,
+		Card:      "5*1234",
+		Original:  template.Amount{Value: 9.65, Currency: "MDL"},
+		Converted: template.Amount{Value: 9.65, Currency: "MDL"},
+		Address:   "LINELLA",
+	}
+	return msg, tx
+}
+
+func TestNewTransaction(t *testing.T) {
+	msg, tx := testPurchase()
+
+	st, err := NewTransaction(msg, tx)
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+	if st.Last4 != "1234" {
+		t.Errorf("Last4 = %q, want 1234", st.Last4)
+	}
+	if st.Amount != -9.65 {
+		t.Errorf("Amount = %v, want -9.65", st.Amount)
+	}
+	if st.Payee != "LINELLA" {
+		t.Errorf("Payee = %q, want LINELLA", st.Payee)
+	}
+	if st.ImportID == "" {
+		t.Error("ImportID should not be empty")
+	}
+}
+
+func TestNewTransaction_NoCard(t *testing.T) {
+	msg, tx := testPurchase()
+	tx.Card = ""
+
+	if _, err := NewTransaction(msg, tx); err == nil {
+		t.Error("NewTransaction() expected error for missing card")
+	}
+}
+
+func TestSyncer_Sync_SkipsAlreadySynced(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+	syncer := NewSyncer(store, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	msg, tx := testPurchase()
+	messages := []*message.Message{msg}
+	transactions := []*template.Transaction{tx}
+
+	first := &recordingSink{name: "first"}
+	result, err := syncer.Sync(first, messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 1 || len(first.written) != 1 {
+		t.Fatalf("first Sync() synced = %d, writes = %d", result.Synced, len(first.written))
+	}
+
+	result, err = syncer.Sync(first, messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 0 || result.Skipped != 1 || len(first.written) != 1 {
+		t.Errorf("second Sync() synced = %d, skipped = %d, writes = %d", result.Synced, result.Skipped, len(first.written))
+	}
+
+	// Sync records are kept per sink
+	second := &recordingSink{name: "second"}
+	result, err = syncer.Sync(second, messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 1 {
+		t.Errorf("other sink Sync() synced = %d, want 1", result.Synced)
+	}
+}
+
+type validatingSink struct {
+	recordingSink
+	reject string
+}
+
+func (v *validatingSink) Validate(tx Transaction) error {
+	if tx.Last4 == v.reject {
+		return fmt.Errorf("no account configured for card %s", tx.Last4)
+	}
+	return nil
+}
+
+func TestSyncer_Sync_SkipsRejected(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+	syncer := NewSyncer(store, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	msg, tx := testPurchase()
+	other := *msg
+	other.Content = "Tranzactie reusita\nCard: 5*9999\nSuma: 9.65 MDL"
+	otherTx := *tx
+	otherTx.Card = "5*9999"
+	messages := []*message.Message{msg, &other}
+	transactions := []*template.Transaction{tx, &otherTx}
+
+	sk := &validatingSink{recordingSink: recordingSink{name: "test"}, reject: "9999"}
+	result, err := syncer.Sync(sk, messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 1 || len(result.Failed) != 1 {
+		t.Fatalf("Sync() synced = %d, failed = %v", result.Synced, result.Failed)
+	}
+
+	// The rejected transaction is not recorded, so it is retried once the
+	// card is configured
+	sk.reject = ""
+	result, err = syncer.Sync(sk, messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 1 || result.Skipped != 1 {
+		t.Errorf("retry Sync() synced = %d, skipped = %d", result.Synced, result.Skipped)
+	}
+}
+
+func TestSyncer_Sync_SkipsBeforeStartDate(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+	syncer := NewSyncer(store, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	msg, tx := testPurchase()
+	sk := &recordingSink{name: "test"}
+	result, err := syncer.Sync(sk, []*message.Message{msg}, []*template.Transaction{tx})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Skipped != 1 || len(sk.written) != 0 {
+		t.Errorf("Sync() skipped = %d, writes = %d", result.Skipped, len(sk.written))
+	}
+}
+
+func TestSyncer_Sync_FollowsPurchaseLifecycle(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data.json"))
+	syncer := NewSyncer(store, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	authMsg, auth := testPurchase()
+	auth.Status = "Odobrena"
+	settleMsg, settle := testPurchase()
+	settleMsg.Timestamp = authMsg.Timestamp.Add(48 * time.Hour)
+	settleMsg.Content += "\nsettled"
+
+	cancelledMsg, cancelled := testPurchase()
+	cancelled.Status = "Odobrena"
+	cancelled.Address = "KAUFLAND"
+	cancelledMsg.Timestamp = authMsg.Timestamp.Add(time.Hour)
+	declineMsg, decline := testPurchase()
+	decline.Status = "Decline"
+	decline.Address = "KAUFLAND"
+	declineMsg.Timestamp = authMsg.Timestamp.Add(2 * time.Hour)
+
+	sk := &recordingSink{name: "test"}
+	result, err := syncer.Sync(sk,
+		[]*message.Message{settleMsg, authMsg, cancelledMsg, declineMsg},
+		[]*template.Transaction{settle, auth, cancelled, decline})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if result.Synced != 3 || result.Skipped != 1 {
+		t.Fatalf("Sync() synced = %d, skipped = %d, want 3 and 1", result.Synced, result.Skipped)
+	}
+	written := sk.written[0]
+	if written[0].Message != authMsg {
+		t.Errorf("first write = %+v, want the authorization and not its settlement", written[0])
+	}
+	if written[1].Amount != -9.65 || written[2].Amount != 9.65 {
+		t.Errorf("declined purchase amounts = %v and %v, want -9.65 offset by 9.65", written[1].Amount, written[2].Amount)
+	}
+}
@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+const storeKey = "sink_synced_transactions"
+
+// Store keeps sync records per sink name, separate from the YNAB records.
+type Store struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+func (s *Store) read() (map[string][]ynab.SyncRecord, error) {
+	records := map[string][]ynab.SyncRecord{}
+	if err := datastore.ReadSection(s.filePath, storeKey, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *Store) IsSynced(sinkName, importID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return false, err
+	}
+
+	for _, record := range records[sinkName] {
+		if record.ImportID == importID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) RecordSync(sinkName string, importIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, id := range importIDs {
+		records[sinkName] = append(records[sinkName], ynab.SyncRecord{ImportID: id, SyncedAt: now})
+	}
+
+	return datastore.WriteSection(s.filePath, storeKey, records)
+}
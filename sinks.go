@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/sink"
+	"github.com/apmyp/ynab_importer_go/template"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+const (
+	SinkLedger    = sink.JournalLedger
+	SinkHledger   = sink.JournalHledger
+	SinkBeancount = sink.JournalBeancount
+	SinkCSV       = sink.ExportCSV
+	SinkJSON      = sink.ExportJSON
+	SinkFirefly   = "firefly"
+	SinkActual    = "actual"
+)
+
+type sinkFactory func(cfg config.SinkConfig) (sink.Sink, error)
+
+func newJournalSink(cfg config.SinkConfig) (sink.Sink, error) {
+	path, err := expandPath(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return sink.NewJournalSink(sink.JournalConfig{
+		Path:           path,
+		Dialect:        cfg.Type,
+		AccountPrefix:  cfg.AccountPrefix,
+		ExpenseAccount: cfg.ExpenseAccount,
+		IncomeAccount:  cfg.IncomeAccount,
+		Accounts:       cfg.Accounts,
+	})
+}
+
+func newExportSink(cfg config.SinkConfig) (sink.Sink, error) {
+	path, err := expandPath(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return sink.NewExportSink(path, cfg.Type)
+}
+
+var sinkRegistry = map[string]sinkFactory{
+	SinkLedger:    newJournalSink,
+	SinkHledger:   newJournalSink,
+	SinkBeancount: newJournalSink,
+	SinkCSV:       newExportSink,
+	SinkJSON:      newExportSink,
+	SinkFirefly: func(cfg config.SinkConfig) (sink.Sink, error) {
+		return sink.NewFireflySink(sink.FireflyConfig{
+			URL:      cfg.URL,
+			Token:    os.Getenv(cfg.TokenEnv),
+			Accounts: cfg.Accounts,
+		})
+	},
+	SinkActual: func(cfg config.SinkConfig) (sink.Sink, error) {
+		return sink.NewActualSink(sink.ActualConfig{
+			URL:      cfg.URL,
+			APIKey:   os.Getenv(cfg.TokenEnv),
+			BudgetID: cfg.BudgetID,
+			Accounts: cfg.Accounts,
+		})
+	},
+}
+
+func buildSinks(cfg *config.Config) ([]sink.Sink, error) {
+	var sinks []sink.Sink
+	for _, sc := range cfg.Sinks {
+		factory, ok := sinkRegistry[sc.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown sink type: %s", sc.Type)
+		}
+		sk, err := factory(sc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure %s sink: %w", sc.Type, err)
+		}
+		sinks = append(sinks, sk)
+	}
+	return sinks, nil
+}
+
+func (app *App) runSinkSync() error {
+	if len(app.config.Sinks) == 0 {
+		return fmt.Errorf("no sinks configured")
+	}
+	app.showMetrics = true
+
+	var startDate time.Time
+	if app.config.YNAB.StartDate != "" {
+		var err error
+		startDate, err = time.Parse("2006-01-02", app.config.YNAB.StartDate)
+		if err != nil {
+			return fmt.Errorf("invalid YNAB start_date format: %w", err)
+		}
+	}
+
+	messages, cleanup, err := app.fetchMessages()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	filteredMessages, filteredTransactions := app.syncableTransactions(messages)
+	return app.syncSinks(startDate, filteredMessages, filteredTransactions)
+}
+
+func (app *App) syncSinks(startDate time.Time, messages []*message.Message, transactions []*template.Transaction) error {
+	sinks, err := buildSinks(app.config)
+	if err != nil {
+		return err
+	}
+
+	// One unreachable sink must not hold back the others
+	var errs []error
+	syncer := sink.NewSyncer(sink.NewStore(app.config.DataFilePath), startDate)
+	for _, sk := range sinks {
+		result, err := syncer.Sync(sk, messages, transactions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sync to %s failed: %w", sk.Name(), err))
+			continue
+		}
+		logSyncResult(sk.Name(), result)
+		app.recordSyncResult(result)
+	}
+	return errors.Join(errs...)
+}
+
+// syncSinksAfterYNAB mirrors transactions YNAB has already taken. A failing
+// sink is logged and catches up on the next run, instead of failing a sync
+// whose YNAB part succeeded.
+func (app *App) syncSinksAfterYNAB(startDate time.Time, messages []*message.Message, transactions []*template.Transaction) {
+	if err := app.syncSinks(startDate, messages, transactions); err != nil {
+		slog.Error("sink sync failed", "error", err)
+		app.recordSinkError(err)
+	}
+}
+
+func logSyncResult(name string, result *ynab.SyncResult) {
+	logger := slog.With("target", name)
+	logger.Info("sync finished",
+		"total", result.Total,
+		"synced", result.Synced,
+		"linked", result.Linked,
+		"updated", result.Updated,
+		"skipped", result.Skipped,
+		"failed", len(result.Failed),
+		"ambiguous", len(result.Ambiguous))
+	for _, failure := range result.Failed {
+		logger.Error("transaction failed to sync", "detail", failure)
+	}
+	for _, ambiguous := range result.Ambiguous {
+		logger.Warn("ambiguous match", "detail", ambiguous)
+	}
+}
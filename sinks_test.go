@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestBuildSinks_UnknownType(t *testing.T) {
+	cfg := &config.Config{
+		Sinks: []config.SinkConfig{{Type: "gnucash", Path: "x"}},
+	}
+	if _, err := buildSinks(cfg); err == nil {
+		t.Error("buildSinks() should return error for unknown sink type")
+	}
+}
+
+func TestBuildSinks_MissingToken(t *testing.T) {
+	cfg := &config.Config{
+		Sinks: []config.SinkConfig{{Type: SinkFirefly, URL: "http://localhost", TokenEnv: "TEST_FIREFLY_TOKEN_UNSET"}},
+	}
+	if _, err := buildSinks(cfg); err == nil {
+		t.Error("buildSinks() should return error when token env is empty")
+	}
+}
+
+func TestApp_runSinkSync(t *testing.T) {
+	dir := t.TempDir()
+	beancountPath := filepath.Join(dir, "main.beancount")
+	csvPath := filepath.Join(dir, "export.csv")
+
+	cfg := &config.Config{
+		Senders:         []string{"102"},
+		DefaultCurrency: "MDL",
+		DataFilePath:    filepath.Join(dir, "data.json"),
+		YNAB:            config.YNABConfig{StartDate: "2023-01-01"},
+		Sinks: []config.SinkConfig{
+			{Type: SinkBeancount, Path: beancountPath},
+			{Type: SinkCSV, Path: csvPath},
+		},
+	}
+	fetcher := &MockFetcher{
+		messages: []*message.Message{
+			{
+				Timestamp: time.Date(2023, 5, 3, 16, 21, 0, 0, time.UTC),
+				Sender:    "102",
+				Content: `Op: Tovary i uslugi
+Karta: *1234
+Status: Odobrena
+Summa: 34 MDL
+Dost: 12500,50
+Data/vremya: 03.05.23 16:21
+Adres: COFFEE SHOP ALPHA
+Podderzhka: +12025551234`,
+			},
+		},
+	}
+
+	app := NewAppWithFetcher(cfg, fetcher)
+	for i := 0; i < 2; i++ {
+		if err := app.runSinkSync(); err != nil {
+			t.Fatalf("runSinkSync() error = %v", err)
+		}
+	}
+
+	journal, err := os.ReadFile(beancountPath)
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	if strings.Count(string(journal), `"COFFEE SHOP ALPHA"`) != 1 {
+		t.Errorf("expected one journal entry, got:\n%s", journal)
+	}
+	if !strings.Contains(string(journal), "-34.00 MDL") {
+		t.Errorf("journal missing amount:\n%s", journal)
+	}
+
+	export, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(export)), "\n"); len(lines) != 2 {
+		t.Errorf("expected header + 1 row, got:\n%s", export)
+	}
+}
+
+func TestApp_runSinkSync_NoSinks(t *testing.T) {
+	app := NewAppWithFetcher(&config.Config{}, &MockFetcher{})
+	if err := app.runSinkSync(); err == nil {
+		t.Error("runSinkSync() should return error when no sinks are configured")
+	}
+}
@@ -0,0 +1,95 @@
+package source
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+type jsonMessage struct {
+	Timestamp time.Time `json:"timestamp"`
+	Sender    string    `json:"sender"`
+	Content   string    `json:"content"`
+}
+
+func ReadJSONFile(path string, senders []string) ([]*message.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open message file: %w", err)
+	}
+	defer f.Close()
+
+	return ReadJSON(f, senders)
+}
+
+// ReadJSON accepts either a JSON array of messages or newline-delimited
+// JSON objects (NDJSON).
+func ReadJSON(r io.Reader, senders []string) ([]*message.Message, error) {
+	if len(senders) == 0 {
+		return []*message.Message{}, nil
+	}
+	allowed := senderSet(senders)
+
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return []*message.Message{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message file: %w", err)
+	}
+
+	decoder := json.NewDecoder(br)
+	var entries []jsonMessage
+
+	if first == '[' {
+		if err := decoder.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to parse message file: %w", err)
+		}
+	} else {
+		for {
+			var entry jsonMessage
+			err := decoder.Decode(&entry)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse message file: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	var messages []*message.Message
+	for _, entry := range entries {
+		if !allowed[entry.Sender] || entry.Content == "" {
+			continue
+		}
+		messages = append(messages, &message.Message{
+			Timestamp: entry.Timestamp.UTC(),
+			Sender:    entry.Sender,
+			Content:   entry.Content,
+		})
+	}
+
+	return messages, nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
@@ -0,0 +1,86 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadJSON_Array(t *testing.T) {
+	content := `[
+  {"timestamp": "2024-01-01T11:00:00Z", "sender": "102", "content": "Op: Tovary i uslugi"},
+  {"timestamp": "2024-01-01T12:00:00+02:00", "sender": "EXIMBANK", "content": "Debitare cont"},
+  {"timestamp": "2024-01-01T13:00:00Z", "sender": "OTHER", "content": "Hello"}
+]`
+
+	messages, err := ReadJSON(strings.NewReader(content), []string{"102", "EXIMBANK"})
+	if err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Content != "Op: Tovary i uslugi" {
+		t.Errorf("unexpected content %q", messages[0].Content)
+	}
+
+	expected := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	if !messages[1].Timestamp.Equal(expected) || messages[1].Timestamp.Location() != time.UTC {
+		t.Errorf("expected UTC timestamp %v, got %v", expected, messages[1].Timestamp)
+	}
+}
+
+func TestReadJSON_NDJSON(t *testing.T) {
+	content := `{"timestamp": "2024-01-01T11:00:00Z", "sender": "102", "content": "first"}
+{"timestamp": "2024-01-01T12:00:00Z", "sender": "102", "content": ""}
+{"timestamp": "2024-01-01T13:00:00Z", "sender": "102", "content": "third"}
+`
+
+	messages, err := ReadJSON(strings.NewReader(content), []string{"102"})
+	if err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[1].Content != "third" {
+		t.Errorf("expected 'third', got %q", messages[1].Content)
+	}
+}
+
+func TestReadJSON_Empty(t *testing.T) {
+	messages, err := ReadJSON(strings.NewReader("  \n"), []string{"102"})
+	if err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected 0 messages, got %d", len(messages))
+	}
+}
+
+func TestReadJSON_Invalid(t *testing.T) {
+	_, err := ReadJSON(strings.NewReader(`{"sender": `), []string{"102"})
+	if err == nil {
+		t.Error("ReadJSON() should return error for malformed JSON")
+	}
+}
+
+func TestReadJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.ndjson")
+	content := `{"timestamp": "2024-01-01T11:00:00Z", "sender": "102", "content": "first"}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	messages, err := ReadJSONFile(path, []string{"102"})
+	if err != nil {
+		t.Fatalf("ReadJSONFile() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(messages))
+	}
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+// SMS Backup & Restore marks received messages with type="1"
+const smsBackupInboxType = "1"
+
+type smsBackupEntry struct {
+	Address string `xml:"address,attr"`
+	Date    string `xml:"date,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:"body,attr"`
+}
+
+func ReadSMSBackupFile(path string, senders []string) ([]*message.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SMS backup: %w", err)
+	}
+	defer f.Close()
+
+	return ReadSMSBackup(f, senders)
+}
+
+func ReadSMSBackup(r io.Reader, senders []string) ([]*message.Message, error) {
+	if len(senders) == 0 {
+		return []*message.Message{}, nil
+	}
+	allowed := senderSet(senders)
+
+	decoder := xml.NewDecoder(r)
+	var messages []*message.Message
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SMS backup: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sms" {
+			continue
+		}
+
+		var entry smsBackupEntry
+		if err := decoder.DecodeElement(&entry, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse SMS backup entry: %w", err)
+		}
+
+		if entry.Type != smsBackupInboxType || !allowed[entry.Address] || entry.Body == "" {
+			continue
+		}
+
+		millis, err := strconv.ParseInt(entry.Date, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q in SMS backup: %w", entry.Date, err)
+		}
+
+		messages = append(messages, &message.Message{
+			Timestamp: time.UnixMilli(millis).UTC(),
+			Sender:    entry.Address,
+			Content:   entry.Body,
+		})
+	}
+
+	return messages, nil
+}
+
+func senderSet(senders []string) map[string]bool {
+	set := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		set[s] = true
+	}
+	return set
+}
@@ -0,0 +1,91 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSMSBackup = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<smses count="4">
+  <sms protocol="0" address="102" date="1704106800000" type="1" body="Op: Tovary i uslugi&#10;Karta: *1234&#10;Summa: 34 MDL" read="1" />
+  <sms protocol="0" address="102" date="1704106900000" type="2" body="Sent by me" read="1" />
+  <sms protocol="0" address="OTHER" date="1704107000000" type="1" body="Not a bank" read="1" />
+  <mms date="1704107100000" msg_box="1" address="102" />
+  <sms protocol="0" address="EXIMBANK" date="1704107200000" type="1" body="Debitare cont Card 9..7890" read="1" />
+</smses>`
+
+func TestReadSMSBackup_FiltersInboxAndSenders(t *testing.T) {
+	messages, err := ReadSMSBackup(strings.NewReader(testSMSBackup), []string{"102", "EXIMBANK"})
+	if err != nil {
+		t.Fatalf("ReadSMSBackup() error = %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+
+	if messages[0].Sender != "102" {
+		t.Errorf("expected sender '102', got %q", messages[0].Sender)
+	}
+	if !strings.Contains(messages[0].Content, "Karta: *1234\n") {
+		t.Errorf("expected multi-line content, got %q", messages[0].Content)
+	}
+	expected := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	if !messages[0].Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %v, got %v", expected, messages[0].Timestamp)
+	}
+
+	if messages[1].Sender != "EXIMBANK" {
+		t.Errorf("expected sender 'EXIMBANK', got %q", messages[1].Sender)
+	}
+}
+
+func TestReadSMSBackup_NoSenders(t *testing.T) {
+	messages, err := ReadSMSBackup(strings.NewReader(testSMSBackup), nil)
+	if err != nil {
+		t.Fatalf("ReadSMSBackup() error = %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected 0 messages, got %d", len(messages))
+	}
+}
+
+func TestReadSMSBackup_InvalidDate(t *testing.T) {
+	content := `<smses><sms address="102" date="yesterday" type="1" body="x" /></smses>`
+	_, err := ReadSMSBackup(strings.NewReader(content), []string{"102"})
+	if err == nil {
+		t.Error("ReadSMSBackup() should return error for invalid date")
+	}
+}
+
+func TestReadSMSBackup_InvalidXML(t *testing.T) {
+	_, err := ReadSMSBackup(strings.NewReader(`<smses><sms`), []string{"102"})
+	if err == nil {
+		t.Error("ReadSMSBackup() should return error for malformed XML")
+	}
+}
+
+func TestReadSMSBackupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.xml")
+	if err := os.WriteFile(path, []byte(testSMSBackup), 0644); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+
+	messages, err := ReadSMSBackupFile(path, []string{"102"})
+	if err != nil {
+		t.Fatalf("ReadSMSBackupFile() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(messages))
+	}
+}
+
+func TestReadSMSBackupFile_NotFound(t *testing.T) {
+	_, err := ReadSMSBackupFile("/nonexistent/sms.xml", []string{"102"})
+	if err == nil {
+		t.Error("ReadSMSBackupFile() should return error for missing file")
+	}
+}
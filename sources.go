@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/email"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/source"
+	"github.com/apmyp/ynab_importer_go/webhook"
+)
+
+const (
+	SourceChatDB    = "chatdb"
+	SourceSMSBackup = "sms_backup_xml"
+	SourceJSON      = "json"
+	SourceWebhook   = "webhook_queue"
+	SourceMbox      = "mbox"
+	SourceMaildir   = "maildir"
+	SourceIMAP      = "imap"
+)
+
+type fetcherFactory func(cfg *config.Config, src config.SourceConfig) MessageFetcher
+
+var sourceRegistry = map[string]fetcherFactory{
+	SourceChatDB: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		fetcher := NewChatDBFetcher(cfg)
+		if src.Path != "" {
+			fetcher.dbPath = src.Path
+		}
+		return fetcher
+	},
+	SourceSMSBackup: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		return NewFileFetcher("SMS backup", src.Path, cfg.Senders, source.ReadSMSBackupFile)
+	},
+	SourceJSON: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		return NewFileFetcher("message file", src.Path, cfg.Senders, source.ReadJSONFile)
+	},
+	SourceWebhook: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		path := src.Path
+		if path == "" {
+			path = cfg.DataFilePath
+		}
+		return NewQueueFetcher(path, cfg.Senders)
+	},
+	SourceMbox: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		return NewFileFetcher("mbox", src.Path, cfg.Senders, emailReader(email.ReadMboxFile, src.FromSenders))
+	},
+	SourceMaildir: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		return NewFileFetcher("maildir", src.Path, cfg.Senders, emailReader(email.ReadMaildir, src.FromSenders))
+	},
+	SourceIMAP: func(cfg *config.Config, src config.SourceConfig) MessageFetcher {
+		return NewIMAPFetcher(cfg, src)
+	},
+}
+
+func emailReader(read func(path string) ([]*email.Email, error), fromSenders map[string]string) func(string, []string) ([]*message.Message, error) {
+	return func(path string, senders []string) ([]*message.Message, error) {
+		emails, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		return email.ToMessages(emails, fromSenders, senders), nil
+	}
+}
+
+// configuredSources falls back to the chat.db source when no sources are
+// listed, so older configs keep working unchanged.
+func configuredSources(cfg *config.Config) []config.SourceConfig {
+	if len(cfg.Sources) == 0 {
+		return []config.SourceConfig{{Type: SourceChatDB}}
+	}
+	return cfg.Sources
+}
+
+type FileFetcher struct {
+	name    string
+	path    string
+	senders []string
+	read    func(path string, senders []string) ([]*message.Message, error)
+}
+
+func NewFileFetcher(name, path string, senders []string, read func(string, []string) ([]*message.Message, error)) *FileFetcher {
+	return &FileFetcher{
+		name:    name,
+		path:    path,
+		senders: senders,
+		read:    read,
+	}
+}
+
+func (f *FileFetcher) CheckDependencies() error {
+	path, err := expandPath(f.path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s not accessible at %s: %w", f.name, path, err)
+	}
+	return nil
+}
+
+func (f *FileFetcher) FetchMessages() ([]*message.Message, func(), error) {
+	path, err := expandPath(f.path)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	messages, err := f.read(path, f.senders)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("failed to read %s: %w", f.name, err)
+	}
+
+	slog.Info("loaded messages", "source", path, "count", len(messages))
+	return messages, func() {}, nil
+}
+
+type MultiFetcher struct {
+	fetchers []MessageFetcher
+	types    []string
+	fetched  map[string][]*message.Message
+	err      error
+}
+
+func NewMultiFetcher(cfg *config.Config) *MultiFetcher {
+	var fetchers []MessageFetcher
+	var types []string
+	for _, src := range configuredSources(cfg) {
+		factory, ok := sourceRegistry[src.Type]
+		if !ok {
+			return &MultiFetcher{err: fmt.Errorf("unknown message source type: %q", src.Type)}
+		}
+		fetchers = append(fetchers, factory(cfg, src))
+		types = append(types, src.Type)
+	}
+
+	return &MultiFetcher{fetchers: fetchers, types: types}
+}
+
+func (m *MultiFetcher) CheckDependencies() error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, f := range m.fetchers {
+		if err := f.CheckDependencies(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MultiFetcher) FetchMessages() ([]*message.Message, func(), error) {
+	if m.err != nil {
+		return nil, func() {}, m.err
+	}
+
+	var all []*message.Message
+	var cleanups []func()
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+
+	m.fetched = make(map[string][]*message.Message)
+	for i, f := range m.fetchers {
+		messages, c, err := f.FetchMessages()
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		cleanups = append(cleanups, c)
+		all = append(all, messages...)
+		m.fetched[m.types[i]] = append(m.fetched[m.types[i]], messages...)
+	}
+
+	if len(m.fetchers) > 1 {
+		sort.SliceStable(all, func(i, j int) bool {
+			return all[i].Timestamp.Before(all[j].Timestamp)
+		})
+	}
+
+	return all, cleanup, nil
+}
+
+// Fetched returns the messages of the last fetch by source type.
+func (m *MultiFetcher) Fetched() map[string][]*message.Message {
+	return m.fetched
+}
+
+type QueueFetcher struct {
+	queue   *webhook.Queue
+	senders map[string]bool
+}
+
+func NewQueueFetcher(dataFilePath string, senders []string) *QueueFetcher {
+	allowed := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		allowed[s] = true
+	}
+	return &QueueFetcher{
+		queue:   webhook.NewQueue(dataFilePath),
+		senders: allowed,
+	}
+}
+
+// CheckDependencies always succeeds: an empty or missing queue just means
+// nothing has been received yet.
+func (f *QueueFetcher) CheckDependencies() error {
+	return nil
+}
+
+func (f *QueueFetcher) FetchMessages() ([]*message.Message, func(), error) {
+	queued, err := f.queue.Messages()
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("failed to read webhook queue: %w", err)
+	}
+
+	var messages []*message.Message
+	for _, msg := range queued {
+		if f.senders[msg.Sender] {
+			messages = append(messages, msg)
+		}
+	}
+
+	slog.Info("loaded messages", "source", "webhook queue", "count", len(messages))
+	return messages, func() {}, nil
+}
+
+type IMAPFetcher struct {
+	imap        email.IMAPConfig
+	passwordEnv string
+	fromSenders email.SenderMap
+	senders     []string
+}
+
+func NewIMAPFetcher(cfg *config.Config, src config.SourceConfig) *IMAPFetcher {
+	mailbox := src.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+
+	// Only fetch mail that could still be synced
+	since, _ := time.Parse("2006-01-02", cfg.YNAB.StartDate)
+
+	return &IMAPFetcher{
+		imap: email.IMAPConfig{
+			Address:  src.Address,
+			Username: src.Username,
+			Mailbox:  mailbox,
+			TLS:      src.TLS,
+			Since:    since,
+		},
+		passwordEnv: src.PasswordEnv,
+		fromSenders: src.FromSenders,
+		senders:     cfg.Senders,
+	}
+}
+
+func (f *IMAPFetcher) CheckDependencies() error {
+	if f.imap.Address == "" {
+		return fmt.Errorf("IMAP source requires an address")
+	}
+	if f.passwordEnv == "" || os.Getenv(f.passwordEnv) == "" {
+		return fmt.Errorf("IMAP password environment variable not set: %q", f.passwordEnv)
+	}
+	return nil
+}
+
+func (f *IMAPFetcher) FetchMessages() ([]*message.Message, func(), error) {
+	imapConfig := f.imap
+	imapConfig.Password = os.Getenv(f.passwordEnv)
+
+	emails, err := email.FetchIMAP(imapConfig)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	messages := email.ToMessages(emails, f.fromSenders, f.senders)
+	slog.Info("loaded messages", "source", "imap", "address", f.imap.Address, "mailbox", f.imap.Mailbox, "count", len(messages))
+	return messages, func() {}, nil
+}
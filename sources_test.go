@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestNewMultiFetcher_DefaultsToChatDB(t *testing.T) {
+	cfg := &config.Config{
+		Senders: []string{"102"},
+		DBPath:  "/nonexistent/chat.db",
+	}
+
+	fetcher := NewMultiFetcher(cfg)
+	if len(fetcher.fetchers) != 1 {
+		t.Fatalf("expected 1 fetcher, got %d", len(fetcher.fetchers))
+	}
+	if _, ok := fetcher.fetchers[0].(*ChatDBFetcher); !ok {
+		t.Errorf("expected ChatDBFetcher, got %T", fetcher.fetchers[0])
+	}
+}
+
+func TestNewMultiFetcher_UnknownType(t *testing.T) {
+	cfg := &config.Config{
+		Sources: []config.SourceConfig{{Type: "carrier_pigeon"}},
+	}
+
+	fetcher := NewMultiFetcher(cfg)
+	if err := fetcher.CheckDependencies(); err == nil {
+		t.Error("CheckDependencies() should return error for unknown source type")
+	}
+	if _, _, err := fetcher.FetchMessages(); err == nil {
+		t.Error("FetchMessages() should return error for unknown source type")
+	}
+}
+
+func TestMultiFetcher_CombinesSources(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "sms.xml")
+	jsonPath := filepath.Join(dir, "messages.ndjson")
+
+	xmlContent := `<smses><sms address="102" date="1704110400000" type="1" body="from android" /></smses>`
+	if err := os.WriteFile(xmlPath, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("failed to write xml: %v", err)
+	}
+	jsonContent := `{"timestamp": "2024-01-01T10:00:00Z", "sender": "102", "content": "from json"}`
+	if err := os.WriteFile(jsonPath, []byte(jsonContent), 0644); err != nil {
+		t.Fatalf("failed to write json: %v", err)
+	}
+
+	cfg := &config.Config{
+		Senders: []string{"102"},
+		Sources: []config.SourceConfig{
+			{Type: SourceSMSBackup, Path: xmlPath},
+			{Type: SourceJSON, Path: jsonPath},
+		},
+	}
+
+	fetcher := NewMultiFetcher(cfg)
+	if err := fetcher.CheckDependencies(); err != nil {
+		t.Fatalf("CheckDependencies() error = %v", err)
+	}
+
+	messages, cleanup, err := fetcher.FetchMessages()
+	if err != nil {
+		t.Fatalf("FetchMessages() error = %v", err)
+	}
+	defer cleanup()
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	// Sorted by timestamp across sources
+	if messages[0].Content != "from json" || messages[1].Content != "from android" {
+		t.Errorf("messages not ordered by timestamp: %q, %q", messages[0].Content, messages[1].Content)
+	}
+}
+
+func TestMultiFetcher_MissingFile(t *testing.T) {
+	cfg := &config.Config{
+		Senders: []string{"102"},
+		Sources: []config.SourceConfig{{Type: SourceJSON, Path: "/nonexistent/messages.json"}},
+	}
+
+	fetcher := NewMultiFetcher(cfg)
+	if err := fetcher.CheckDependencies(); err == nil {
+		t.Error("CheckDependencies() should return error for missing file")
+	}
+}
+
+func TestMultiFetcher_FetchError_RunsCleanup(t *testing.T) {
+	first := &MockFetcher{messages: []*message.Message{{Timestamp: time.Now(), Sender: "102", Content: "ok"}}}
+	second := &MockFetcher{fetchErr: errors.New("boom")}
+
+	fetcher := &MultiFetcher{fetchers: []MessageFetcher{first, second}, types: []string{SourceJSON, SourceJSON}}
+	if _, _, err := fetcher.FetchMessages(); err == nil {
+		t.Fatal("FetchMessages() should return error when a source fails")
+	}
+	if !first.cleanupCalled {
+		t.Error("cleanup of earlier sources should run on failure")
+	}
+}
+
+func TestMultiFetcher_MaildirSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "new"), 0755); err != nil {
+		t.Fatalf("failed to create maildir: %v", err)
+	}
+	content := "From: Alerts <alerts@eximbank.md>\r\nDate: Sat, 10 Jan 2026 10:00:00 +0000\r\n\r\nDebitare cont Card 9..7890\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "new", "1768039200.M1.host"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	cfg := &config.Config{
+		Senders: []string{"EXIMBANK"},
+		Sources: []config.SourceConfig{{
+			Type:        SourceMaildir,
+			Path:        dir,
+			FromSenders: map[string]string{"@eximbank.md": "EXIMBANK"},
+		}},
+	}
+
+	fetcher := NewMultiFetcher(cfg)
+	if err := fetcher.CheckDependencies(); err != nil {
+		t.Fatalf("CheckDependencies() error = %v", err)
+	}
+	messages, _, err := fetcher.FetchMessages()
+	if err != nil {
+		t.Fatalf("FetchMessages() error = %v", err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Sender != "EXIMBANK" || messages[0].Content != "Debitare cont Card 9..7890" {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+}
+
+func TestIMAPFetcher_CheckDependencies(t *testing.T) {
+	origPassword := os.Getenv("TEST_IMAP_PASSWORD")
+	defer os.Setenv("TEST_IMAP_PASSWORD", origPassword)
+
+	cfg := &config.Config{
+		Senders: []string{"102"},
+		YNAB:    config.YNABConfig{StartDate: "2026-01-01"},
+	}
+	src := config.SourceConfig{Type: SourceIMAP, Address: "127.0.0.1:1143", PasswordEnv: "TEST_IMAP_PASSWORD"}
+
+	fetcher := NewIMAPFetcher(cfg, src)
+	if fetcher.imap.Mailbox != "INBOX" {
+		t.Errorf("expected default mailbox INBOX, got %q", fetcher.imap.Mailbox)
+	}
+	if !fetcher.imap.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected since to follow start_date, got %v", fetcher.imap.Since)
+	}
+
+	os.Unsetenv("TEST_IMAP_PASSWORD")
+	if err := fetcher.CheckDependencies(); err == nil {
+		t.Error("CheckDependencies() should fail without password")
+	}
+
+	os.Setenv("TEST_IMAP_PASSWORD", "secret")
+	if err := fetcher.CheckDependencies(); err != nil {
+		t.Errorf("CheckDependencies() error = %v", err)
+	}
+
+	src.Address = ""
+	if err := NewIMAPFetcher(cfg, src).CheckDependencies(); err == nil {
+		t.Error("CheckDependencies() should fail without address")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/pipeline"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+// Exchange rates are fetched over the network, so converting gains from
+// more workers than there are CPUs, but BNM should not get flooded.
+const convertWorkers = 4
+
+// syncPipeline turns messages into the transactions to sync: drop ignored
+// messages, parse, convert to the default currency, keep syncable ones and
+// drop messages read twice, e.g. from both the Messages database and the
+// webhook queue. Messages no template parses are dropped by parse already, so its
+// metrics count them.
+func (app *App) syncPipeline() *pipeline.Pipeline[*ParsedMessage] {
+	return newParsedPipeline(app.ignoreStage(), app.parseStage(), app.convertStage(), currencyStage("MDL"), dedupeStage())
+}
+
+func newParsedPipeline(stages ...pipeline.Stage[*ParsedMessage]) *pipeline.Pipeline[*ParsedMessage] {
+	p := pipeline.New(stages...)
+	p.OnError = func(stage string, pm *ParsedMessage, err error) {
+		warnStageError(stage, pm.Message, err)
+	}
+	return p
+}
+
+// runParsed passes items through p and returns the ones that pass every
+// stage, in order. Mapping and sinks take them as one batch afterwards.
+func (app *App) runParsed(p *pipeline.Pipeline[*ParsedMessage], items []*ParsedMessage) ([]*ParsedMessage, *pipeline.Metrics) {
+	var passed []*ParsedMessage
+	// The sink only collects, so the pipeline cannot fail
+	metrics, _ := p.Run(context.Background(), pipeline.FromSlice(context.Background(), items), func(pm *ParsedMessage) error {
+		passed = append(passed, pm)
+		return nil
+	})
+	app.writeMetrics(metrics)
+	return passed, metrics
+}
+
+func newParsedMessages(messages []*message.Message) []*ParsedMessage {
+	items := make([]*ParsedMessage, len(messages))
+	for i, msg := range messages {
+		items[i] = &ParsedMessage{Message: msg}
+	}
+	return items
+}
+
+// ignoreStage classifies messages and drops the ones an ignore rule wins.
+func (app *App) ignoreStage() pipeline.Stage[*ParsedMessage] {
+	return pipeline.Stage[*ParsedMessage]{Name: "ignore", Workers: runtime.NumCPU(), Process: func(_ context.Context, pm *ParsedMessage) (*ParsedMessage, bool, error) {
+		pm.match = app.matcher.Classify(pm.Message.Sender, pm.Message.Content)
+		return pm, !pm.match.Ignored(), nil
+	}}
+}
+
+func (app *App) parseStage() pipeline.Stage[*ParsedMessage] {
+	return pipeline.Stage[*ParsedMessage]{Name: "parse", Workers: runtime.NumCPU(), Process: func(_ context.Context, pm *ParsedMessage) (*ParsedMessage, bool, error) {
+		match := pm.match
+		if match == nil {
+			match = app.matcher.Classify(pm.Message.Sender, pm.Message.Content)
+		}
+		parsed := parseMatch(pm.Message, match)
+		return parsed, parsed.HasTemplate && parsed.Transaction != nil, nil
+	}}
+}
+
+func (app *App) convertStage() pipeline.Stage[*ParsedMessage] {
+	return pipeline.Stage[*ParsedMessage]{Name: "convert", Workers: convertWorkers, Process: func(_ context.Context, pm *ParsedMessage) (*ParsedMessage, bool, error) {
+		// Without a rate the original amount is kept and filtered next
+		if _, err := app.convertTransaction(pm.Message, pm.Transaction); err != nil {
+			slog.Warn("failed to convert transaction", "error", err)
+		}
+		return pm, true, nil
+	}}
+}
+
+func currencyStage(currency string) pipeline.Stage[*ParsedMessage] {
+	return pipeline.Stage[*ParsedMessage]{Name: "filter", Process: func(_ context.Context, pm *ParsedMessage) (*ParsedMessage, bool, error) {
+		return pm, pm.Transaction.Converted.Currency == currency, nil
+	}}
+}
+
+// duplicateWindow is how far apart the timestamps of one SMS read from two
+// sources can be, e.g. the time chat.db stored it and the time the webhook
+// received it.
+const duplicateWindow = 5 * time.Minute
+
+// dedupeStage drops a message whose sender and content were already seen
+// within duplicateWindow. Banks put the balance or a reference in their
+// messages, so two real transactions do not repeat one text that closely.
+func dedupeStage() pipeline.Stage[*ParsedMessage] {
+	seen := make(map[string][]time.Time)
+	return pipeline.Stage[*ParsedMessage]{Name: "dedupe", Process: func(_ context.Context, pm *ParsedMessage) (*ParsedMessage, bool, error) {
+		key := pm.Message.Sender + "\x00" + pm.Message.Content
+		for _, at := range seen[key] {
+			if d := pm.Message.Timestamp.Sub(at); d <= duplicateWindow && d >= -duplicateWindow {
+				return pm, false, nil
+			}
+		}
+		seen[key] = append(seen[key], pm.Message.Timestamp)
+		return pm, true, nil
+	}}
+}
+
+// dropAggregatorCopies drops MPay and Paynet confirmations of payments made
+// with a card whose issuer's messages are read too, since the issuer reports
+// the same payment with another time and merchant name.
+func (app *App) dropAggregatorCopies(passed []*ParsedMessage) []*ParsedMessage {
+	issued := make(map[string]bool)
+	for _, pm := range passed {
+		if !template.IsAggregator(pm.Message.Sender) {
+			if last4 := last4Regex.FindString(pm.Transaction.Card); last4 != "" {
+				issued[last4] = true
+			}
+		}
+	}
+
+	kept := passed[:0]
+	dropped := 0
+	for _, pm := range passed {
+		if template.IsAggregator(pm.Message.Sender) && issued[last4Regex.FindString(pm.Transaction.Card)] {
+			slog.Debug("skipping payment confirmed by the card's issuer too", "sender", pm.Message.Sender)
+			dropped++
+			continue
+		}
+		kept = append(kept, pm)
+	}
+	app.recordIgnored(dropped)
+	return kept
+}
+
+type classifiedMessage struct {
+	msg   *message.Message
+	match *template.Match
+}
+
+// classifyPipeline checks messages against every template and ignore rule.
+// Messages sent by the user get a nil match.
+func (app *App) classifyPipeline() *pipeline.Pipeline[*classifiedMessage] {
+	p := pipeline.New(
+		pipeline.Stage[*classifiedMessage]{Name: "classify", Workers: runtime.NumCPU(), Process: func(_ context.Context, c *classifiedMessage) (*classifiedMessage, bool, error) {
+			if c.msg.Sender != "Me" {
+				c.match = app.matcher.Classify(c.msg.Sender, c.msg.Content)
+			}
+			return c, true, nil
+		}},
+	)
+	p.OnError = func(stage string, c *classifiedMessage, err error) {
+		warnStageError(stage, c.msg, err)
+	}
+	return p
+}
+
+func warnStageError(stage string, msg *message.Message, err error) {
+	slog.Warn("pipeline stage failed", "stage", stage, "sender", msg.Sender, "error", err)
+}
+
+// writeMetrics logs the stage metrics, at info level for commands run by
+// hand and at debug level otherwise.
+func (app *App) writeMetrics(metrics *pipeline.Metrics) {
+	if metrics == nil {
+		return
+	}
+	level := slog.LevelDebug
+	if app.showMetrics {
+		level = slog.LevelInfo
+	}
+	metrics.Log(slog.Default(), level)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestApp_syncableTransactions_DropsDuplicates(t *testing.T) {
+	app := NewApp(&config.Config{Senders: []string{"102"}}, "")
+
+	sent := time.Date(2023, 5, 3, 16, 21, 0, 0, time.UTC)
+	purchase := func(amount string, at time.Time) *message.Message {
+		return &message.Message{
+			Timestamp: at,
+			Sender:    "102",
+			Content: `Op: Tovary i uslugi
+Karta: *1234
+Status: Odobrena
+Summa: ` + amount + ` MDL
+Dost: 12500,50
+Data/vremya: 03.05.23 16:21
+Adres: COFFEE SHOP ALPHA
+Podderzhka: +12025551234`,
+		}
+	}
+	// The copy received by the webhook has a later timestamp than chat.db's
+	messages := []*message.Message{
+		purchase("34", sent),
+		{Timestamp: time.Now(), Sender: "102", Content: "Unknown message"},
+		purchase("34", sent.Add(40*time.Second)),
+		purchase("50", sent),
+		purchase("34", sent.Add(24*time.Hour)),
+	}
+
+	filtered, transactions := app.syncableTransactions(messages)
+
+	if len(filtered) != 3 || len(transactions) != 3 {
+		t.Fatalf("syncableTransactions() returned %d messages and %d transactions, want 3", len(filtered), len(transactions))
+	}
+	if filtered[0] != messages[0] || filtered[1] != messages[3] || filtered[2] != messages[4] {
+		t.Error("syncableTransactions() should keep the first of duplicate messages, in order")
+	}
+	if transactions[1].Original.Value != 50 {
+		t.Errorf("second transaction amount = %v, want 50", transactions[1].Original.Value)
+	}
+}
+
+func TestApp_classifyMessages_KeepsOrder(t *testing.T) {
+	app := NewApp(&config.Config{Senders: []string{"102"}}, "")
+
+	messages := []*message.Message{
+		{Sender: "Me", Content: "Hello"},
+		{Sender: "102", Content: "Unknown message"},
+	}
+
+	matches := app.classifyMessages(messages)
+
+	if len(matches) != 2 {
+		t.Fatalf("classifyMessages() returned %d matches, want 2", len(matches))
+	}
+	if matches[0] != nil {
+		t.Error("messages sent by the user should get no match")
+	}
+	if matches[1] == nil {
+		t.Error("received messages should be classified")
+	}
+}
+
+func TestApp_syncableTransactions_DropsAggregatorCopies(t *testing.T) {
+	app := NewApp(&config.Config{Senders: []string{"MICB", "MPay", "Paynet"}}, "")
+
+	at := time.Date(2024, 5, 19, 20, 15, 0, 0, time.UTC)
+	messages := []*message.Message{
+		{Timestamp: at, Sender: "MICB", Content: "MICB: Plata card *1234, 85.00 MDL, MPAY*APA-CANAL, 19.05.2024 20:15. Disponibil: 4520.10 MDL"},
+		{Timestamp: at, Sender: "MPay", Content: "MPay: Plata in suma de 85.00 MDL catre Apa-Canal Chisinau a fost efectuata cu succes cu cardul *1234. 19.05.2024 20:15"},
+		// No message from the issuer of this card is read
+		{Timestamp: at, Sender: "Paynet", Content: "Paynet: Plata 150.00 MDL pentru Orange Moldova efectuata cu succes. Card *5678. 19.05.2024 20:15"},
+	}
+
+	filtered, _ := app.syncableTransactions(messages)
+
+	if len(filtered) != 2 || filtered[0] != messages[0] || filtered[1] != messages[2] {
+		t.Errorf("syncableTransactions() = %v, want the MICB and Paynet messages", filtered)
+	}
+}
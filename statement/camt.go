@@ -0,0 +1,114 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	IBAN    string      `xml:"Acct>Id>IBAN"`
+	Other   string      `xml:"Acct>Id>Othr>Id"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+	Status      struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate string `xml:"BookgDt>Dt"`
+	BookingTime string `xml:"BookgDt>DtTm"`
+	ValueDate   string `xml:"ValDt>Dt"`
+	Info        string `xml:"AddtlNtryInf"`
+	Details     []struct {
+		Creditor   string `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPt string `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Debtor     string `xml:"RltdPties>Dbtr>Nm"`
+		DebtorPt   string `xml:"RltdPties>Dbtr>Pty>Nm"`
+		Remittance string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+func ParseCAMT053(data []byte) ([]Entry, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CAMT.053 statement: %w", err)
+	}
+
+	var entries []Entry
+	for _, stmt := range doc.Statements {
+		account := stmt.IBAN
+		if account == "" {
+			account = stmt.Other
+		}
+
+		for _, ntry := range stmt.Entries {
+			// Pending entries are not yet booked and may still change
+			if strings.TrimSpace(ntry.Status.Value)+ntry.Status.Code == "PDNG" {
+				continue
+			}
+
+			date, err := camtDate(ntry)
+			if err != nil {
+				return nil, err
+			}
+
+			amount, err := parseAmount(ntry.Amount.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CAMT.053 amount %q: %w", ntry.Amount.Value, err)
+			}
+			if ntry.CreditDebit == "DBIT" {
+				amount = -amount
+			}
+
+			payee := ntry.Info
+			raw := ntry.Info
+			if len(ntry.Details) > 0 {
+				d := ntry.Details[0]
+				counterparty := d.Creditor + d.CreditorPt
+				if ntry.CreditDebit == "CRDT" {
+					counterparty = d.Debtor + d.DebtorPt
+				}
+				if counterparty != "" {
+					payee = counterparty
+				} else if d.Remittance != "" {
+					payee = d.Remittance
+				}
+				if d.Remittance != "" {
+					raw = strings.TrimSpace(raw + "\n" + d.Remittance)
+				}
+			}
+
+			entries = append(entries, newEntry(date, amount, ntry.Amount.Currency, account, payee, raw))
+		}
+	}
+
+	return entries, nil
+}
+
+func camtDate(ntry camtEntry) (time.Time, error) {
+	switch {
+	case ntry.BookingDate != "":
+		return time.Parse("2006-01-02", ntry.BookingDate)
+	case ntry.BookingTime != "":
+		t, err := time.Parse(time.RFC3339, ntry.BookingTime)
+		if err != nil {
+			t, err = time.Parse("2006-01-02T15:04:05", ntry.BookingTime)
+		}
+		return t.UTC().Truncate(24 * time.Hour), err
+	case ntry.ValueDate != "":
+		return time.Parse("2006-01-02", ntry.ValueDate)
+	}
+	return time.Time{}, fmt.Errorf("CAMT.053 entry without booking or value date")
+}
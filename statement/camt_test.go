@@ -0,0 +1,94 @@
+package statement
+
+import (
+	"testing"
+)
+
+const testCAMT053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>MD24AG000225100013107890</IBAN></Id><Ccy>MDL</Ccy></Acct>
+      <Ntry>
+        <Amt Ccy="MDL">9.65</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-01-10</Dt></BookgDt>
+        <NtryDtls><TxDtls>
+          <RltdPties><Cdtr><Nm>MAIB GROCERY STORE</Nm></Cdtr></RltdPties>
+          <RmtInf><Ustrd>Card payment 9..7890</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="MDL">1500.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2026-01-11T09:00:00+02:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <RltdPties><Dbtr><Pty><Nm>ACME SRL</Nm></Pty></Dbtr></RltdPties>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">5.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <ValDt><Dt>2026-01-12</Dt></ValDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="MDL">3.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <ValDt><Dt>2026-01-12</Dt></ValDt>
+        <AddtlNtryInf>Comision</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	entries, err := ParseCAMT053([]byte(testCAMT053))
+	if err != nil {
+		t.Fatalf("ParseCAMT053() error = %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 booked entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if !first.Date.Equal(testDate) {
+		t.Errorf("expected date %v, got %v", testDate, first.Date)
+	}
+	tx := first.Transaction
+	if tx.Operation != OperationDebit || tx.Original.Value != 9.65 || tx.Original.Currency != "MDL" {
+		t.Errorf("unexpected first transaction %+v", tx)
+	}
+	if tx.Card != "MD24AG000225100013107890" || tx.Address != "MAIB GROCERY STORE" {
+		t.Errorf("unexpected account/payee %q/%q", tx.Card, tx.Address)
+	}
+
+	second := entries[1].Transaction
+	if second.Operation != OperationCredit || second.Address != "ACME SRL" || second.DateTime != "2026-01-11" {
+		t.Errorf("unexpected second transaction %+v", second)
+	}
+
+	if entries[2].Transaction.Address != "Comision" {
+		t.Errorf("expected additional info as payee, got %q", entries[2].Transaction.Address)
+	}
+}
+
+func TestParseCAMT053_Errors(t *testing.T) {
+	if _, err := ParseCAMT053([]byte("<Document")); err == nil {
+		t.Error("ParseCAMT053() should return error for malformed XML")
+	}
+
+	noDate := `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="MDL">1</Amt></Ntry></Stmt></BkToCstmrStmt></Document>`
+	if _, err := ParseCAMT053([]byte(noDate)); err == nil {
+		t.Error("ParseCAMT053() should return error for entry without date")
+	}
+
+	badAmount := `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="MDL">x</Amt><BookgDt><Dt>2026-01-10</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>`
+	if _, err := ParseCAMT053([]byte(badAmount)); err == nil {
+		t.Error("ParseCAMT053() should return error for invalid amount")
+	}
+}
@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CSVConfig maps statement columns, by header name, onto transaction fields.
+// Either Amount (signed) or Debit/Credit (unsigned) must be set.
+type CSVConfig struct {
+	Delimiter  string
+	Date       string
+	DateFormat string
+	Amount     string
+	Debit      string
+	Credit     string
+	Currency   string
+	Payee      string
+	Account    string
+
+	DefaultCurrency string
+}
+
+func ParseCSV(r io.Reader, cfg CSVConfig) ([]Entry, error) {
+	if cfg.Date == "" || (cfg.Amount == "" && cfg.Debit == "" && cfg.Credit == "") {
+		return nil, errors.New("CSV statement mapping needs a date and an amount or debit/credit column")
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if cfg.Delimiter != "" {
+		reader.Comma = []rune(cfg.Delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	for _, name := range []string{cfg.Date, cfg.Amount, cfg.Debit, cfg.Credit, cfg.Currency, cfg.Payee, cfg.Account} {
+		if _, ok := columns[name]; name != "" && !ok {
+			return nil, fmt.Errorf("CSV column %q not found in header", name)
+		}
+	}
+
+	dateFormat := cfg.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+
+	var entries []Entry
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		get := func(name string) string {
+			if name == "" {
+				return ""
+			}
+			idx := columns[name]
+			if idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		if get(cfg.Date) == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateFormat, get(cfg.Date))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date: %w", line, err)
+		}
+
+		amount, err := csvAmount(get, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		currency := get(cfg.Currency)
+		if currency == "" {
+			currency = cfg.DefaultCurrency
+		}
+
+		entries = append(entries, newEntry(date, amount, currency, get(cfg.Account), get(cfg.Payee), strings.Join(record, ",")))
+	}
+
+	return entries, nil
+}
+
+func csvAmount(get func(string) string, cfg CSVConfig) (float64, error) {
+	if cfg.Amount != "" {
+		amount, err := parseAmount(get(cfg.Amount))
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %w", err)
+		}
+		return amount, nil
+	}
+
+	if debit := get(cfg.Debit); debit != "" {
+		amount, err := parseAmount(debit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid debit: %w", err)
+		}
+		if amount > 0 {
+			amount = -amount
+		}
+		return amount, nil
+	}
+
+	amount, err := parseAmount(get(cfg.Credit))
+	if err != nil {
+		return 0, fmt.Errorf("invalid credit: %w", err)
+	}
+	return amount, nil
+}
@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testDate = time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+func TestParseCSV_SignedAmount(t *testing.T) {
+	content := "\ufeffData;Suma;Valuta;Beneficiar;Cont\n" +
+		"10.01.2026;-9,65;MDL;MAIB GROCERY STORE;MD24AG000225100013107890\n" +
+		"11.01.2026;1 500,00;MDL;Plata salariala;MD24AG000225100013107890\n" +
+		";;;;\n"
+
+	entries, err := ParseCSV(strings.NewReader(content), CSVConfig{
+		Delimiter:  ";",
+		Date:       "Data",
+		DateFormat: "02.01.2006",
+		Amount:     "Suma",
+		Currency:   "Valuta",
+		Payee:      "Beneficiar",
+		Account:    "Cont",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if !first.Date.Equal(testDate) {
+		t.Errorf("expected date %v, got %v", testDate, first.Date)
+	}
+	tx := first.Transaction
+	if tx.Operation != OperationDebit || tx.Original.Value != 9.65 || tx.Original.Currency != "MDL" {
+		t.Errorf("unexpected first transaction %+v", tx)
+	}
+	if tx.Address != "MAIB GROCERY STORE" || tx.Card != "MD24AG000225100013107890" {
+		t.Errorf("unexpected payee/account %q/%q", tx.Address, tx.Card)
+	}
+
+	if entries[1].Transaction.Operation != OperationCredit || entries[1].Transaction.Original.Value != 1500 {
+		t.Errorf("unexpected second transaction %+v", entries[1].Transaction)
+	}
+}
+
+func TestParseCSV_DebitCreditColumns(t *testing.T) {
+	content := "date,debit,credit,description\n" +
+		"2026-01-10,12.50,,Coffee\n" +
+		"2026-01-11,,100.00,Refund\n"
+
+	entries, err := ParseCSV(strings.NewReader(content), CSVConfig{
+		Date:            "date",
+		Debit:           "debit",
+		Credit:          "credit",
+		Payee:           "description",
+		DefaultCurrency: "EUR",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Transaction.Operation != OperationDebit || entries[0].Transaction.Original.Currency != "EUR" {
+		t.Errorf("unexpected debit entry %+v", entries[0].Transaction)
+	}
+	if entries[1].Transaction.Operation != OperationCredit || entries[1].Transaction.Original.Value != 100 {
+		t.Errorf("unexpected credit entry %+v", entries[1].Transaction)
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		cfg     CSVConfig
+	}{
+		{"missing mapping", "date,amount\n", CSVConfig{Date: "date"}},
+		{"unknown column", "date,amount\n", CSVConfig{Date: "date", Amount: "sum"}},
+		{"empty file", "", CSVConfig{Date: "date", Amount: "amount"}},
+		{"invalid date", "date,amount\nyesterday,1\n", CSVConfig{Date: "date", Amount: "amount"}},
+		{"invalid amount", "date,amount\n2026-01-10,abc\n", CSVConfig{Date: "date", Amount: "amount"}},
+		{"invalid debit", "date,debit,credit\n2026-01-10,abc,\n", CSVConfig{Date: "date", Debit: "debit", Credit: "credit"}},
+		{"invalid credit", "date,debit,credit\n2026-01-10,,abc\n", CSVConfig{Date: "date", Debit: "debit", Credit: "credit"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseCSV(strings.NewReader(tc.content), tc.cfg); err == nil {
+				t.Error("ParseCSV() should return error")
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	mt940TagRegex     = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	mt940BalanceRegex = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+	// YYMMDD, optional MMDD entry date, mark (C, D, RC, RD), optional funds code, amount
+	mt940LineRegex = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])[A-Z]?([\d,]+)`)
+)
+
+type mt940Line struct {
+	tag   string
+	value string
+}
+
+func ParseMT940(data []byte) ([]Entry, error) {
+	lines, err := mt940Fields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	var account, currency string
+	var pending *Entry
+
+	flush := func() {
+		if pending != nil {
+			entries = append(entries, *pending)
+			pending = nil
+		}
+	}
+
+	for _, field := range lines {
+		switch field.tag {
+		case "25":
+			account = strings.TrimSpace(field.value)
+		case "60F", "60M":
+			if m := mt940BalanceRegex.FindStringSubmatch(field.value); m != nil {
+				currency = m[1]
+			}
+		case "61":
+			flush()
+			m := mt940LineRegex.FindStringSubmatch(field.value)
+			if m == nil {
+				return nil, fmt.Errorf("invalid :61: statement line %q", field.value)
+			}
+			date, err := time.Parse("060102", m[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid :61: value date: %w", err)
+			}
+			amount, err := parseAmount(m[4])
+			if err != nil {
+				return nil, fmt.Errorf("invalid :61: amount: %w", err)
+			}
+			// D and RC (reversal of credit) take money out of the account
+			if m[3] == "D" || m[3] == "RC" {
+				amount = -amount
+			}
+			entry := newEntry(date, amount, currency, account, "", field.value)
+			pending = &entry
+		case "86":
+			if pending != nil {
+				pending.Transaction.Address = mt940Payee(field.value)
+				pending.Transaction.RawMessage += "\n" + field.value
+			}
+		case "62F", "62M":
+			flush()
+		}
+	}
+	flush()
+
+	if account == "" {
+		return nil, fmt.Errorf("not an MT940 statement: missing :25: account")
+	}
+	return entries, nil
+}
+
+func mt940Fields(data []byte) ([]mt940Line, error) {
+	var fields []mt940Line
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := mt940TagRegex.FindStringSubmatch(line); m != nil {
+			fields = append(fields, mt940Line{tag: m[1], value: m[2]})
+			continue
+		}
+		if line == "-" || line == "" || strings.HasPrefix(line, "{") {
+			continue
+		}
+		if len(fields) > 0 {
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MT940 statement: %w", err)
+	}
+	return fields, nil
+}
+
+// mt940Payee extracts the counterparty from structured ?32/?33 subfields
+// when present, otherwise uses the free text.
+func mt940Payee(info string) string {
+	info = strings.ReplaceAll(info, "\n", "")
+	if !strings.Contains(info, "?") {
+		return info
+	}
+
+	var name, text []string
+	for _, sub := range strings.Split(info, "?")[1:] {
+		if len(sub) < 2 {
+			continue
+		}
+		code, value := sub[:2], sub[2:]
+		switch {
+		case code == "32" || code == "33":
+			name = append(name, value)
+		case code >= "20" && code <= "29":
+			text = append(text, value)
+		}
+	}
+	if len(name) > 0 {
+		return strings.Join(name, "")
+	}
+	return strings.Join(text, " ")
+}
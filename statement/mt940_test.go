@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"testing"
+)
+
+const testMT940 = `{1:F01AGRNMD2XAXXX0000000000}{4:
+:20:STMT260110
+:25:MD24AG000225100013107890
+:28C:1/1
+:60F:C260109MDL38410,25
+:61:2601100110D9,65NMSCNONREF
+:86:MAIB GROCERY STORE
+CHISINAU
+:61:2601110111C1500,00NTRFNONREF
+:86:?20Plata salariala?21ianuarie?32ACME?33 SRL
+:61:2601120112RC20,00NMSCNONREF
+:62F:C260112MDL39880,60
+-}`
+
+func TestParseMT940(t *testing.T) {
+	entries, err := ParseMT940([]byte(testMT940))
+	if err != nil {
+		t.Fatalf("ParseMT940() error = %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if !first.Date.Equal(testDate) {
+		t.Errorf("expected date %v, got %v", testDate, first.Date)
+	}
+	tx := first.Transaction
+	if tx.Operation != OperationDebit || tx.Original.Value != 9.65 || tx.Original.Currency != "MDL" {
+		t.Errorf("unexpected first transaction %+v", tx)
+	}
+	if tx.Card != "MD24AG000225100013107890" {
+		t.Errorf("unexpected account %q", tx.Card)
+	}
+	if tx.Address != "MAIB GROCERY STORECHISINAU" {
+		t.Errorf("unexpected payee %q", tx.Address)
+	}
+
+	if entries[1].Transaction.Operation != OperationCredit || entries[1].Transaction.Address != "ACME SRL" {
+		t.Errorf("unexpected second transaction %+v", entries[1].Transaction)
+	}
+
+	// RC is a reversal of a credit, so money leaves the account
+	if entries[2].Transaction.Operation != OperationDebit || entries[2].Transaction.Original.Value != 20 {
+		t.Errorf("unexpected reversal transaction %+v", entries[2].Transaction)
+	}
+}
+
+func TestMT940Payee_UnstructuredText(t *testing.T) {
+	if got := mt940Payee("?20Card payment?21SHOP"); got != "Card payment SHOP" {
+		t.Errorf("expected remittance text, got %q", got)
+	}
+}
+
+func TestParseMT940_Errors(t *testing.T) {
+	if _, err := ParseMT940([]byte(":20:STMT\n:61:2601100110D9,65\n")); err == nil {
+		t.Error("ParseMT940() should return error without :25: account")
+	}
+
+	if _, err := ParseMT940([]byte(":25:ACC\n:61:garbage\n")); err == nil {
+		t.Error("ParseMT940() should return error for invalid :61: line")
+	}
+
+	if _, err := ParseMT940([]byte(":25:ACC\n:61:261340D9,65\n")); err == nil {
+		t.Error("ParseMT940() should return error for invalid date")
+	}
+}
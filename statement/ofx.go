@@ -0,0 +1,67 @@
+package statement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ofxTransactionRegex = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxDateRegex        = regexp.MustCompile(`^(\d{8})`)
+)
+
+// ParseOFX handles both SGML (OFX 1.x, QFX) and XML (OFX 2.x) statements.
+// SGML leaf elements have no closing tags, so values are read up to the next
+// tag or line break.
+func ParseOFX(data []byte) ([]Entry, error) {
+	content := string(data)
+
+	account := ofxValue(content, "ACCTID")
+	currency := ofxValue(content, "CURDEF")
+
+	blocks := ofxTransactionRegex.FindAllStringSubmatch(content, -1)
+	if blocks == nil && !strings.Contains(strings.ToUpper(content), "<OFX>") {
+		return nil, fmt.Errorf("not an OFX statement")
+	}
+
+	var entries []Entry
+	for _, block := range blocks {
+		body := block[1]
+
+		posted := ofxDateRegex.FindString(ofxValue(body, "DTPOSTED"))
+		date, err := time.Parse("20060102", posted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DTPOSTED in transaction %s: %w", ofxValue(body, "FITID"), err)
+		}
+
+		amount, err := parseAmount(ofxValue(body, "TRNAMT"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRNAMT in transaction %s: %w", ofxValue(body, "FITID"), err)
+		}
+
+		payee := ofxValue(body, "NAME")
+		if payee == "" {
+			payee = ofxValue(body, "MEMO")
+		}
+
+		txCurrency := currency
+		if c := ofxValue(body, "CURSYM"); c != "" {
+			txCurrency = c
+		}
+
+		entries = append(entries, newEntry(date, amount, txCurrency, account, payee, strings.TrimSpace(body)))
+	}
+
+	return entries, nil
+}
+
+func ofxValue(content, tag string) string {
+	re := regexp.MustCompile(`(?i)<` + tag + `>([^<\r\n]*)`)
+	m := re.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"testing"
+)
+
+const testOFXSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<CURDEF>MDL
+<BANKACCTFROM>
+<BANKID>AGRNMD2X
+<ACCTID>MD24AG000225100013107890
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260110093000[+2:EET]
+<TRNAMT>-9.65
+<FITID>0001
+<NAME>MAIB GROCERY STORE
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20260111
+<TRNAMT>1500.00
+<FITID>0002
+<MEMO>Plata salariala
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>`
+
+const testOFXXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR</CURDEF>
+<BANKACCTFROM><ACCTID>1234</ACCTID></BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20260112</DTPOSTED><TRNAMT>-12.50</TRNAMT><FITID>A1</FITID><NAME>Cafe</NAME><CURRENCY><CURSYM>USD</CURSYM></CURRENCY></STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+func TestParseOFX_SGML(t *testing.T) {
+	entries, err := ParseOFX([]byte(testOFXSGML))
+	if err != nil {
+		t.Fatalf("ParseOFX() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if !first.Date.Equal(testDate) {
+		t.Errorf("expected date %v, got %v", testDate, first.Date)
+	}
+	tx := first.Transaction
+	if tx.Operation != OperationDebit || tx.Original.Value != 9.65 || tx.Original.Currency != "MDL" {
+		t.Errorf("unexpected first transaction %+v", tx)
+	}
+	if tx.Card != "MD24AG000225100013107890" || tx.Address != "MAIB GROCERY STORE" {
+		t.Errorf("unexpected account/payee %q/%q", tx.Card, tx.Address)
+	}
+
+	if entries[1].Transaction.Address != "Plata salariala" {
+		t.Errorf("expected MEMO as payee fallback, got %q", entries[1].Transaction.Address)
+	}
+}
+
+func TestParseOFX_XML(t *testing.T) {
+	entries, err := ParseOFX([]byte(testOFXXML))
+	if err != nil {
+		t.Fatalf("ParseOFX() error = %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	tx := entries[0].Transaction
+	if tx.Original.Currency != "USD" || tx.Card != "1234" || tx.Address != "Cafe" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+}
+
+func TestParseOFX_Errors(t *testing.T) {
+	if _, err := ParseOFX([]byte("hello")); err == nil {
+		t.Error("ParseOFX() should return error for non-OFX input")
+	}
+
+	badDate := `<OFX><STMTTRN><DTPOSTED>soon<TRNAMT>1</STMTTRN></OFX>`
+	if _, err := ParseOFX([]byte(badDate)); err == nil {
+		t.Error("ParseOFX() should return error for invalid date")
+	}
+
+	badAmount := `<OFX><STMTTRN><DTPOSTED>20260110<TRNAMT>lots</STMTTRN></OFX>`
+	if _, err := ParseOFX([]byte(badAmount)); err == nil {
+		t.Error("ParseOFX() should return error for invalid amount")
+	}
+}
@@ -0,0 +1,122 @@
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatMT940   = "mt940"
+	FormatCAMT053 = "camt053"
+)
+
+// Statements carry signed amounts rather than operation names, so entries
+// use the same operation names as the SMS templates to keep the mapper's
+// debit/credit handling unchanged.
+const (
+	OperationDebit  = "Debitare"
+	OperationCredit = "Suplinire"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+type Entry struct {
+	Date        time.Time
+	Transaction *template.Transaction
+}
+
+func ParseFile(path, format string, csvConfig CSVConfig) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	if format == "" {
+		format = DetectFormat(path, data)
+	}
+
+	switch format {
+	case FormatCSV:
+		return ParseCSV(bytes.NewReader(data), csvConfig)
+	case FormatOFX:
+		return ParseOFX(data)
+	case FormatMT940:
+		return ParseMT940(data)
+	case FormatCAMT053:
+		return ParseCAMT053(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+func DetectFormat(path string, data []byte) string {
+	head := string(data[:min(len(data), 4096)])
+
+	switch {
+	case strings.Contains(head, "camt.053"):
+		return FormatCAMT053
+	case strings.Contains(head, "OFXHEADER") || strings.Contains(strings.ToUpper(head), "<OFX>"):
+		return FormatOFX
+	case strings.Contains(head, ":20:") && strings.Contains(head, ":25:"):
+		return FormatMT940
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".sta", ".mt940":
+		return FormatMT940
+	}
+	return ""
+}
+
+func newEntry(date time.Time, amount float64, currency, account, payee, raw string) Entry {
+	operation, direction := OperationCredit, template.DirectionCredit
+	if amount < 0 {
+		operation, direction = OperationDebit, template.DirectionDebit
+	}
+
+	return Entry{
+		Date: date,
+		Transaction: &template.Transaction{
+			Operation:  operation,
+			Direction:  direction,
+			Card:       account,
+			Original:   template.Amount{Value: math.Abs(amount), Currency: currency},
+			DateTime:   date.Format("2006-01-02"),
+			Address:    strings.TrimSpace(payee),
+			RawMessage: raw,
+		},
+	}
+}
+
+// parseAmount accepts both "1 234,56" and "1,234.56" styles: whichever of
+// '.' or ',' comes last is the decimal separator.
+func parseAmount(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	value = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(value)
+
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+	if lastComma > lastDot {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
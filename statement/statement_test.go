@@ -0,0 +1,107 @@
+package statement
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"9.65", 9.65},
+		{"-9,65", -9.65},
+		{"1 234,56", 1234.56},
+		{"1,234.56", 1234.56},
+		{"1.234,56", 1234.56},
+		{"1'234.50", 1234.50},
+		{"100", 100},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseAmount(tc.input)
+		if err != nil {
+			t.Errorf("parseAmount(%q) error = %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseAmount(%q) = %f, want %f", tc.input, got, tc.want)
+		}
+	}
+
+	if _, err := parseAmount("abc"); err == nil {
+		t.Error("parseAmount() should return error for non-numeric input")
+	}
+}
+
+func TestNewEntry_Direction(t *testing.T) {
+	debit := newEntry(testDate, -9.65, "MDL", "MD24AG000225100013104168", "SHOP", "raw")
+	if debit.Transaction.Operation != OperationDebit {
+		t.Errorf("expected debit operation, got %q", debit.Transaction.Operation)
+	}
+	if debit.Transaction.Original.Value != 9.65 {
+		t.Errorf("expected unsigned amount 9.65, got %f", debit.Transaction.Original.Value)
+	}
+
+	credit := newEntry(testDate, 100, "MDL", "acc", "Salary", "raw")
+	if credit.Transaction.Operation != OperationCredit {
+		t.Errorf("expected credit operation, got %q", credit.Transaction.Operation)
+	}
+	if credit.Transaction.DateTime != "2026-01-10" {
+		t.Errorf("expected DateTime 2026-01-10, got %q", credit.Transaction.DateTime)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []struct {
+		path string
+		data string
+		want string
+	}{
+		{"s.xml", `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">`, FormatCAMT053},
+		{"s.txt", "OFXHEADER:100\nDATA:OFXSGML", FormatOFX},
+		{"s.xml", "<?xml version=\"1.0\"?><OFX>", FormatOFX},
+		{"s.txt", ":20:STMT\n:25:MD24AG000225100013104168\n", FormatMT940},
+		{"s.CSV", "date,amount", FormatCSV},
+		{"s.qfx", "", FormatOFX},
+		{"s.sta", "", FormatMT940},
+		{"s.pdf", "%PDF", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := DetectFormat(tc.path, []byte(tc.data)); got != tc.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "statement.sta")
+	if err := os.WriteFile(path, []byte(testMT940), 0644); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	entries, err := ParseFile(path, "", CSVConfig{})
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(entries))
+	}
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	if _, err := ParseFile("/nonexistent/statement.csv", "", CSVConfig{}); err == nil {
+		t.Error("ParseFile() should return error for missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "statement.pdf")
+	os.WriteFile(path, []byte("%PDF"), 0644)
+	if _, err := ParseFile(path, "", CSVConfig{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
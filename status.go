@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/history"
+	"github.com/apmyp/ynab_importer_go/pipeline"
+	"github.com/apmyp/ynab_importer_go/system"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+const defaultStatusRuns = 10
+
+// recordedCommands are the commands whose runs go into the run history.
+var recordedCommands = map[string]bool{
+	"ynab_sync":        true,
+	"sink_sync":        true,
+	"import_statement": true,
+}
+
+// recordRun adds a finished run, with what it read, parsed and synced, to
+// the run history in dataPath and returns the run's error. A nil run is not
+// recorded.
+func recordRun(dataPath string, run *history.Run, err error) error {
+	if run == nil {
+		return err
+	}
+	run.Finish(time.Now(), err)
+	if saveErr := history.NewStore(dataPath).Append(run); saveErr != nil {
+		slog.Warn("failed to record sync run", "error", saveErr)
+	}
+	return err
+}
+
+func (app *App) recordMetrics(metrics *pipeline.Metrics) {
+	if app.run == nil || metrics == nil {
+		return
+	}
+	if ignore := metrics.Stage("ignore"); ignore != nil {
+		app.run.Ignored += int(ignore.Dropped)
+	}
+	if parse := metrics.Stage("parse"); parse != nil {
+		app.run.Parsed += int(parse.Out)
+		app.run.Unmatched += int(parse.Dropped)
+	}
+	// Transactions left in another currency have no rate to convert with
+	if filter := metrics.Stage("filter"); filter != nil {
+		app.run.Converted += int(filter.Out)
+	}
+}
+
+// recordStatement counts the entries of an imported statement as parsed.
+// The messages read alongside are only used to skip entries already
+// imported from SMS, so their metrics are not recorded.
+func (app *App) recordStatement(entries int, metrics *pipeline.Metrics) {
+	if app.run == nil {
+		return
+	}
+	app.run.Parsed += entries
+	app.recordMetrics(metrics)
+}
+
+func (app *App) recordSyncResult(result *ynab.SyncResult) {
+	if app.run == nil {
+		return
+	}
+	app.run.Synced += result.Synced
+	app.run.Skipped += result.Skipped
+	app.run.Failed += len(result.Failed)
+	app.run.Errors = append(app.run.Errors, result.Failed...)
+}
+
+func (app *App) recordIgnored(count int) {
+	if app.run == nil {
+		return
+	}
+	app.run.Ignored += count
+}
+
+func (app *App) recordSinkError(err error) {
+	if app.run == nil {
+		return
+	}
+	app.run.Errors = append(app.run.Errors, err.Error())
+}
+
+func (app *App) runStatus(args []string) error {
+	limit := defaultStatusRuns
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--runs" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --runs value: %s", args[i+1])
+			}
+			limit = n
+			i++
+		default:
+			return fmt.Errorf("unknown status argument: %s", args[i])
+		}
+	}
+
+	runs, err := history.NewStore(app.config.DataFilePath).Runs()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	writeStatus(os.Stdout, runs, limit, now)
+
+	status, err := app.serviceStatus()
+	fmt.Println()
+	fmt.Println(formatServiceStatus(status, err, now))
+	return nil
+}
+
+func (app *App) serviceStatus() (*system.ServiceStatus, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	installer, err := system.NewInstaller(execPath, workingDir, "")
+	if err != nil {
+		return nil, err
+	}
+	return installer.Status()
+}
+
+func writeStatus(w io.Writer, runs []*history.Run, limit int, now time.Time) {
+	if last := history.LastSuccess(runs); last != nil {
+		fmt.Fprintf(w, "Last successful sync: %s\n", formatTime(last.FinishedAt, now))
+	} else {
+		fmt.Fprintln(w, "Last successful sync: never")
+	}
+
+	fmt.Fprintln(w, "\nRecent runs:")
+	if len(runs) == 0 {
+		fmt.Fprintln(w, "  none recorded yet")
+	}
+	for i := len(runs) - 1; i >= 0 && i >= len(runs)-limit; i-- {
+		fmt.Fprintln(w, "  "+formatRun(runs[i], now))
+		if runs[i].Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", runs[i].Error)
+		}
+		for _, failure := range runs[i].Errors {
+			fmt.Fprintf(w, "      - %s\n", failure)
+		}
+	}
+
+	fmt.Fprintln(w, "\nNewest message per sender:")
+	activity := history.NewestMessages(runs)
+	if len(activity) == 0 {
+		fmt.Fprintln(w, "  none seen yet")
+	}
+	for _, a := range activity {
+		fmt.Fprintf(w, "  %-12s %s\n", a.Sender, formatTime(a.Newest, now))
+	}
+}
+
+func formatRun(run *history.Run, now time.Time) string {
+	result := "ok"
+	if !run.Succeeded() {
+		result = "FAILED"
+	}
+
+	sources := make([]string, 0, len(run.Sources))
+	for source, count := range run.Sources {
+		sources = append(sources, fmt.Sprintf("%s %d", source, count))
+	}
+	sort.Strings(sources)
+
+	return fmt.Sprintf("%s  %-9s %-6s %6s  messages %d (%s), parsed %d, ignored %d, unmatched %d, converted %d, synced %d, skipped %d, failed %d",
+		formatTime(run.StartedAt, now), run.Command, result,
+		run.FinishedAt.Sub(run.StartedAt).Round(100*time.Millisecond),
+		run.Messages, strings.Join(sources, ", "),
+		run.Parsed, run.Ignored, run.Unmatched, run.Converted, run.Synced, run.Skipped, run.Failed)
+}
+
+func formatServiceStatus(status *system.ServiceStatus, err error, now time.Time) string {
+	if err != nil {
+		return fmt.Sprintf("Service: unavailable (%v)", err)
+	}
+	if !status.Installed {
+		return "Service: not installed (run system_install)"
+	}
+
+	var parts []string
+	if status.Loaded {
+		parts = append(parts, "loaded")
+	} else {
+		parts = append(parts, "NOT LOADED")
+	}
+	if status.Running {
+		parts = append(parts, "running")
+	}
+	if status.HasLastExitStatus {
+		if status.LastExitStatus == 0 {
+			parts = append(parts, "last run exited cleanly")
+		} else {
+			parts = append(parts, fmt.Sprintf("LAST RUN FAILED (exit status %d)", status.LastExitStatus))
+		}
+	}
+
+	line := "Service: installed, " + strings.Join(parts, ", ")
+	if !status.LogModified.IsZero() {
+		line += fmt.Sprintf("\n  Log %s last written %s", status.LogPath, formatTime(status.LogModified, now))
+	}
+	return line
+}
+
+func formatTime(t, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), ago(now.Sub(t)))
+}
+
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/history"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/ynab"
+)
+
+func TestRecordRun(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data.json")
+	cfg := &config.Config{Senders: []string{"102"}, DataFilePath: dataPath}
+	fetcher := &MockFetcher{messages: []*message.Message{
+		{Timestamp: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), Sender: "102", Content: "Unknown message"},
+		{Timestamp: time.Date(2024, 3, 1, 9, 5, 0, 0, time.UTC), Sender: "OTPbank", Content: "Codul de confirmare OTP Bank Online: 123456"},
+	}}
+	app := NewAppWithFetcher(cfg, fetcher)
+	app.run = history.NewRun("abc123", "sink_sync", time.Now())
+
+	err := recordRun(dataPath, app.run, func() error {
+		messages, cleanup, err := app.fetchMessages()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		app.syncableTransactions(messages)
+		app.recordSyncResult(&ynab.SyncResult{Skipped: 1, Failed: []string{"Failed to map: no account"}})
+		return errors.New("sync to journal failed")
+	}())
+	if err == nil {
+		t.Fatal("recordRun() should return the command's error")
+	}
+
+	runs, err := history.NewStore(dataPath).Runs()
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(runs))
+	}
+	run := runs[0]
+	if run.ID != "abc123" || run.Command != "sink_sync" || run.Error != "sync to journal failed" {
+		t.Errorf("run = %+v", run)
+	}
+	if run.Messages != 2 || run.Sources["default"] != 2 || run.Ignored != 1 || run.Unmatched != 1 || run.Parsed != 0 {
+		t.Errorf("run counts = %+v, want 1 ignored and 1 unmatched message", run)
+	}
+	if run.Skipped != 1 || run.Failed != 1 || len(run.Errors) != 1 {
+		t.Errorf("run results = %+v", run)
+	}
+}
+
+func TestRun_RecordsConfigErrors(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data.json")
+
+	err := Run([]string{"--config", filepath.Join(t.TempDir(), "missing.json"), "--data-file", dataPath, "sink_sync"})
+	if err == nil {
+		t.Fatal("Run() should fail without a config")
+	}
+
+	runs, err := history.NewStore(dataPath).Runs()
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	if len(runs) != 1 || runs[0].Command != "sink_sync" || !strings.HasPrefix(runs[0].Error, "loading config") {
+		t.Errorf("runs = %+v, want the failed sink_sync recorded", runs)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ok := history.NewRun("a1", "ynab_sync", now.Add(-3*time.Hour))
+	ok.AddMessages("chatdb", []*message.Message{{Sender: "102", Timestamp: now.Add(-4 * time.Hour)}})
+	ok.Synced = 1
+	ok.Finish(now.Add(-3*time.Hour+2*time.Second), nil)
+
+	broken := history.NewRun("b2", "ynab_sync", now.Add(-time.Hour))
+	broken.Finish(now.Add(-time.Hour), errors.New("YNAB API error 401"))
+
+	var b bytes.Buffer
+	writeStatus(&b, []*history.Run{ok, broken}, 10, now)
+	out := b.String()
+
+	for _, want := range []string{
+		"Last successful sync: ",
+		"(3h ago)",
+		"FAILED",
+		"error: YNAB API error 401",
+		"messages 1 (chatdb 1)",
+		"synced 1",
+		"102          ",
+		"(4h ago)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "FAILED") > strings.Index(out, " ok ") {
+		t.Errorf("runs should be listed newest first:\n%s", out)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/apmyp/ynab_importer_go/suggest"
+)
+
+// Clusters of a single message are usually one-off notices, and drafting
+// from one message would copy its merchant and amounts into the pattern.
+const minClusterSize = 2
+
+func (app *App) runSuggestTemplates() error {
+	messages, cleanup, err := app.fetchMessages()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	unmatched := app.unmatchedMessages(messages)
+	clusters := suggest.Clusters(unmatched, minClusterSize)
+
+	fmt.Printf("Suggested templates for %d messages without templates:\n", len(unmatched))
+	fmt.Println("=====================================")
+
+	clustered, covered, drafts := 0, 0, 0
+	for i, c := range clusters {
+		clustered += len(c.Messages)
+		first, last := c.Messages[0].Timestamp, c.Messages[len(c.Messages)-1].Timestamp
+
+		fmt.Printf("\nCluster %d: %d messages from %s (%.1f%%), %s to %s\n",
+			i+1, len(c.Messages), c.Sender,
+			100*float64(len(c.Messages))/float64(len(unmatched)),
+			first.Format("2006-01-02"), last.Format("2006-01-02"))
+		fmt.Printf("  %s\n", c.Shape)
+
+		if c.Err != nil {
+			fmt.Printf("  No draft: %v\n", c.Err)
+			continue
+		}
+
+		drafts++
+		covered += c.Matched
+		fmt.Printf("  Draft matches %d/%d:\n  ", c.Matched, len(c.Messages))
+
+		// Keep < and > readable in named groups
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(c.Definition); err != nil {
+			return fmt.Errorf("failed to encode template: %w", err)
+		}
+	}
+
+	fmt.Printf("\n%d draft templates cover %d of %d messages", drafts, covered, len(unmatched))
+	if len(unmatched) > 0 {
+		fmt.Printf(" (%.1f%%)", 100*float64(covered)/float64(len(unmatched)))
+	}
+	fmt.Printf("; %d messages did not form a cluster\n", len(unmatched)-clustered)
+	if drafts > 0 {
+		fmt.Println("Review the drafts, then add them to \"templates\" in the config.")
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package suggest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+// Messages of one format differ only in masked tokens and free text such
+// as merchant names, so they stay well above this LCS similarity.
+const similarityThreshold = 0.7
+
+var balanceLabels = map[string]bool{
+	"disponibil": true, "dost": true, "sold": true, "balance": true,
+	"ostatok": true, "доступно": true, "остаток": true, "баланс": true,
+}
+
+type Cluster struct {
+	Sender   string
+	Messages []*message.Message
+	// Shape is the format with variable parts replaced by placeholders
+	// such as <AMOUNT> and <MERCHANT>, safe to print.
+	Shape      string
+	Definition template.Definition
+	// Err is set when no usable template could be drafted.
+	Err error
+	// Matched counts the cluster's messages that the draft matches.
+	Matched int
+
+	tokens [][]token
+}
+
+// Clusters groups messages of each sender by structural similarity and
+// drafts a template for every cluster with at least minSize messages.
+// Clusters are ordered by size, largest first.
+func Clusters(messages []*message.Message, minSize int) []*Cluster {
+	var clusters []*Cluster
+	bySignature := make(map[string]*Cluster)
+
+	for _, msg := range messages {
+		tokens := tokenize(msg.Content)
+		sig := msg.Sender + "\x00" + signature(tokens)
+
+		cluster := bySignature[sig]
+		if cluster == nil {
+			for _, c := range clusters {
+				if c.Sender == msg.Sender && similarity(c.tokens[0], tokens) >= similarityThreshold {
+					cluster = c
+					break
+				}
+			}
+		}
+		if cluster == nil {
+			cluster = &Cluster{Sender: msg.Sender}
+			clusters = append(clusters, cluster)
+		}
+		bySignature[sig] = cluster
+
+		cluster.Messages = append(cluster.Messages, msg)
+		cluster.tokens = append(cluster.tokens, tokens)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Messages) > len(clusters[j].Messages)
+	})
+
+	var result []*Cluster
+	for _, c := range clusters {
+		if len(c.Messages) < minSize {
+			continue
+		}
+		c.draft(fmt.Sprintf("%s-%d", templateName(c.Sender), len(result)+1))
+		result = append(result, c)
+	}
+	return result
+}
+
+func signature(tokens []token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if t.masked() {
+			fmt.Fprintf(&b, "<%d>", t.kind)
+		} else {
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func templateName(sender string) string {
+	name := strings.Trim(nonNameChars.ReplaceAllString(sender, "_"), "_")
+	if name == "" {
+		return "draft"
+	}
+	return name
+}
+
+type segment struct {
+	pattern string
+	shape   string
+}
+
+func (c *Cluster) draft(name string) {
+	rep := c.tokens[0]
+	constant := make([]bool, len(rep))
+	for i := range constant {
+		constant[i] = true
+	}
+	for _, other := range c.tokens[1:] {
+		for i, common := range lcs(rep, other) {
+			constant[i] = constant[i] && common
+		}
+	}
+
+	names := nameGroups(rep, constant)
+
+	var segments []segment
+	var operation string
+	for i := 0; i < len(rep); i++ {
+		if !constant[i] {
+			j := i
+			for j < len(rep) && !constant[j] {
+				j++
+			}
+			quantifier := ".+?"
+			if j == len(rep) {
+				quantifier = ".+"
+			}
+			if names[i] == "merchant" {
+				segments = append(segments, segment{"(?P<merchant>" + quantifier + ")", "<MERCHANT>"})
+			} else {
+				segments = append(segments, segment{quantifier, "<TEXT>"})
+			}
+			i = j - 1
+			continue
+		}
+
+		t, groupName := rep[i], names[i]
+		switch t.kind {
+		case kindSpace:
+			segments = append(segments, segment{`\s+`, " "})
+		case kindWord, kindPunct:
+			if operation == "" && t.kind == kindWord && len([]rune(t.text)) > 2 {
+				operation = t.text
+			}
+			segments = append(segments, segment{regexp.QuoteMeta(t.text), t.text})
+		case kindCurrency:
+			segments = append(segments, group(groupName, `\p{L}{3}`, "CURRENCY"))
+		case kindCard:
+			segments = append(segments, group(groupName, `(?:\d{1,6})?(?:\*+|\.{2,}|[xX]{2,})\d{4}`, "CARD"))
+		case kindDate:
+			pattern := digitPattern(t.text)
+			// Keep a following time inside the date group
+			if groupName == "date" && i+2 < len(rep) && constant[i+1] && constant[i+2] &&
+				rep[i+1].kind == kindSpace && rep[i+2].kind == kindTime {
+				pattern += `\s+` + digitPattern(rep[i+2].text)
+				i += 2
+			}
+			segments = append(segments, group(groupName, pattern, "DATE"))
+		case kindTime:
+			segments = append(segments, group(groupName, digitPattern(t.text), "TIME"))
+		case kindNumber:
+			segments = append(segments, group(groupName, `\d+(?:[.,]\d+)*`, "NUMBER"))
+		}
+	}
+
+	var pattern, shape strings.Builder
+	for _, s := range segments {
+		pattern.WriteString(s.pattern)
+		shape.WriteString(s.shape)
+	}
+	c.Shape = shape.String()
+
+	// Most unmatched notices are card payments; the direction is only a
+	// starting point for the user to review.
+	c.Definition = template.Definition{
+		Name:      name,
+		Pattern:   pattern.String(),
+		Operation: operation,
+		Direction: string(template.DirectionDebit),
+	}
+	tmpl, err := template.NewRegexTemplate(c.Definition)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	for _, msg := range c.Messages {
+		if tmpl.Match(msg.Content) {
+			c.Matched++
+		}
+	}
+}
+
+func group(name, pattern, placeholder string) segment {
+	if name == "" {
+		return segment{pattern, "<" + placeholder + ">"}
+	}
+	return segment{"(?P<" + name + ">" + pattern + ")", "<" + strings.ToUpper(name) + ">"}
+}
+
+var digitRun = regexp.MustCompile(`\d+`)
+
+// digitPattern keeps the separators of a date or time and replaces each
+// run of digits with \d{n}.
+func digitPattern(text string) string {
+	parts := digitRun.Split(text, -1)
+	runs := digitRun.FindAllString(text, -1)
+
+	var b strings.Builder
+	for i, part := range parts {
+		b.WriteString(regexp.QuoteMeta(part))
+		if i < len(runs) {
+			fmt.Fprintf(&b, `\d{%d}`, len(runs[i]))
+		}
+	}
+	return b.String()
+}
+
+// nameGroups picks which tokens of the representative message become the
+// amount, currency, balance, card, date and merchant groups.
+func nameGroups(tokens []token, constant []bool) map[int]string {
+	names := make(map[int]string)
+	used := make(map[string]bool)
+	assign := func(i int, name string) {
+		names[i] = name
+		used[name] = true
+	}
+
+	// next returns the index of the nearest constant non-space token in
+	// direction dir, or -1.
+	next := func(i, dir int) int {
+		for j := i + dir; j >= 0 && j < len(tokens); j += dir {
+			if !constant[j] {
+				return -1
+			}
+			if tokens[j].kind != kindSpace {
+				return j
+			}
+		}
+		return -1
+	}
+
+	for i, t := range tokens {
+		if !constant[i] {
+			continue
+		}
+		switch t.kind {
+		case kindCard:
+			if !used["card"] {
+				assign(i, "card")
+			}
+		case kindDate:
+			if !used["date"] {
+				assign(i, "date")
+			}
+		case kindNumber:
+			currency := next(i, 1)
+			if currency < 0 || tokens[currency].kind != kindCurrency {
+				currency = next(i, -1)
+			}
+			if currency >= 0 && tokens[currency].kind != kindCurrency {
+				currency = -1
+			}
+
+			switch {
+			case afterBalanceLabel(tokens, constant, i):
+				if !used["balance"] {
+					assign(i, "balance")
+				}
+			case currency >= 0 && !used["amount"]:
+				assign(i, "amount")
+				if !used["currency"] {
+					assign(currency, "currency")
+				}
+			case currency >= 0 && !used["balance"]:
+				assign(i, "balance")
+			}
+		}
+	}
+
+	if !used["amount"] {
+		for i, t := range tokens {
+			if constant[i] && t.kind == kindNumber && names[i] == "" {
+				assign(i, "amount")
+				break
+			}
+		}
+	}
+
+	for i := range tokens {
+		if !constant[i] && (i == 0 || constant[i-1]) && !used["merchant"] {
+			assign(i, "merchant")
+		}
+	}
+
+	return names
+}
+
+func afterBalanceLabel(tokens []token, constant []bool, i int) bool {
+	for j := i - 1; j >= 0 && j >= i-4; j-- {
+		if !constant[j] {
+			return false
+		}
+		if tokens[j].kind == kindWord && balanceLabels[strings.ToLower(tokens[j].text)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package suggest
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func testMessages(sender string, contents ...string) []*message.Message {
+	messages := make([]*message.Message, len(contents))
+	for i, content := range contents {
+		messages[i] = &message.Message{
+			Timestamp: time.Date(2024, 4, 1+i, 10, 0, 0, 0, time.UTC),
+			Sender:    sender,
+			Content:   content,
+		}
+	}
+	return messages
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := tokenize("Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL")
+
+	var kinds []kind
+	for _, tok := range tokens {
+		if tok.kind != kindSpace {
+			kinds = append(kinds, tok.kind)
+		}
+	}
+	want := []kind{kindWord, kindCard, kindPunct, kindWord, kindDate, kindTime, kindPunct, kindWord, kindNumber, kindCurrency}
+	if len(kinds) != len(want) {
+		t.Fatalf("tokenize() kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("token %d kind = %v, want %v", i, kinds[i], want[i])
+		}
+	}
+}
+
+func TestClusters_DraftsTemplate(t *testing.T) {
+	messages := testMessages("VICTORIABANK",
+		"Plata 4*1234 la LINELLA SRL, 08.04.2024 09:27, suma 9.65 MDL. Sold 100.00 MDL",
+		"Plata 4*1234 la KAUFLAND, 09.04.2024 18:02, suma 120.50 MDL. Sold 20.35 MDL",
+		"Plata 4*5678 la NR1 FARMACIE CENTRALA, 10.04.2024 07:45, suma 45 EUR. Sold 1,200.00 MDL",
+	)
+	messages = append(messages, testMessages("VICTORIABANK", "Codul Dvs. de confirmare: 123456")...)
+
+	clusters := Clusters(messages, 2)
+	if len(clusters) != 1 {
+		t.Fatalf("Clusters() returned %d clusters, want 1", len(clusters))
+	}
+
+	c := clusters[0]
+	if len(c.Messages) != 3 {
+		t.Errorf("cluster has %d messages, want 3", len(c.Messages))
+	}
+	if c.Err != nil {
+		t.Fatalf("draft error = %v", c.Err)
+	}
+	if c.Matched != 3 {
+		t.Errorf("draft matches %d of 3 messages; pattern %s", c.Matched, c.Definition.Pattern)
+	}
+	wantShape := "Plata <CARD> la <MERCHANT>, <DATE>, suma <AMOUNT> <CURRENCY>. Sold <BALANCE> <CURRENCY>"
+	if c.Shape != wantShape {
+		t.Errorf("Shape = %q, want %q", c.Shape, wantShape)
+	}
+	if c.Definition.Name != "VICTORIABANK-1" {
+		t.Errorf("Name = %q", c.Definition.Name)
+	}
+
+	tmpl, err := template.NewRegexTemplate(c.Definition)
+	if err != nil {
+		t.Fatalf("NewRegexTemplate() error = %v", err)
+	}
+	tx, err := tmpl.Parse(messages[2].Content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if tx.Card != "4*5678" || tx.Address != "NR1 FARMACIE CENTRALA" || tx.DateTime != "10.04.2024 07:45" {
+		t.Errorf("unexpected transaction: %+v", tx)
+	}
+	if tx.Original.Value != 45 || tx.Original.Currency != "EUR" || tx.Balance != 1200 {
+		t.Errorf("unexpected amounts: %+v, balance %v", tx.Original, tx.Balance)
+	}
+}
+
+func TestClusters_SeparatesSenders(t *testing.T) {
+	messages := append(
+		testMessages("A", "Suma 10.00 MDL la SHOP", "Suma 12.00 MDL la MARKET"),
+		testMessages("B", "Suma 10.00 MDL la SHOP", "Suma 11.00 MDL la CAFE")...,
+	)
+
+	clusters := Clusters(messages, 1)
+	if len(clusters) != 2 {
+		t.Fatalf("Clusters() returned %d clusters, want 2", len(clusters))
+	}
+	for _, c := range clusters {
+		if len(c.Messages) != 2 {
+			t.Errorf("cluster %s has %d messages, want 2", c.Sender, len(c.Messages))
+		}
+	}
+}
+
+func TestClusters_NoAmount(t *testing.T) {
+	clusters := Clusters(testMessages("X", "Bine ati venit", "Bine ati venit"), 2)
+	if len(clusters) != 1 || clusters[0].Err == nil {
+		t.Fatal("expected a cluster without a usable draft")
+	}
+}
+
+func TestDigitPattern(t *testing.T) {
+	got := digitPattern("08.04.2024")
+	if got != `\d{2}\.\d{2}\.\d{4}` {
+		t.Errorf("digitPattern() = %s", got)
+	}
+	if !regexp.MustCompile(got).MatchString("13.12.2025") {
+		t.Error("pattern should match another date")
+	}
+}
@@ -0,0 +1,124 @@
+package suggest
+
+import (
+	"regexp"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindWord kind = iota
+	kindSpace
+	kindPunct
+	kindCard
+	kindDate
+	kindTime
+	kindNumber
+	kindCurrency
+)
+
+type token struct {
+	kind kind
+	text string
+}
+
+var tokenRegex = regexp.MustCompile(
+	`((?:\d{1,6})?(?:\*+|\.{2,}|[xX]{2,})\d{4})` +
+		`|(\d{4}-\d{2}-\d{2}|\d{2}[./-]\d{2}[./-]\d{2,4})` +
+		`|(\d{2}:\d{2}(?::\d{2})?)` +
+		`|(\d+(?:[.,]\d+)*)` +
+		`|([\p{L}\p{M}]+)` +
+		`|(\s+)` +
+		`|(.)`)
+
+var currencies = map[string]bool{
+	"MDL": true, "EUR": true, "USD": true, "RON": true, "RUB": true, "UAH": true,
+	"GBP": true, "CHF": true, "TRY": true, "PLN": true, "LEI": true, "LEU": true,
+}
+
+var groupKinds = []kind{kindCard, kindDate, kindTime, kindNumber, kindWord, kindSpace, kindPunct}
+
+func tokenize(content string) []token {
+	var tokens []token
+	for _, m := range tokenRegex.FindAllStringSubmatchIndex(content, -1) {
+		for g, k := range groupKinds {
+			start := m[2*(g+1)]
+			if start < 0 {
+				continue
+			}
+			text := content[start:m[2*(g+1)+1]]
+			if k == kindWord && currencies[strings.ToUpper(text)] {
+				k = kindCurrency
+			}
+			tokens = append(tokens, token{kind: k, text: text})
+			break
+		}
+	}
+	return tokens
+}
+
+// masked reports whether the token's text varies between messages of the
+// same format, so that only its kind is compared.
+func (t token) masked() bool {
+	switch t.kind {
+	case kindCard, kindDate, kindTime, kindNumber, kindCurrency, kindSpace:
+		return true
+	}
+	return false
+}
+
+func (t token) equal(other token) bool {
+	if t.kind != other.kind {
+		return false
+	}
+	return t.masked() || t.text == other.text
+}
+
+// lcs returns, for each token of a, whether it is part of a longest common
+// subsequence with b.
+func lcs(a, b []token) []bool {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].equal(b[j]) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	common := make([]bool, len(a))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].equal(b[j]):
+			common[i] = true
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common
+}
+
+func similarity(a, b []token) float64 {
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+	common := 0
+	for _, c := range lcs(a, b) {
+		if c {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestNewMatcher_ConfigTemplates(t *testing.T) {
+	cfg := &config.Config{
+		Templates: []config.TemplateConfig{
+			{Name: "Victoriabank", Pattern: `Plata (?P<card>\S+) suma (?P<amount>[\d.]+) (?P<currency>[A-Z]{3})`, Operation: "Debitare", Direction: "debit"},
+			{Name: "Broken", Pattern: `(`},
+		},
+	}
+
+	matcher := newMatcher(cfg)
+	tx, err := matcher.Parse("Plata 4*1234 suma 10.50 MDL")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if tx.Card != "4*1234" || tx.Original.Value != 10.50 || tx.Operation != "Debitare" {
+		t.Errorf("unexpected transaction: %+v", tx)
+	}
+
+	tmpl := matcher.FindTemplate("Plata 4*1234 suma 10.50 MDL")
+	if tmpl == nil || tmpl.Name() != "Victoriabank" {
+		t.Errorf("FindTemplate() = %v, want Victoriabank", tmpl)
+	}
+}
+
+func TestApp_runSuggestTemplates(t *testing.T) {
+	cfg := &config.Config{
+		Senders:      []string{"VICTORIABANK"},
+		DataFilePath: filepath.Join(t.TempDir(), "data.json"),
+	}
+	now := time.Now()
+	fetcher := &MockFetcher{
+		messages: []*message.Message{
+			{Timestamp: now, Sender: "VICTORIABANK", Content: "Plata 4*1234 la LINELLA, suma 9.65 MDL"},
+			{Timestamp: now, Sender: "VICTORIABANK", Content: "Plata 4*1234 la KAUFLAND, suma 120.50 MDL"},
+			{Timestamp: now, Sender: "VICTORIABANK", Content: "Parola: 1234"},
+		},
+	}
+
+	app := NewAppWithFetcher(cfg, fetcher)
+	if err := app.runSuggestTemplates(); err != nil {
+		t.Errorf("runSuggestTemplates() error = %v", err)
+	}
+	if !fetcher.cleanupCalled {
+		t.Error("cleanup should have been called")
+	}
+}
+
+func TestApp_unmatchedMessages(t *testing.T) {
+	app := NewAppWithFetcher(&config.Config{DataFilePath: filepath.Join(t.TempDir(), "data.json")}, &MockFetcher{})
+	now := time.Now()
+	messages := []*message.Message{
+		{Timestamp: now, Sender: "102", Content: "Op: Tovary i uslugi\nKarta: *1234\nSumma: 34 MDL"},
+		{Timestamp: now, Sender: "102", Content: "Parola: 1234"},
+		{Timestamp: now, Sender: "Me", Content: "hello"},
+		{Timestamp: now, Sender: "102", Content: "Something new"},
+	}
+
+	unmatched := app.unmatchedMessages(messages)
+	if len(unmatched) != 1 || unmatched[0].Content != "Something new" {
+		t.Errorf("unmatchedMessages() = %v", unmatched)
+	}
+}
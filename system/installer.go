@@ -28,6 +28,7 @@ type fileWriter interface {
 
 type commandRunner interface {
 	Run(name string, args ...string) error
+	Output(name string, args ...string) ([]byte, error)
 }
 
 type osFileWriter struct{}
@@ -63,6 +64,10 @@ func (execCommandRunner) Run(name string, args ...string) error {
 	return cmd.Run()
 }
 
+func (execCommandRunner) Output(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
 func NewInstaller(execPath, workingDir, apiKey string) (*Installer, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
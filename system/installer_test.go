@@ -55,8 +55,10 @@ func (m *mockFileWriter) CopyFile(src, dst string, perm os.FileMode) error {
 }
 
 type mockCommandRunner struct {
-	runErr   error
-	commands [][]string
+	runErr    error
+	commands  [][]string
+	output    []byte
+	outputErr error
 }
 
 func (m *mockCommandRunner) Run(name string, args ...string) error {
@@ -68,6 +70,11 @@ func (m *mockCommandRunner) Run(name string, args ...string) error {
 	return nil
 }
 
+func (m *mockCommandRunner) Output(name string, args ...string) ([]byte, error) {
+	m.commands = append(m.commands, append([]string{name}, args...))
+	return m.output, m.outputErr
+}
+
 func TestCheckOS_Darwin(t *testing.T) {
 	installer := &Installer{
 		goos: "darwin",
@@ -0,0 +1,56 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	launchctlPID        = regexp.MustCompile(`"PID"\s*=\s*(\d+);`)
+	launchctlExitStatus = regexp.MustCompile(`"LastExitStatus"\s*=\s*(-?\d+);`)
+)
+
+type ServiceStatus struct {
+	Installed bool
+	Loaded    bool
+	Running   bool
+	// LastExitStatus is only known once the job has run since it was loaded
+	LastExitStatus    int
+	HasLastExitStatus bool
+	// LogPath is the job's standard error, where its log lines go.
+	// LogModified is zero when the job has not written it yet.
+	LogPath     string
+	LogModified time.Time
+}
+
+// Status reports whether the hourly sync service is installed and loaded,
+// how its last run exited and when it last wrote to its log.
+func (i *Installer) Status() (*ServiceStatus, error) {
+	if err := i.checkOS(); err != nil {
+		return nil, err
+	}
+
+	status := &ServiceStatus{LogPath: filepath.Join(i.workingDir, "ynab_sync_error.log")}
+
+	if _, err := os.Stat(i.launchdPlistPath()); err == nil {
+		status.Installed = true
+	}
+
+	if out, err := i.cmdRunner.Output("launchctl", "list", plistLabel); err == nil {
+		status.Loaded = true
+		status.Running = launchctlPID.Match(out)
+		if m := launchctlExitStatus.FindSubmatch(out); m != nil {
+			status.LastExitStatus, _ = strconv.Atoi(string(m[1]))
+			status.HasLastExitStatus = true
+		}
+	}
+
+	if info, err := os.Stat(status.LogPath); err == nil {
+		status.LogModified = info.ModTime()
+	}
+
+	return status, nil
+}
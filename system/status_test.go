@@ -0,0 +1,65 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatus_LoadedJob(t *testing.T) {
+	home := t.TempDir()
+	workingDir := t.TempDir()
+
+	agents := filepath.Join(home, "Library/LaunchAgents")
+	if err := os.MkdirAll(agents, 0755); err != nil {
+		t.Fatalf("failed to create LaunchAgents: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(agents, plistLabel+".plist"), []byte("<plist/>"), 0644); err != nil {
+		t.Fatalf("failed to write plist: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workingDir, "ynab_sync_error.log"), []byte("level=ERROR\n"), 0644); err != nil {
+		t.Fatalf("failed to write error log: %v", err)
+	}
+
+	runner := &mockCommandRunner{output: []byte(`{
+	"Label" = "com.apmyp.ynab_importer_go";
+	"LastExitStatus" = 256;
+};`)}
+	installer := &Installer{goos: "darwin", homeDir: home, workingDir: workingDir, cmdRunner: runner}
+
+	status, err := installer.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Installed || !status.Loaded || status.Running {
+		t.Errorf("Status() = %+v, want installed and loaded but not running", status)
+	}
+	if !status.HasLastExitStatus || status.LastExitStatus != 256 {
+		t.Errorf("LastExitStatus = %d, want 256", status.LastExitStatus)
+	}
+	if status.LogModified.IsZero() {
+		t.Error("LogModified should be set when the log exists")
+	}
+}
+
+func TestStatus_NotInstalled(t *testing.T) {
+	runner := &mockCommandRunner{outputErr: errors.New("Could not find service")}
+	installer := &Installer{goos: "darwin", homeDir: t.TempDir(), workingDir: t.TempDir(), cmdRunner: runner}
+
+	status, err := installer.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Installed || status.Loaded || !status.LogModified.IsZero() {
+		t.Errorf("Status() = %+v, want nothing installed", status)
+	}
+}
+
+func TestStatus_NonDarwin(t *testing.T) {
+	installer := &Installer{goos: "linux"}
+
+	if _, err := installer.Status(); err == nil {
+		t.Error("Status() on non-darwin should return error")
+	}
+}
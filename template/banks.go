@@ -0,0 +1,130 @@
+package template
+
+import "regexp"
+
+// Patterns shared by the bank definitions below.
+const (
+	amountGroup   = `(?P<amount>\d+(?:[.,]\d+)*)`
+	currencyGroup = `(?P<currency>\p{L}{3})`
+	balanceGroup  = `(?P<balance>\d+(?:[.,]\d+)*)`
+)
+
+// bankDefinitions covers the issuers and payment services whose messages
+// have a single-line format. Purchases use the "Debitare" operation and
+// top-ups "Suplinire", like Eximbank's, so they get no memo.
+var bankDefinitions = []Definition{
+	{
+		// Example: MICB: Plata card *1234, 125.50 MDL, LINELLA CHISINAU, 12.05.2024 14:33. Disponibil: 4520.10 MDL
+		Name:      "Moldindconbank",
+		Pattern:   `^MICB: Plata card (?P<card>\*\d{4}), ` + amountGroup + ` ` + currencyGroup + `, (?P<merchant>.+?), (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})\. Disponibil: ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: MICB: Alimentare card *1234, 5000.00 MDL, 25.05.2024 09:00. Disponibil: 9520.10 MDL
+		Name:      "MoldindconbankTopUp",
+		Pattern:   `^MICB: Alimentare card (?P<card>\*\d{4}), ` + amountGroup + ` ` + currencyGroup + `, (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})\. Disponibil: ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Suplinire",
+		Direction: string(DirectionCredit),
+		Kind:      string(KindTopUp),
+	},
+	{
+		// Example: VB: Card 4*1234; Cumparare 250.00 MDL; 15.05.2024 10:12; KAUFLAND CHISINAU; Disp: 8900.00 MDL
+		Name:      "Victoriabank",
+		Pattern:   `^VB: Card (?P<card>\d\*\d{4}); Cumparare ` + amountGroup + ` ` + currencyGroup + `; (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2}); (?P<merchant>.+?); Disp: ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: OTP Bank: Plata cu cardul *1234 in suma de 99.90 MDL la ORANGE SHOP, 16.05.2024 18:40. Sold disponibil: 1200.00 MDL
+		Name:      "OTPBank",
+		Pattern:   `^OTP Bank: Plata cu cardul (?P<card>\*\d{4}) in suma de ` + amountGroup + ` ` + currencyGroup + ` la (?P<merchant>.+?), (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})\. Sold disponibil: ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: FinComBank: Card ***1234 debitat cu 75.00 MDL la FARMACIA FAMILIEI 17/05/2024 09:05. Sold: 640.25 MDL
+		Name:      "FinComBank",
+		Pattern:   `^FinComBank: Card (?P<card>\*+\d{4}) debitat cu ` + amountGroup + ` ` + currencyGroup + ` la (?P<merchant>.+?) (?P<date>\d{2}/\d{2}/\d{4} \d{2}:\d{2})\. Sold: ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: Energbank: Achitare 320.00 MDL, card **1234, NR1 SUPERMARKET, 18.05.2024 12:00, sold 2300.00 MDL
+		Name:      "Energbank",
+		Pattern:   `^Energbank: Achitare ` + amountGroup + ` ` + currencyGroup + `, card (?P<card>\*+\d{4}), (?P<merchant>.+?), (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2}), sold ` + balanceGroup + ` \p{L}{3}$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: MPay: Plata in suma de 85.00 MDL catre Apa-Canal Chisinau a fost efectuata cu succes cu cardul *1234. 19.05.2024 20:15
+		Name:      "MPay",
+		Pattern:   `^MPay: Plata in suma de ` + amountGroup + ` ` + currencyGroup + ` catre (?P<merchant>.+?) a fost efectuata cu succes cu cardul (?P<card>\*\d{4})\. (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+	{
+		// Example: Paynet: Plata 150.00 MDL pentru Orange Moldova efectuata cu succes. Card *1234. 20.05.2024 08:30
+		Name:      "Paynet",
+		Pattern:   `^Paynet: Plata ` + amountGroup + ` ` + currencyGroup + ` pentru (?P<merchant>.+?) efectuata cu succes\. Card (?P<card>\*\d{4})\. (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})$`,
+		Operation: "Debitare",
+		Direction: string(DirectionDebit),
+		Kind:      string(KindPurchase),
+	},
+}
+
+// bankSenders are the SMS senders of bankDefinitions. One-time codes and
+// marketing messages from them are ignored, with a priority below the
+// templates so that a transaction mentioning "OTP Bank" or a promo merchant
+// is still imported.
+var bankSenders = []string{"MICB", "VICTORIABANK", "OTPbank", "FinComBank", "Energbank", "MPay", "Paynet"}
+
+// aggregatorSenders pay bills with a card issued by a bank, which sends its
+// own message for the same payment.
+var aggregatorSenders = map[string]bool{"MPay": true, "Paynet": true}
+
+// IsAggregator reports whether sender is a payment service confirming
+// payments made with another issuer's card.
+func IsAggregator(sender string) bool {
+	return aggregatorSenders[sender]
+}
+
+// otpWording is how the banks introduce a one-time code. It must not match
+// transaction wording such as "Cod operatiune" or the bank name "OTP Bank".
+const otpWording = `(?:cod(?:ul)?(?: dvs\.?)? de (?:confirmare|verificare)|confirmarea platii|parola (?:unica|de acces)|код подтверждения)`
+
+var (
+	// A one-time code message carries the code as its only number, so
+	// anything with an amount, card or balance is never ignored by it
+	otpRegex       = regexp.MustCompile(`(?i)^\D*(?:` + otpWording + `\D*\b\d{4,8}\b|\b\d{4,8}\b\D*` + otpWording + `)\D*$`)
+	marketingRegex = regexp.MustCompile(`(?i)\b(?:oferta|promo(?:tie)?|reducere|profita|castiga|credit(?:ul)? (?:rapid|de consum))\b|акция|скидка`)
+)
+
+func bankTemplates() []Template {
+	templates := make([]Template, 0, len(bankDefinitions))
+	for _, def := range bankDefinitions {
+		tmpl, err := NewRegexTemplate(def)
+		if err != nil {
+			panic(err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates
+}
+
+func bankIgnoreRules() []*IgnoreRule {
+	var rules []*IgnoreRule
+	for _, sender := range bankSenders {
+		rules = append(rules,
+			&IgnoreRule{Sender: sender, Regex: otpRegex, Priority: -1},
+			&IgnoreRule{Sender: sender, Regex: marketingRegex, Priority: -1},
+		)
+	}
+	return rules
+}
@@ -0,0 +1,31 @@
+package template
+
+import "testing"
+
+func TestBankIgnoreRules_ScopedToSender(t *testing.T) {
+	m := NewMatcher()
+	content := "Codul de confirmare MICB Mobile: 482915."
+
+	if match := m.Classify("MICB", content); !match.Ignored() {
+		t.Errorf("Classify(MICB) should ignore the code")
+	}
+	if match := m.Classify("Unknown", content); match.Ignored() || match.Template != nil {
+		t.Errorf("Classify(Unknown) = %+v, want unmatched", match)
+	}
+}
+
+func TestBankTemplates_WinOverIgnoreRules(t *testing.T) {
+	m := NewMatcher()
+	content := "OTP Bank: Plata cu cardul *1234 in suma de 99.90 MDL la PROMO SHOP, 16.05.2024 18:40. Sold disponibil: 1200.00 MDL"
+
+	match := m.Classify("OTPbank", content)
+	if match.Template == nil || match.Template.Name() != "OTPBank" {
+		t.Fatalf("Classify() template = %v, want OTPBank", match.Template)
+	}
+	if match.Ambiguous {
+		t.Error("Classify() should not be ambiguous")
+	}
+	if len(match.IgnoreRules) != 1 || match.IgnoreRules[0].Regex != marketingRegex {
+		t.Errorf("Classify() ignore rules = %v, want only the marketing rule", match.IgnoreRules)
+	}
+}
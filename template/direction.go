@@ -0,0 +1,46 @@
+package template
+
+import "fmt"
+
+// Direction says which way money moves on the account the message is about.
+type Direction string
+
+const (
+	DirectionUnknown  Direction = ""
+	DirectionDebit    Direction = "debit"
+	DirectionCredit   Direction = "credit"
+	DirectionReversal Direction = "reversal"
+	DirectionTransfer Direction = "transfer"
+)
+
+func ParseDirection(value string) (Direction, error) {
+	switch d := Direction(value); d {
+	case DirectionDebit, DirectionCredit, DirectionReversal, DirectionTransfer:
+		return d, nil
+	}
+	return DirectionUnknown, fmt.Errorf("unknown direction %q (want debit, credit, reversal or transfer)", value)
+}
+
+// Kind describes what a transaction is for. It is informational and may be
+// empty.
+type Kind string
+
+const (
+	KindUnknown  Kind = ""
+	KindPurchase Kind = "purchase"
+	KindATM      Kind = "atm"
+	KindFee      Kind = "fee"
+	KindSalary   Kind = "salary"
+	KindRefund   Kind = "refund"
+	KindTopUp    Kind = "topup"
+	KindTransfer Kind = "transfer"
+	KindCashback Kind = "cashback"
+)
+
+func ParseKind(value string) (Kind, error) {
+	switch k := Kind(value); k {
+	case KindUnknown, KindPurchase, KindATM, KindFee, KindSalary, KindRefund, KindTopUp, KindTransfer, KindCashback:
+		return k, nil
+	}
+	return KindUnknown, fmt.Errorf("unknown kind %q", value)
+}
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+// Explainer is implemented by templates that can say why a message does
+// not match them.
+type Explainer interface {
+	Explain(content string) string
+}
+
+func Explain(tmpl Template, content string) string {
+	if e, ok := tmpl.(Explainer); ok {
+		return e.Explain(content)
+	}
+	return "pattern did not match"
+}
+
+// explainRegex finds the longest leading run of the pattern's top-level
+// elements that still matches and reports the element that failed after it.
+func explainRegex(re *regexp.Regexp, content string) string {
+	if re.MatchString(content) {
+		return ""
+	}
+
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil || parsed.Op != syntax.OpConcat {
+		return fmt.Sprintf("pattern `%s` did not match", re)
+	}
+
+	failed, matchedEnd := failedElement(parsed, content)
+	// The whole pattern failed although every element matched, e.g. when a
+	// prefix does not compile on its own or its flags differ
+	if failed >= len(parsed.Sub) {
+		return fmt.Sprintf("pattern `%s` did not match", re)
+	}
+
+	expected := parsed.Sub[failed].String()
+	if matchedEnd < 0 {
+		return fmt.Sprintf("expected `%s`", expected)
+	}
+	if matchedEnd == 0 {
+		return fmt.Sprintf("expected `%s` but found %q", expected, truncate(content, 30, false))
+	}
+	return fmt.Sprintf("matched %q, then expected `%s` but found %q",
+		truncate(content[:matchedEnd], 40, true), expected, truncate(content[matchedEnd:], 30, false))
+}
+
+// failedElement returns the index of the first top-level element after the
+// longest matching run, len(parsed.Sub) when no element fails, and where
+// the match of the run ends, or -1 when nothing matched.
+func failedElement(parsed *syntax.Regexp, content string) (failed, matchedEnd int) {
+	matchedEnd = -1
+	for i := 1; i <= len(parsed.Sub); i++ {
+		prefix := &syntax.Regexp{Op: syntax.OpConcat, Flags: parsed.Flags, Sub: parsed.Sub[:i]}
+		prefixRegex, err := regexp.Compile(prefix.String())
+		if err != nil {
+			return len(parsed.Sub), matchedEnd
+		}
+		loc := prefixRegex.FindStringIndex(content)
+		if loc == nil {
+			return i - 1, matchedEnd
+		}
+		matchedEnd = loc[1]
+	}
+	return len(parsed.Sub), matchedEnd
+}
+
+func truncate(s string, max int, keepEnd bool) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if keepEnd {
+		return "…" + string(runes[len(runes)-max:])
+	}
+	return string(runes[:max]) + "…"
+}
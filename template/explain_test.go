@@ -0,0 +1,77 @@
+package template
+
+import (
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     Template
+		content  string
+		contains []string
+	}{
+		{
+			name:     "literal missing",
+			tmpl:     NewDebitareTemplate(),
+			content:  "Hello world",
+			contains: []string{"expected `Debitare cont Card `"},
+		},
+		{
+			name:     "decimal comma in amount",
+			tmpl:     NewDebitareTemplate(),
+			content:  "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9,65 MDL, Detalii x, Disponibil 1.00 MDL",
+			contains: []string{"Suma 9\"", "found \",65 MDL"},
+		},
+		{
+			name:     "anchored at start",
+			tmpl:     NewMAIBTemplate(),
+			content:  "OP: Tovary",
+			contains: []string{"expected `Op|Операция|", "but found \"OP: Tovary\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Explain(tt.tmpl, tt.content)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Explain() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExplain_Matching(t *testing.T) {
+	content := "Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 10.00 MDL, Detalii Plata"
+	if got := Explain(NewSuplinireTemplate(), content); got != "" {
+		t.Errorf("Explain() = %q, want empty for a matching message", got)
+	}
+}
+
+func TestFailedElement_EveryElementMatches(t *testing.T) {
+	parsed, err := syntax.Parse(`ab`, syntax.Perl)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	parsed = &syntax.Regexp{Op: syntax.OpConcat, Sub: []*syntax.Regexp{parsed}}
+
+	// Reported as no failing element, which explainRegex must not index
+	failed, matchedEnd := failedElement(parsed, "xab")
+	if failed != len(parsed.Sub) || matchedEnd != 3 {
+		t.Errorf("failedElement() = %d, %d, want %d, 3", failed, matchedEnd, len(parsed.Sub))
+	}
+}
+
+func TestMatcher_IgnoredBy(t *testing.T) {
+	m := NewMatcher()
+	if got := m.IgnoredBy("Parola: 1234"); got != "/^Parola:/" {
+		t.Errorf("IgnoredBy() = %q, want %q", got, "/^Parola:/")
+	}
+	if got := m.IgnoredBy("Op: Tovary i uslugi"); got != "" {
+		t.Errorf("IgnoredBy() = %q, want empty", got)
+	}
+}
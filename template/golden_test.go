@@ -0,0 +1,158 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate the expected files in testdata")
+
+// goldenFile is the expected outcome stored next to each testdata/<bank>/*.txt
+// message. Messages are either ignored or matched by exactly one template and
+// no ignore rule, unless the lower-priority ignore rules it beats are listed
+// in Overrides. Unmatched samples are transactions no template covers yet,
+// which must stay visible instead of being ignored. Messages are classified
+// as sent by the sender named in testdata/<bank>/sender, if present.
+type goldenFile struct {
+	Ignored     bool                   `json:"ignored,omitempty"`
+	Unmatched   bool                   `json:"unmatched,omitempty"`
+	Template    string                 `json:"template,omitempty"`
+	Overrides   []string               `json:"overrides,omitempty"`
+	Transaction map[string]interface{} `json:"transaction,omitempty"`
+}
+
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden messages found in testdata")
+	}
+
+	matcher := NewMatcher()
+	for _, path := range paths {
+		name := strings.TrimSuffix(path, ".txt")
+		t.Run(strings.TrimPrefix(name, "testdata"+string(filepath.Separator)), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := strings.TrimRight(string(data), "\n")
+
+			var sender string
+			if data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "sender")); err == nil {
+				sender = strings.TrimSpace(string(data))
+			}
+
+			got := goldenOutcome(t, matcher, sender, content)
+			goldenPath := name + ".json"
+
+			var want goldenFile
+			wantData, err := os.ReadFile(goldenPath)
+			if err == nil {
+				if err := json.Unmarshal(wantData, &want); err != nil {
+					t.Fatalf("invalid golden file %s: %v", goldenPath, err)
+				}
+			} else if !*update {
+				t.Fatalf("missing golden file %s; run go test ./template -update", goldenPath)
+			}
+
+			// Regenerating must not hide one template taking over another's
+			// messages, so a changed owner always needs a manual edit.
+			if wantData != nil && (want.Template != got.Template || want.Ignored != got.Ignored || want.Unmatched != got.Unmatched) {
+				t.Fatalf("message moved from %s to %s", describe(want), describe(got))
+			}
+			// Likewise a new ignore rule overlapping a template must be
+			// listed by hand, not picked up by regenerating.
+			if wantData != nil && strings.Join(want.Overrides, "\n") != strings.Join(got.Overrides, "\n") {
+				t.Fatalf("template %s overrides ignore rules %q, golden file lists %q", got.Template, got.Overrides, want.Overrides)
+			}
+
+			gotData, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotData = append(gotData, '\n')
+
+			if *update {
+				if err := os.WriteFile(goldenPath, gotData, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !bytes.Equal(gotData, wantData) {
+				t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", goldenPath, gotData, wantData)
+			}
+		})
+	}
+}
+
+func goldenOutcome(t *testing.T, matcher *Matcher, sender, content string) goldenFile {
+	t.Helper()
+
+	match := matcher.Classify(sender, content)
+	if match.Ambiguous {
+		t.Fatalf("ambiguous match: %s", strings.Join(match.Candidates(), ", "))
+	}
+	if match.Ignored() {
+		if len(match.Templates) > 0 {
+			t.Fatalf("ignored message also matches templates: %s", strings.Join(match.Candidates(), ", "))
+		}
+		return goldenFile{Ignored: true}
+	}
+	if match.Template == nil {
+		return goldenFile{Unmatched: true}
+	}
+	if len(match.Templates) > 1 {
+		t.Fatalf("message matches %d templates: %s", len(match.Templates), strings.Join(match.Candidates(), ", "))
+	}
+	matched := match.Templates
+
+	var overrides []string
+	for _, rule := range match.IgnoreRules {
+		overrides = append(overrides, rule.String())
+	}
+
+	tx, err := matched[0].Parse(content)
+	if err != nil {
+		t.Fatalf("%s failed to parse: %v", matched[0].Name(), err)
+	}
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	// RawMessage only repeats the .txt file, and templates never convert
+	delete(fields, "RawMessage")
+	delete(fields, "Converted")
+	if components, ok := fields["Components"].([]interface{}); ok {
+		for _, c := range components {
+			delete(c.(map[string]interface{}), "Converted")
+		}
+	}
+
+	return goldenFile{Template: matched[0].Name(), Overrides: overrides, Transaction: fields}
+}
+
+func describe(g goldenFile) string {
+	if g.Ignored {
+		return "ignored"
+	}
+	if g.Unmatched {
+		return "unmatched"
+	}
+	if g.Template == "" {
+		return "no template"
+	}
+	return g.Template
+}
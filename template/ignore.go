@@ -0,0 +1,62 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IgnoreRule marks messages that are not transactions. A rule matches when
+// the content contains Contains or matches Regex, and, if Sender is set,
+// the message comes from that sender.
+type IgnoreRule struct {
+	Sender   string
+	Contains string
+	Regex    *regexp.Regexp
+	Priority int
+}
+
+func NewIgnoreRule(sender, contains, pattern string, priority int) (*IgnoreRule, error) {
+	if (contains == "") == (pattern == "") {
+		return nil, fmt.Errorf("ignore rule needs exactly one of contains or regex")
+	}
+
+	rule := &IgnoreRule{Sender: sender, Contains: contains, Priority: priority}
+	if pattern != "" {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore regex: %w", err)
+		}
+		rule.Regex = regex
+	}
+	return rule, nil
+}
+
+func containsRule(text string) *IgnoreRule {
+	return &IgnoreRule{Contains: text}
+}
+
+func regexRule(pattern string) *IgnoreRule {
+	return &IgnoreRule{Regex: regexp.MustCompile(pattern)}
+}
+
+func (r *IgnoreRule) Matches(sender, content string) bool {
+	if r.Sender != "" && !strings.EqualFold(r.Sender, sender) {
+		return false
+	}
+	if r.Regex != nil {
+		return r.Regex.MatchString(content)
+	}
+	return strings.Contains(content, r.Contains)
+}
+
+func (r *IgnoreRule) String() string {
+	s := fmt.Sprintf("%q", r.Contains)
+	if r.Regex != nil {
+		s = "/" + r.Regex.String() + "/"
+	}
+	if r.Sender != "" {
+		s += " from " + r.Sender
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewIgnoreRule(t *testing.T) {
+	if _, err := NewIgnoreRule("", "", "", 0); err == nil {
+		t.Error("expected error without contains or regex")
+	}
+	if _, err := NewIgnoreRule("", "Parola", "^Parola", 0); err == nil {
+		t.Error("expected error with both contains and regex")
+	}
+	if _, err := NewIgnoreRule("", "", "(", 0); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestIgnoreRule_Matches(t *testing.T) {
+	scoped, _ := NewIgnoreRule("VICTORIABANK", "", `^Codul \d+`, 0)
+
+	tests := []struct {
+		sender  string
+		content string
+		want    bool
+	}{
+		{"VICTORIABANK", "Codul 1234 este valabil 5 minute", true},
+		{"victoriabank", "Codul 1234 este valabil 5 minute", true},
+		{"102", "Codul 1234 este valabil 5 minute", false},
+		{"VICTORIABANK", "Plata reusita. Codul 1234", false},
+	}
+
+	for _, tt := range tests {
+		if got := scoped.Matches(tt.sender, tt.content); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.sender, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_Classify(t *testing.T) {
+	debitare := "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii Test, Disponibil 100.00 MDL"
+
+	t.Run("single template", func(t *testing.T) {
+		match := NewMatcher().Classify("EXIMBANK", debitare)
+		if match.Template == nil || match.Template.Name() != "Debitare" || match.Ambiguous {
+			t.Errorf("unexpected match: %+v", match)
+		}
+	})
+
+	t.Run("lower priority ignore rule", func(t *testing.T) {
+		match := NewMatcher().Classify("EXIMBANK", "Tranzactia din 29/05/2023 din contul A in contul B in suma de 5000.00 MDL a fost Executata")
+		if match.Template == nil || match.Template.Name() != "EximTransaction" {
+			t.Errorf("Template = %v, want EximTransaction", match.Template)
+		}
+		if match.Ambiguous || len(match.IgnoreRules) != 1 {
+			t.Errorf("unexpected match: %+v", match)
+		}
+	})
+
+	t.Run("template and ignore rule tie", func(t *testing.T) {
+		m := NewMatcher()
+		rule, _ := NewIgnoreRule("", "Detalii Test", "", 0)
+		m.AddIgnoreRules(rule)
+
+		match := m.Classify("EXIMBANK", debitare)
+		if !match.Ambiguous {
+			t.Error("expected ambiguous match")
+		}
+		if match.Template == nil || match.Ignored() {
+			t.Errorf("template should win a tie, got %+v", match)
+		}
+		if candidates := match.Candidates(); len(candidates) != 2 || !strings.Contains(candidates[1], "Detalii Test") {
+			t.Errorf("Candidates() = %v", candidates)
+		}
+	})
+
+	t.Run("higher priority ignore rule", func(t *testing.T) {
+		m := NewMatcher()
+		rule, _ := NewIgnoreRule("EXIMBANK", "Detalii Test", "", 1)
+		m.AddIgnoreRules(rule)
+
+		match := m.Classify("EXIMBANK", debitare)
+		if !match.Ignored() || match.Template != nil || match.Ambiguous {
+			t.Errorf("ignore rule should win, got %+v", match)
+		}
+		if other := m.Classify("102", debitare); other.Ignored() {
+			t.Error("sender-scoped rule should not apply to other senders")
+		}
+	})
+
+	t.Run("two templates", func(t *testing.T) {
+		m := NewMatcher()
+		low, _ := NewRegexTemplate(Definition{Name: "Generic", Pattern: `Suma (?P<amount>[\d.]+)`, Direction: "debit"})
+		high, _ := NewRegexTemplate(Definition{Name: "Specific", Pattern: `Detalii Test, Disponibil (?P<amount>[\d.]+)`, Priority: 5, Direction: "debit"})
+		m.AddTemplates(low, high)
+
+		match := m.Classify("EXIMBANK", debitare)
+		if match.Template != high || match.Ambiguous {
+			t.Errorf("Template = %v, ambiguous = %v; want Specific", match.Template, match.Ambiguous)
+		}
+		if len(match.Templates) != 3 {
+			t.Errorf("expected 3 matching templates, got %d", len(match.Templates))
+		}
+		if m.FindTemplate(debitare) != high {
+			t.Error("FindTemplate() should respect priority")
+		}
+
+		m.AddTemplates(low)
+		if m.Classify("102", "Suma 1.00").Ambiguous != true {
+			t.Error("expected ambiguity between templates of equal priority")
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package template
+
+import "strings"
+
+// Stage is where a card payment is in its lifecycle. A purchase is usually
+// authorized first and settled later, or declined or reversed instead.
+type Stage string
+
+const (
+	StageAuthorized Stage = "authorized"
+	StageSettled    Stage = "settled"
+	StageDeclined   Stage = "declined"
+	StageReversed   Stage = "reversed"
+)
+
+// StageOf returns the lifecycle stage a message reports. MAIB marks
+// authorizations with status Odobrena; messages without a status report
+// bookings on the account and are settled.
+func StageOf(tx *Transaction) Stage {
+	switch {
+	case strings.HasPrefix(tx.Status, "Decline"):
+		return StageDeclined
+	case tx.Direction == DirectionReversal:
+		return StageReversed
+	case tx.Status == "Odobrena":
+		return StageAuthorized
+	}
+	return StageSettled
+}
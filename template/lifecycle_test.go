@@ -0,0 +1,22 @@
+package template
+
+import "testing"
+
+func TestStageOf(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   Transaction
+		want Stage
+	}{
+		{"approved purchase", Transaction{Status: "Odobrena", Direction: DirectionDebit}, StageAuthorized},
+		{"declined", Transaction{Status: "Decline (insufficient funds)", Direction: DirectionDebit}, StageDeclined},
+		{"cancellation", Transaction{Status: "Odobrena", Direction: DirectionReversal}, StageReversed},
+		{"booking", Transaction{Operation: "Debitare", Direction: DirectionDebit}, StageSettled},
+	}
+
+	for _, tt := range tests {
+		if got := StageOf(&tt.tx); got != tt.want {
+			t.Errorf("%s: StageOf() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
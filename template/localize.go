@@ -0,0 +1,100 @@
+package template
+
+import "strings"
+
+// maibKeys maps the field names of localized MAIB messages onto the
+// transliterated ones the template was written for.
+var maibKeys = map[string]string{
+	"Операция":   "Op",
+	"Operațiune": "Op",
+	"Operaţiune": "Op",
+	"Operatiune": "Op",
+	"Карта":      "Karta",
+	"Card":       "Karta",
+	"Статус":     "Status",
+	"Stare":      "Status",
+	"Сумма":      "Summa",
+	"Suma":       "Summa",
+	"Sumă":       "Summa",
+	"Доступно":   "Dost",
+	"Disponibil": "Dost",
+	"Дата/время": "Data/vremya",
+	"Data/ora":   "Data/vremya",
+	"Адрес":      "Adres",
+	"Adresa":     "Adres",
+	"Adresă":     "Adres",
+	"Поддержка":  "Podderzhka",
+	"Suport":     "Podderzhka",
+	"Asistență":  "Podderzhka",
+	"Asistenta":  "Podderzhka",
+	"Комиссия":   "Komissiya",
+	"Comision":   "Komissiya",
+	"Кэшбэк":     "Keshbek",
+	"Cashback":   "Keshbek",
+}
+
+// Operations and statuses are normalized to the transliterated names so
+// the YNAB mapper classifies localized messages the same way.
+var operationNames = map[string]string{
+	"товары и услуги":    "Tovary i uslugi",
+	"bunuri si servicii": "Tovary i uslugi",
+	"снятие наличных":    "Snyatie nalichnyh",
+	"retragere numerar":  "Snyatie nalichnyh",
+	"пополнение":         "Popolnenie",
+	"alimentare":         "Popolnenie",
+	"возврат":            "Vozvrat",
+	"rambursare":         "Vozvrat",
+	"отмена":             "Otmena",
+	"anulare":            "Otmena",
+	"комиссия":           "Komissiya",
+	"comision":           "Komissiya",
+	"зарплата":           "Zarplata",
+	"salariu":            "Zarplata",
+}
+
+var statusNames = map[string]string{
+	"одобрена":  "Odobrena",
+	"одобрено":  "Odobrena",
+	"aprobata":  "Odobrena",
+	"aprobat":   "Odobrena",
+	"отклонена": "Decline",
+	"отказ":     "Decline",
+	"respinsa":  "Decline",
+	"refuzata":  "Decline",
+}
+
+var diacritics = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "A", "Â", "A", "Î", "I", "Ș", "S", "Ş", "S", "Ț", "T", "Ţ", "T",
+)
+
+func foldName(name string) string {
+	return strings.ToLower(diacritics.Replace(strings.TrimSpace(name)))
+}
+
+func normalizeOperation(op string) string {
+	if name, ok := operationNames[foldName(op)]; ok {
+		return name
+	}
+	return op
+}
+
+func normalizeStatus(status string) string {
+	if name, ok := statusNames[foldName(status)]; ok {
+		return name
+	}
+	return status
+}
+
+var currencyNames = map[string]string{
+	"лей": "MDL",
+	"lei": "MDL",
+	"leu": "MDL",
+}
+
+func normalizeCurrency(currency string) string {
+	if code, ok := currencyNames[strings.ToLower(currency)]; ok {
+		return code
+	}
+	return currency
+}
@@ -0,0 +1,160 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Definition describes a template declaratively as a regular expression
+// with named groups. Recognised groups are amount, currency, card, date,
+// merchant, balance, operation, status, fee and cashback; amount is
+// required, and fee and cashback are in the amount's currency. Direction
+// is required and Kind optional, see ParseDirection and ParseKind.
+type Definition struct {
+	Name      string `json:"name"`
+	Pattern   string `json:"pattern"`
+	Operation string `json:"operation,omitempty"`
+	Priority  int    `json:"priority,omitempty"`
+	Direction string `json:"direction"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+type RegexTemplate struct {
+	def       Definition
+	regex     *regexp.Regexp
+	direction Direction
+	kind      Kind
+}
+
+func NewRegexTemplate(def Definition) (*RegexTemplate, error) {
+	if def.Name == "" {
+		return nil, errors.New("template name is required")
+	}
+
+	regex, err := regexp.Compile(def.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for template %s: %w", def.Name, err)
+	}
+	if regex.SubexpIndex("amount") < 0 {
+		return nil, fmt.Errorf("pattern for template %s has no amount group", def.Name)
+	}
+
+	direction, err := ParseDirection(def.Direction)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+	kind, err := ParseKind(def.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", def.Name, err)
+	}
+
+	return &RegexTemplate{def: def, regex: regex, direction: direction, kind: kind}, nil
+}
+
+func (t *RegexTemplate) Name() string {
+	return t.def.Name
+}
+
+func (t *RegexTemplate) Priority() int {
+	return t.def.Priority
+}
+
+func (t *RegexTemplate) Definition() Definition {
+	return t.def
+}
+
+func (t *RegexTemplate) Match(content string) bool {
+	return t.regex.MatchString(content)
+}
+
+func (t *RegexTemplate) Explain(content string) string {
+	return explainRegex(t.regex, content)
+}
+
+func (t *RegexTemplate) Parse(content string) (*Transaction, error) {
+	matches := t.regex.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, fmt.Errorf("failed to parse %s message", t.def.Name)
+	}
+
+	group := func(name string) string {
+		if i := t.regex.SubexpIndex(name); i >= 0 {
+			return strings.TrimSpace(matches[i])
+		}
+		return ""
+	}
+
+	amount, err := parseGroupedNumber(group("amount"))
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &Transaction{
+		Operation:  t.def.Operation,
+		Direction:  t.direction,
+		Kind:       t.kind,
+		Card:       group("card"),
+		Status:     group("status"),
+		Original:   Amount{Value: amount, Currency: group("currency")},
+		DateTime:   group("date"),
+		Address:    group("merchant"),
+		RawMessage: content,
+	}
+	for _, c := range []struct {
+		group string
+		kind  Kind
+	}{{"fee", KindFee}, {"cashback", KindCashback}} {
+		value := group(c.group)
+		if value == "" {
+			continue
+		}
+		componentAmount, err := parseGroupedNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		tx.Components = append(tx.Components, Component{Kind: c.kind, Original: Amount{Value: componentAmount, Currency: tx.Original.Currency}})
+	}
+	if op := group("operation"); op != "" {
+		tx.Operation = op
+	}
+	if balance := group("balance"); balance != "" {
+		tx.Balance, err = parseGroupedNumber(balance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
+// parseGroupedNumber accepts "1234.56", "1234,56", "1,234.56" and
+// "1.234,56": when both separators appear the last one is the decimal
+// point, and a single separator that repeats groups thousands.
+func parseGroupedNumber(value string) (float64, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	dot := strings.LastIndex(value, ".")
+	comma := strings.LastIndex(value, ",")
+
+	switch {
+	case dot >= 0 && comma >= 0:
+		if dot > comma {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	case comma >= 0:
+		if strings.Count(value, ",") > 1 {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	case strings.Count(value, ".") > 1:
+		value = strings.ReplaceAll(value, ".", "")
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,108 @@
+package template
+
+import "testing"
+
+func TestNewRegexTemplate_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+	}{
+		{"no name", Definition{Pattern: `(?P<amount>\d+)`, Direction: "debit"}},
+		{"bad pattern", Definition{Name: "x", Pattern: `(`, Direction: "debit"}},
+		{"no amount group", Definition{Name: "x", Pattern: `Suma (\d+)`, Direction: "debit"}},
+		{"no direction", Definition{Name: "x", Pattern: `Suma (?P<amount>\d+)`}},
+		{"bad direction", Definition{Name: "x", Pattern: `Suma (?P<amount>\d+)`, Direction: "out"}},
+		{"bad kind", Definition{Name: "x", Pattern: `Suma (?P<amount>\d+)`, Direction: "debit", Kind: "misc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRegexTemplate(tt.def); err == nil {
+				t.Error("NewRegexTemplate() expected error")
+			}
+		})
+	}
+}
+
+func TestRegexTemplate_Parse(t *testing.T) {
+	tmpl, err := NewRegexTemplate(Definition{
+		Name:      "Victoriabank",
+		Pattern:   `Plata cu cardul (?P<card>\S+) la (?P<merchant>.+?) in suma de (?P<amount>[\d.,]+) (?P<currency>[A-Z]{3})\. Sold: (?P<balance>[\d.,]+)`,
+		Operation: "Debitare",
+		Direction: "debit",
+		Kind:      "purchase",
+	})
+	if err != nil {
+		t.Fatalf("NewRegexTemplate() error = %v", err)
+	}
+
+	content := "Plata cu cardul 4*1234 la LINELLA SRL in suma de 1,234.50 MDL. Sold: 10.000,25"
+	if !tmpl.Match(content) {
+		t.Fatal("Match() = false")
+	}
+
+	tx, err := tmpl.Parse(content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if tx.Operation != "Debitare" || tx.Card != "4*1234" || tx.Address != "LINELLA SRL" {
+		t.Errorf("unexpected transaction: %+v", tx)
+	}
+	if tx.Direction != DirectionDebit || tx.Kind != KindPurchase {
+		t.Errorf("Direction, Kind = %q, %q, want debit, purchase", tx.Direction, tx.Kind)
+	}
+	if tx.Original.Value != 1234.50 || tx.Original.Currency != "MDL" {
+		t.Errorf("Original = %+v, want 1234.50 MDL", tx.Original)
+	}
+	if tx.Balance != 10000.25 {
+		t.Errorf("Balance = %v, want 10000.25", tx.Balance)
+	}
+}
+
+func TestParseGroupedNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"34", 34},
+		{"9.65", 9.65},
+		{"12500,50", 12500.50},
+		{"1,234.56", 1234.56},
+		{"1.234,56", 1234.56},
+		{"1,234,567", 1234567},
+		{"1.234.567", 1234567},
+		{"1 234,56", 1234.56},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGroupedNumber(tt.input)
+		if err != nil {
+			t.Errorf("parseGroupedNumber(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseGroupedNumber(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRegexTemplate_Parse_Components(t *testing.T) {
+	tmpl, err := NewRegexTemplate(Definition{
+		Name:      "WithFee",
+		Pattern:   `Retragere (?P<amount>[\d.]+) (?P<currency>[A-Z]{3}), comision (?P<fee>[\d.]+)(?:, cashback (?P<cashback>[\d.]+))?`,
+		Direction: "debit",
+		Kind:      "atm",
+	})
+	if err != nil {
+		t.Fatalf("NewRegexTemplate() error = %v", err)
+	}
+
+	tx, err := tmpl.Parse("Retragere 500.00 MDL, comision 7.50")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Component{{Kind: KindFee, Original: Amount{Value: 7.5, Currency: "MDL"}}}
+	if len(tx.Components) != 1 || tx.Components[0] != want[0] {
+		t.Errorf("Components = %+v, want %+v", tx.Components, want)
+	}
+}
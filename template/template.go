@@ -2,6 +2,7 @@ package template
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,8 +13,18 @@ type Amount struct {
 	Currency string
 }
 
+// Component is an amount reported next to the main one: a fee charged on
+// top of it (KindFee) or cashback credited for it (KindCashback).
+type Component struct {
+	Kind      Kind
+	Original  Amount
+	Converted Amount
+}
+
 type Transaction struct {
 	Operation   string
+	Direction   Direction
+	Kind        Kind
 	Card        string
 	Status      string
 	Original    Amount
@@ -24,6 +35,7 @@ type Transaction struct {
 	Support     string
 	FromAccount string
 	ToAccount   string
+	Components  []Component `json:",omitempty"`
 	RawMessage  string
 }
 
@@ -41,9 +53,9 @@ type MAIBTemplate struct {
 
 func NewMAIBTemplate() *MAIBTemplate {
 	return &MAIBTemplate{
-		opRegex:     regexp.MustCompile(`^Op: (.+)`),
+		opRegex:     regexp.MustCompile(`^(?:Op|Операция|Opera[tțţ]iune): (.+)`),
 		fieldRegex:  regexp.MustCompile(`^([^:]+): (.*)$`),
-		amountRegex: regexp.MustCompile(`^([\d,]+)\s*(\w+)$`),
+		amountRegex: regexp.MustCompile(`^([\d,]+)\s*(\p{L}+)$`),
 	}
 }
 
@@ -55,6 +67,10 @@ func (t *MAIBTemplate) Match(content string) bool {
 	return t.opRegex.MatchString(content)
 }
 
+func (t *MAIBTemplate) Explain(content string) string {
+	return explainRegex(t.opRegex, content)
+}
+
 func (t *MAIBTemplate) Parse(content string) (*Transaction, error) {
 	lines := strings.Split(content, "\n")
 	tx := &Transaction{RawMessage: content}
@@ -67,14 +83,17 @@ func (t *MAIBTemplate) Parse(content string) (*Transaction, error) {
 
 		key := strings.TrimSpace(matches[1])
 		value := strings.TrimSpace(matches[2])
+		if name, ok := maibKeys[key]; ok {
+			key = name
+		}
 
 		switch key {
 		case "Op":
-			tx.Operation = value
+			tx.Operation = normalizeOperation(value)
 		case "Karta":
 			tx.Card = value
 		case "Status":
-			tx.Status = value
+			tx.Status = normalizeStatus(value)
 		case "Summa":
 			amount, currency, err := t.parseAmount(value)
 			if err != nil {
@@ -93,12 +112,42 @@ func (t *MAIBTemplate) Parse(content string) (*Transaction, error) {
 			tx.Address = value
 		case "Podderzhka":
 			tx.Support = value
+		case "Komissiya", "Keshbek":
+			amount, currency, err := t.parseAmount(value)
+			if err != nil {
+				return nil, err
+			}
+			kind := KindFee
+			if key == "Keshbek" {
+				kind = KindCashback
+			}
+			tx.Components = append(tx.Components, Component{Kind: kind, Original: Amount{Value: amount, Currency: currency}})
 		}
 	}
 
+	if op, ok := maibOperations[tx.Operation]; ok {
+		tx.Direction, tx.Kind = op.direction, op.kind
+	}
+
 	return tx, nil
 }
 
+// maibOperations lists the operations with a known direction. Any other
+// operation is left unknown, so it is reported instead of being imported
+// with a guessed sign.
+var maibOperations = map[string]struct {
+	direction Direction
+	kind      Kind
+}{
+	"Tovary i uslugi":   {DirectionDebit, KindPurchase},
+	"Snyatie nalichnyh": {DirectionDebit, KindATM},
+	"Komissiya":         {DirectionDebit, KindFee},
+	"Popolnenie":        {DirectionCredit, KindTopUp},
+	"Zarplata":          {DirectionCredit, KindSalary},
+	"Vozvrat":           {DirectionCredit, KindRefund},
+	"Otmena":            {DirectionReversal, KindPurchase},
+}
+
 func (t *MAIBTemplate) parseAmount(value string) (float64, string, error) {
 	matches := t.amountRegex.FindStringSubmatch(value)
 	if matches == nil {
@@ -110,7 +159,7 @@ func (t *MAIBTemplate) parseAmount(value string) (float64, string, error) {
 		return 0, "", err
 	}
 
-	return amount, matches[2], nil
+	return amount, normalizeCurrency(matches[2]), nil
 }
 
 func parseNumber(value string) (float64, error) {
@@ -136,6 +185,10 @@ func (t *EximTransactionTemplate) Match(content string) bool {
 	return t.regex.MatchString(content)
 }
 
+func (t *EximTransactionTemplate) Explain(content string) string {
+	return explainRegex(t.regex, content)
+}
+
 func (t *EximTransactionTemplate) Parse(content string) (*Transaction, error) {
 	matches := t.regex.FindStringSubmatch(content)
 	if matches == nil {
@@ -148,6 +201,8 @@ func (t *EximTransactionTemplate) Parse(content string) (*Transaction, error) {
 	}
 
 	return &Transaction{
+		Direction:   DirectionTransfer,
+		Kind:        KindTransfer,
 		DateTime:    matches[1],
 		FromAccount: matches[2],
 		ToAccount:   matches[3],
@@ -164,7 +219,7 @@ type DebitareTemplate struct {
 func NewDebitareTemplate() *DebitareTemplate {
 	return &DebitareTemplate{
 		// Example: Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii ..., Disponibil 38400.60 MDL
-		regex: regexp.MustCompile(`Debitare cont Card ([^,]+), Data ([^,]+), Suma ([\d.]+) (\w+), Detalii (.+?), Disponibil ([\d.]+) \w+$`),
+		regex: regexp.MustCompile(`Debitare cont Card ([^,]+), Data ([^,]+), Sum[aă] ([\d.]+) (\w+), Detalii (.+?), Disponibil ([\d.]+) \w+$`),
 	}
 }
 
@@ -176,6 +231,10 @@ func (t *DebitareTemplate) Match(content string) bool {
 	return t.regex.MatchString(content)
 }
 
+func (t *DebitareTemplate) Explain(content string) string {
+	return explainRegex(t.regex, content)
+}
+
 func (t *DebitareTemplate) Parse(content string) (*Transaction, error) {
 	matches := t.regex.FindStringSubmatch(content)
 	if matches == nil {
@@ -192,8 +251,15 @@ func (t *DebitareTemplate) Parse(content string) (*Transaction, error) {
 		return nil, err
 	}
 
+	kind := KindPurchase
+	if strings.Contains(strings.ToLower(matches[5]), "comision") {
+		kind = KindFee
+	}
+
 	return &Transaction{
 		Operation:  "Debitare",
+		Direction:  DirectionDebit,
+		Kind:       kind,
 		Card:       matches[1],
 		DateTime:   matches[2],
 		Original:   Amount{Value: amount, Currency: matches[4]},
@@ -210,7 +276,7 @@ type TranzactieReusitaTemplate struct {
 func NewTranzactieReusitaTemplate() *TranzactieReusitaTemplate {
 	return &TranzactieReusitaTemplate{
 		// Example: Tranzactie reusita, Data 13.04.2024 13:20:30, Card 9..7890, Suma 91.91 MDL, Locatie MAIB GROCERY STORE>CHISINAU, MDA, Disponibil 31200.80 MDL
-		regex: regexp.MustCompile(`Tranzactie reusita, Data ([^,]+), Card ([^,]+), Suma ([\d.]+) (\w+), Locatie ([^,]+, \w+), Disponibil ([\d.]+)`),
+		regex: regexp.MustCompile(`Tranzac[tțţ]ie reu[sșş]it[aă], Data ([^,]+), Card ([^,]+), Sum[aă] ([\d.]+) (\w+), Loca[tțţ]ie ([^,]+, \w+), Disponibil ([\d.]+)`),
 	}
 }
 
@@ -222,6 +288,10 @@ func (t *TranzactieReusitaTemplate) Match(content string) bool {
 	return t.regex.MatchString(content)
 }
 
+func (t *TranzactieReusitaTemplate) Explain(content string) string {
+	return explainRegex(t.regex, content)
+}
+
 func (t *TranzactieReusitaTemplate) Parse(content string) (*Transaction, error) {
 	matches := t.regex.FindStringSubmatch(content)
 	if matches == nil {
@@ -240,6 +310,8 @@ func (t *TranzactieReusitaTemplate) Parse(content string) (*Transaction, error)
 
 	return &Transaction{
 		Operation:  "Tranzactie reusita",
+		Direction:  DirectionDebit,
+		Kind:       KindPurchase,
 		DateTime:   matches[1],
 		Card:       matches[2],
 		Original:   Amount{Value: amount, Currency: matches[4]},
@@ -256,7 +328,7 @@ type SuplinireTemplate struct {
 func NewSuplinireTemplate() *SuplinireTemplate {
 	return &SuplinireTemplate{
 		// Example: Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 93719.33 MDL, Detalii Plata salariala, Disponibil 88700.25 MDL
-		regex: regexp.MustCompile(`Suplinire cont Card ([^,]+), Data ([^,]+), Suma ([\d.]+) (\w+), Detalii (.+?)(?:, Disponibil ([\d.]+) \w+)?$`),
+		regex: regexp.MustCompile(`Suplinire cont Card ([^,]+), Data ([^,]+), Sum[aă] ([\d.]+) (\w+), Detalii (.+?)(?:, Disponibil ([\d.]+) \w+)?$`),
 	}
 }
 
@@ -268,6 +340,10 @@ func (t *SuplinireTemplate) Match(content string) bool {
 	return t.regex.MatchString(content)
 }
 
+func (t *SuplinireTemplate) Explain(content string) string {
+	return explainRegex(t.regex, content)
+}
+
 func (t *SuplinireTemplate) Parse(content string) (*Transaction, error) {
 	matches := t.regex.FindStringSubmatch(content)
 	if matches == nil {
@@ -289,6 +365,8 @@ func (t *SuplinireTemplate) Parse(content string) (*Transaction, error) {
 
 	return &Transaction{
 		Operation:  "Suplinire",
+		Direction:  DirectionCredit,
+		Kind:       suplinireKind(matches[5]),
 		Card:       matches[1],
 		DateTime:   matches[2],
 		Original:   Amount{Value: amount, Currency: matches[4]},
@@ -298,13 +376,24 @@ func (t *SuplinireTemplate) Parse(content string) (*Transaction, error) {
 	}, nil
 }
 
+func suplinireKind(details string) Kind {
+	details = strings.ToLower(details)
+	switch {
+	case strings.Contains(details, "salari"):
+		return KindSalary
+	case strings.Contains(details, "rambursare"), strings.Contains(details, "returnare"):
+		return KindRefund
+	}
+	return KindTopUp
+}
+
 type Matcher struct {
-	templates      []Template
-	ignorePatterns []string
+	templates   []Template
+	ignoreRules []*IgnoreRule
 }
 
 func NewMatcher() *Matcher {
-	return &Matcher{
+	m := &Matcher{
 		templates: []Template{
 			NewMAIBTemplate(),
 			NewEximTransactionTemplate(),
@@ -312,46 +401,152 @@ func NewMatcher() *Matcher {
 			NewTranzactieReusitaTemplate(),
 			NewSuplinireTemplate(),
 		},
-		ignorePatterns: []string{
-			"Vas privetstvuet servis opoveshenia ot MAIB",
-			"Oper.: Ostatok",
-			"Autentificarea Dvs. in sistemul Eximbank Online a fost inregistrata la",
-			"Parola de unica folosinta pentru tranzactia cu ID-ul",
-			"OTP-ul pentru Plati din Exim Personal este",
-			"Va multumim ca ati ales serviciul Eximbank SMS Info.",
-			"Parola de Unica Folosinta (OTP) a Dvs. pentru logare este",
-			"Parola:",
-			"Parola Dvs.",
-			"Tranzactie esuata,",
-			"Tranzactia din",
-			"Anulare tranzactie",
-			"Acesta este momentul pe care il asteptai!",
-			"Vrei un card pentru copilul tau?",
-			"Refinanteaza creditele de consum de la alte",
-			"Profita acum! Credit PERSONAL sau MAGNIFIC",
-			"In data de",
-			"Cardul Eximbank",
-			"] Me:",
+		ignoreRules: []*IgnoreRule{
+			containsRule("Vas privetstvuet servis opoveshenia ot MAIB"),
+			containsRule("Вас приветствует сервис оповещения от MAIB"),
+			containsRule("Oper.: Ostatok"),
+			containsRule("Опер.: Остаток"),
+			containsRule("Autentificarea Dvs. in sistemul Eximbank Online a fost inregistrata la"),
+			containsRule("Parola de unica folosinta pentru tranzactia cu ID-ul"),
+			containsRule("OTP-ul pentru Plati din Exim Personal este"),
+			containsRule("Va multumim ca ati ales serviciul Eximbank SMS Info."),
+			containsRule("Parola de Unica Folosinta (OTP) a Dvs. pentru logare este"),
+			regexRule(`^Parola:`),
+			containsRule("Parola Dvs."),
+			containsRule("Tranzactie esuata,"),
+			// Fallback for transfer notices the EximTransaction template
+			// does not parse
+			{Contains: "Tranzactia din", Priority: -1},
+			containsRule("Anulare tranzactie"),
+			containsRule("Acesta este momentul pe care il asteptai!"),
+			containsRule("Vrei un card pentru copilul tau?"),
+			containsRule("Refinanteaza creditele de consum de la alte"),
+			containsRule("Profita acum! Credit PERSONAL sau MAGNIFIC"),
+			regexRule(`^In data de`),
+			containsRule("Cardul Eximbank"),
+			containsRule("] Me:"),
 		},
 	}
+	m.AddTemplates(bankTemplates()...)
+	m.AddIgnoreRules(bankIgnoreRules()...)
+	return m
 }
 
-func (m *Matcher) ShouldIgnore(content string) bool {
-	for _, pattern := range m.ignorePatterns {
-		if strings.Contains(content, pattern) {
+// Prioritized is implemented by templates with a priority other than 0.
+type Prioritized interface {
+	Priority() int
+}
+
+func priorityOf(tmpl Template) int {
+	if p, ok := tmpl.(Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// Match is the outcome of checking a message against every template and
+// ignore rule. The candidate with the highest priority wins; on a tie a
+// template wins over an ignore rule and earlier entries over later ones,
+// and the match is reported as ambiguous.
+type Match struct {
+	Template    Template
+	Ignore      *IgnoreRule
+	Templates   []Template
+	IgnoreRules []*IgnoreRule
+	Ambiguous   bool
+}
+
+func (m *Match) Ignored() bool {
+	return m.Ignore != nil
+}
+
+// Candidates describes every matching template and ignore rule.
+func (m *Match) Candidates() []string {
+	var candidates []string
+	for _, tmpl := range m.Templates {
+		candidates = append(candidates, fmt.Sprintf("template %s (priority %d)", tmpl.Name(), priorityOf(tmpl)))
+	}
+	for _, rule := range m.IgnoreRules {
+		candidates = append(candidates, fmt.Sprintf("ignore %s (priority %d)", rule, rule.Priority))
+	}
+	return candidates
+}
+
+func (m *Matcher) Classify(sender, content string) *Match {
+	match := &Match{}
+	best, tied := 0, 0
+	consider := func(priority int) bool {
+		switch {
+		case tied == 0 || priority > best:
+			best, tied = priority, 1
 			return true
+		case priority == best:
+			tied++
+		}
+		return false
+	}
+
+	for _, tmpl := range m.templates {
+		if !tmpl.Match(content) {
+			continue
+		}
+		match.Templates = append(match.Templates, tmpl)
+		if consider(priorityOf(tmpl)) {
+			match.Template = tmpl
+		}
+	}
+	for _, rule := range m.ignoreRules {
+		if !rule.Matches(sender, content) {
+			continue
+		}
+		match.IgnoreRules = append(match.IgnoreRules, rule)
+		if consider(rule.Priority) {
+			match.Template, match.Ignore = nil, rule
+		}
+	}
+
+	match.Ambiguous = tied > 1
+	return match
+}
+
+// ShouldIgnore reports whether any ignore rule without a sender matches
+// content, whatever its priority.
+func (m *Matcher) ShouldIgnore(content string) bool {
+	return m.IgnoredBy(content) != ""
+}
+
+// IgnoredBy returns the first ignore rule matching content, or "" if none.
+func (m *Matcher) IgnoredBy(content string) string {
+	for _, rule := range m.ignoreRules {
+		if rule.Matches("", content) {
+			return rule.String()
 		}
 	}
-	return false
+	return ""
+}
+
+func (m *Matcher) AddTemplates(templates ...Template) {
+	m.templates = append(m.templates, templates...)
+}
+
+func (m *Matcher) AddIgnoreRules(rules ...*IgnoreRule) {
+	m.ignoreRules = append(m.ignoreRules, rules...)
+}
+
+func (m *Matcher) Templates() []Template {
+	return m.templates
 }
 
+// FindTemplate returns the highest-priority template matching content,
+// regardless of ignore rules.
 func (m *Matcher) FindTemplate(content string) Template {
+	var found Template
 	for _, tmpl := range m.templates {
-		if tmpl.Match(content) {
-			return tmpl
+		if tmpl.Match(content) && (found == nil || priorityOf(tmpl) > priorityOf(found)) {
+			found = tmpl
 		}
 	}
-	return nil
+	return found
 }
 
 func (m *Matcher) Parse(content string) (*Transaction, error) {
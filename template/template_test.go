@@ -139,6 +139,72 @@ Podderzhka: +12025551234`
 	}
 }
 
+func TestMAIBTemplate_Parse_LocalizedOperations(t *testing.T) {
+	tests := []struct {
+		op, status, wantOp, wantStatus string
+		wantDirection                  Direction
+	}{
+		{"Товары и услуги", "Одобрена", "Tovary i uslugi", "Odobrena", DirectionDebit},
+		{"Bunuri și servicii", "Aprobată", "Tovary i uslugi", "Odobrena", DirectionDebit},
+		{"Bunuri si servicii", "Aprobata", "Tovary i uslugi", "Odobrena", DirectionDebit},
+		{"Снятие наличных", "Отклонена", "Snyatie nalichnyh", "Decline", DirectionDebit},
+		{"Retragere numerar", "Respinsă", "Snyatie nalichnyh", "Decline", DirectionDebit},
+		{"Возврат", "Одобрена", "Vozvrat", "Odobrena", DirectionCredit},
+		{"Отмена", "Одобрена", "Otmena", "Odobrena", DirectionReversal},
+		{"Unknown op", "Other", "Unknown op", "Other", DirectionUnknown},
+	}
+
+	tmpl := NewMAIBTemplate()
+	for _, tt := range tests {
+		content := "Операция: " + tt.op + "\nСтатус: " + tt.status + "\nСумма: 10 лей"
+		if !tmpl.Match(content) {
+			t.Fatalf("Match(%q) = false, want true", content)
+		}
+		tx, err := tmpl.Parse(content)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if tx.Operation != tt.wantOp {
+			t.Errorf("Operation = %q, want %q", tx.Operation, tt.wantOp)
+		}
+		if tx.Status != tt.wantStatus {
+			t.Errorf("Status = %q, want %q", tx.Status, tt.wantStatus)
+		}
+		if tx.Direction != tt.wantDirection {
+			t.Errorf("Direction = %q, want %q", tx.Direction, tt.wantDirection)
+		}
+		if tx.Original.Currency != "MDL" {
+			t.Errorf("Currency = %q, want MDL", tx.Original.Currency)
+		}
+	}
+}
+
+func TestTemplates_DirectionAndKind(t *testing.T) {
+	tests := []struct {
+		tmpl          Template
+		content       string
+		wantDirection Direction
+		wantKind      Kind
+	}{
+		{NewDebitareTemplate(), "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 1.00 MDL, Detalii Comision SMS, Disponibil 38400.60 MDL", DirectionDebit, KindFee},
+		{NewDebitareTemplate(), "Debitare cont Card 9..7890, Data 08.04.2024 09:27:01, Suma 9.65 MDL, Detalii LINELLA, Disponibil 38400.60 MDL", DirectionDebit, KindPurchase},
+		{NewSuplinireTemplate(), "Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 10.00 MDL, Detalii Plata salariala", DirectionCredit, KindSalary},
+		{NewSuplinireTemplate(), "Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 10.00 MDL, Detalii Rambursare comerciant", DirectionCredit, KindRefund},
+		{NewSuplinireTemplate(), "Suplinire cont Card 9..7890, Data 29.04.2024 16:18:01, Suma 10.00 MDL, Detalii Transfer", DirectionCredit, KindTopUp},
+		{NewEximTransactionTemplate(), "Tranzactia din 01/05/2024 din contul 123 in contul 456 in suma de 10.00 MDL a fost executata", DirectionTransfer, KindTransfer},
+	}
+
+	for _, tt := range tests {
+		tx, err := tt.tmpl.Parse(tt.content)
+		if err != nil {
+			t.Fatalf("%s Parse() error = %v", tt.tmpl.Name(), err)
+		}
+		if tx.Direction != tt.wantDirection || tx.Kind != tt.wantKind {
+			t.Errorf("%s: Direction, Kind = %q, %q, want %q, %q", tt.tmpl.Name(), tx.Direction, tx.Kind, tt.wantDirection, tt.wantKind)
+		}
+	}
+}
+
 func TestEximTransactionTemplate_Match_Valid(t *testing.T) {
 	content := `Tranzactia din 29/05/2023 din contul ACC1234567MD4 in contul MD99XX000000011111111111 in suma de 5000.00 MDL a fost Executata`
 
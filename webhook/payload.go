@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+// Field names used by common SMS forwarders: our own format, Android
+// "SMS Forwarder" apps (from/text/sentStamp), iOS Shortcuts and generic
+// gateways (sender/body/message, address/msg).
+var (
+	senderKeys    = []string{"sender", "from", "address", "phone", "number"}
+	contentKeys   = []string{"content", "text", "body", "message", "msg"}
+	timestampKeys = []string{"timestamp", "sentStamp", "receivedStamp", "date", "time"}
+)
+
+var ErrEmptyPayload = errors.New("payload contains no messages")
+
+// ParsePayload accepts a single JSON object, an array of objects or an
+// object wrapping them under "messages".
+func ParsePayload(body []byte, now time.Time) ([]*message.Message, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, ErrEmptyPayload
+	}
+
+	var objects []map[string]interface{}
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &objects); err != nil {
+			return nil, fmt.Errorf("invalid JSON payload: %w", err)
+		}
+	} else {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(trimmed, &obj); err != nil {
+			return nil, fmt.Errorf("invalid JSON payload: %w", err)
+		}
+		if nested, ok := obj["messages"].([]interface{}); ok {
+			for _, item := range nested {
+				if m, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, m)
+				}
+			}
+		} else {
+			objects = append(objects, obj)
+		}
+	}
+
+	if len(objects) == 0 {
+		return nil, ErrEmptyPayload
+	}
+
+	messages := make([]*message.Message, 0, len(objects))
+	for i, obj := range objects {
+		msg, err := parseObject(obj, now)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func parseObject(obj map[string]interface{}, now time.Time) (*message.Message, error) {
+	sender := firstString(obj, senderKeys)
+	if sender == "" {
+		return nil, errors.New("missing sender")
+	}
+
+	content := firstString(obj, contentKeys)
+	if content == "" {
+		return nil, errors.New("missing content")
+	}
+
+	timestamp := now.UTC()
+	for _, key := range timestampKeys {
+		value, ok := obj[key]
+		if !ok {
+			continue
+		}
+		parsed, err := parseTimestamp(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		timestamp = parsed
+		break
+	}
+
+	return &message.Message{
+		Timestamp: timestamp,
+		Sender:    strings.TrimSpace(sender),
+		Content:   content,
+	}, nil
+}
+
+func firstString(obj map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if s, ok := obj[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// parseTimestamp accepts RFC 3339 strings and Unix timestamps in seconds or
+// milliseconds, either as numbers or numeric strings.
+func parseTimestamp(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		return unixTime(int64(v)), nil
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return unixTime(n), nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func unixTime(n int64) time.Time {
+	// Anything past year 33658 in seconds is really milliseconds
+	if n > 1e12 {
+		return time.UnixMilli(n).UTC()
+	}
+	return time.Unix(n, 0).UTC()
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func TestParsePayload_NativeShape(t *testing.T) {
+	body := `{"sender": "102", "content": "Op: Tovary i uslugi", "timestamp": "2026-01-10T10:00:00+02:00"}`
+
+	messages, err := ParsePayload([]byte(body), testNow)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Sender != "102" || messages[0].Content != "Op: Tovary i uslugi" {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+	expected := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+	if !messages[0].Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %v, got %v", expected, messages[0].Timestamp)
+	}
+}
+
+func TestParsePayload_SMSForwarderShape(t *testing.T) {
+	body := `{"from": "EXIMBANK", "text": "Debitare cont", "sentStamp": 1768039200000, "receivedStamp": 1768039201000, "sim": "SIM1"}`
+
+	messages, err := ParsePayload([]byte(body), testNow)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	expected := time.UnixMilli(1768039200000).UTC()
+	if !messages[0].Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %v, got %v", expected, messages[0].Timestamp)
+	}
+	if messages[0].Sender != "EXIMBANK" {
+		t.Errorf("expected sender 'EXIMBANK', got %q", messages[0].Sender)
+	}
+}
+
+func TestParsePayload_ShortcutsShapeWithoutTimestamp(t *testing.T) {
+	body := `{"sender": "102", "message": "Op: Tovary i uslugi"}`
+
+	messages, err := ParsePayload([]byte(body), testNow)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if !messages[0].Timestamp.Equal(testNow) {
+		t.Errorf("expected receive time as timestamp, got %v", messages[0].Timestamp)
+	}
+}
+
+func TestParsePayload_ArrayAndWrapped(t *testing.T) {
+	array := `[{"from": "102", "body": "one", "date": "1768039200"}, {"address": "102", "msg": "two"}]`
+	messages, err := ParsePayload([]byte(array), testNow)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if !messages[0].Timestamp.Equal(time.Unix(1768039200, 0)) {
+		t.Errorf("unexpected timestamp %v", messages[0].Timestamp)
+	}
+
+	wrapped := `{"messages": [{"sender": "102", "content": "one"}]}`
+	messages, err = ParsePayload([]byte(wrapped), testNow)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(messages))
+	}
+}
+
+func TestParsePayload_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"empty", ""},
+		{"empty array", "[]"},
+		{"invalid json", "{"},
+		{"missing sender", `{"content": "x"}`},
+		{"missing content", `{"sender": "102"}`},
+		{"invalid timestamp", `{"sender": "102", "content": "x", "timestamp": "yesterday"}`},
+		{"unsupported timestamp type", `{"sender": "102", "content": "x", "timestamp": true}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParsePayload([]byte(tc.body), testNow); err == nil {
+				t.Error("ParsePayload() should return error")
+			}
+		})
+	}
+}
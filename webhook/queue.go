@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+const queueKey = "webhook_queue"
+
+// Queued messages are dropped this long after they were received. Syncs
+// skip the ones already imported, so they only need to outlive the gap
+// until the next sync.
+const queueRetention = 30 * 24 * time.Hour
+
+type queuedMessage struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Sender     string    `json:"sender"`
+	Content    string    `json:"content"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Queue keeps received messages in the data file until the next sync reads
+// them back as a message source.
+type Queue struct {
+	filePath string
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func NewQueue(filePath string) *Queue {
+	return &Queue{filePath: filePath, now: time.Now}
+}
+
+func (q *Queue) Enqueue(msg *message.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var queued []queuedMessage
+	if err := datastore.ReadSection(q.filePath, queueKey, &queued); err != nil {
+		return err
+	}
+
+	now := q.now().UTC()
+	kept := queued[:0]
+	for _, existing := range queued {
+		if existing.Timestamp.Equal(msg.Timestamp) && existing.Sender == msg.Sender && existing.Content == msg.Content {
+			return nil
+		}
+		if now.Sub(existing.ReceivedAt) <= queueRetention {
+			kept = append(kept, existing)
+		}
+	}
+
+	queued = append(kept, queuedMessage{
+		Timestamp:  msg.Timestamp,
+		Sender:     msg.Sender,
+		Content:    msg.Content,
+		ReceivedAt: now,
+	})
+
+	return datastore.WriteSection(q.filePath, queueKey, queued)
+}
+
+func (q *Queue) Messages() ([]*message.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var queued []queuedMessage
+	if err := datastore.ReadSection(q.filePath, queueKey, &queued); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*message.Message, len(queued))
+	for i, qm := range queued {
+		messages[i] = &message.Message{
+			Timestamp: qm.Timestamp,
+			Sender:    qm.Sender,
+			Content:   qm.Content,
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+)
+
+func TestQueue_EnqueueAndMessages(t *testing.T) {
+	queue := NewQueue(filepath.Join(t.TempDir(), "data.json"))
+
+	msg := &message.Message{
+		Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC),
+		Sender:    "102",
+		Content:   "Op: Tovary i uslugi",
+	}
+
+	if err := queue.Enqueue(msg); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	// Duplicate deliveries from the forwarder are stored once
+	if err := queue.Enqueue(msg); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	messages, err := queue.Messages()
+	if err != nil {
+		t.Fatalf("Messages() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Content != msg.Content || !messages[0].Timestamp.Equal(msg.Timestamp) {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+}
+
+func TestQueue_Messages_Empty(t *testing.T) {
+	queue := NewQueue(filepath.Join(t.TempDir(), "data.json"))
+
+	messages, err := queue.Messages()
+	if err != nil {
+		t.Fatalf("Messages() error = %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected 0 messages, got %d", len(messages))
+	}
+}
+
+func TestQueue_DropsOldMessages(t *testing.T) {
+	queue := NewQueue(filepath.Join(t.TempDir(), "data.json"))
+	received := time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return received }
+
+	old := &message.Message{Timestamp: received, Sender: "102", Content: "old"}
+	if err := queue.Enqueue(old); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	queue.now = func() time.Time { return received.Add(queueRetention + time.Hour) }
+	fresh := &message.Message{Timestamp: received.Add(queueRetention), Sender: "102", Content: "fresh"}
+	if err := queue.Enqueue(fresh); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	messages, err := queue.Messages()
+	if err != nil {
+		t.Fatalf("Messages() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].Content != "fresh" {
+		t.Errorf("expected only the fresh message, got %v", messages)
+	}
+}
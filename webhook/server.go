@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/logging"
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const maxBodySize = 1 << 20
+
+type HandlerFunc func(msg *message.Message) error
+
+type Result struct {
+	Accepted      int `json:"accepted"`
+	Ignored       int `json:"ignored"`
+	Unmatched     int `json:"unmatched"`
+	UnknownSender int `json:"unknown_sender"`
+}
+
+type Server struct {
+	// AllowQueryToken also accepts the token as a "token" query parameter,
+	// for forwarder apps that can only set a URL. The token then ends up in
+	// access logs and proxy histories.
+	AllowQueryToken bool
+
+	token   []byte
+	senders map[string]bool
+	matcher *template.Matcher
+	handle  HandlerFunc
+	now     func() time.Time
+}
+
+func NewServer(token string, senders []string, matcher *template.Matcher, handle HandlerFunc) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("webhook token must not be empty")
+	}
+
+	allowed := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		allowed[s] = true
+	}
+
+	return &Server{
+		token:   []byte(token),
+		senders: allowed,
+		matcher: matcher,
+		handle:  handle,
+		now:     time.Now,
+	}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	logger := slog.With("request_id", logging.NewRunID())
+
+	if !s.authorized(r) {
+		logger.Warn("rejected webhook request", "reason", "unauthorized", "remote", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+
+	messages, err := ParsePayload(body, s.now())
+	if err != nil {
+		logger.Warn("rejected webhook request", "reason", "invalid payload", "error", err)
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var result Result
+	for _, msg := range messages {
+		if !s.senders[msg.Sender] {
+			result.UnknownSender++
+			continue
+		}
+
+		// Every message is handed on, like the polling sources do, so that
+		// the sync pipeline classifies it and unmatched ones are still found
+		// by missing_templates or a template added later. The counts only
+		// tell the sender what happens to them.
+		match := s.matcher.Classify(msg.Sender, msg.Content)
+		switch {
+		case match.Ignored():
+			result.Ignored++
+		case match.Template == nil:
+			result.Unmatched++
+		}
+		if err := s.handle(msg); err != nil {
+			logger.Error("failed to handle webhook message", "sender", msg.Sender, "error", err)
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		result.Accepted++
+	}
+
+	logger.Info("webhook request handled", "accepted", result.Accepted, "ignored", result.Ignored,
+		"unmatched", result.Unmatched, "unknown_sender", result.UnknownSender)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// authorized accepts the token as a bearer token or an X-Webhook-Token
+// header, and as a "token" query parameter only when AllowQueryToken is set.
+func (s *Server) authorized(r *http.Request) bool {
+	candidates := []string{
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+		r.Header.Get("X-Webhook-Token"),
+	}
+	if s.AllowQueryToken {
+		candidates = append(candidates, r.URL.Query().Get("token"))
+	}
+
+	for _, c := range candidates {
+		if c != "" && subtle.ConstantTimeCompare([]byte(c), s.token) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const maibMessage = `Op: Tovary i uslugi\nKarta: *1234\nStatus: Odobrena\nSumma: 34 MDL`
+
+func newTestServer(t *testing.T, handle HandlerFunc) *Server {
+	t.Helper()
+	server, err := NewServer("secret", []string{"102"}, template.NewMatcher(), handle)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return server
+}
+
+func TestNewServer_EmptyToken(t *testing.T) {
+	_, err := NewServer("", []string{"102"}, template.NewMatcher(), func(*message.Message) error { return nil })
+	if err == nil {
+		t.Error("NewServer() should reject an empty token")
+	}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server := newTestServer(t, func(*message.Message) error { return nil })
+	body := `{"sender": "102", "content": "` + maibMessage + `"}`
+
+	testCases := []struct {
+		name   string
+		setup  func(r *http.Request)
+		target string
+		status int
+	}{
+		{"no token", func(r *http.Request) {}, "/", http.StatusUnauthorized},
+		{"wrong token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, "/", http.StatusUnauthorized},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, "/", http.StatusOK},
+		{"header", func(r *http.Request) { r.Header.Set("X-Webhook-Token", "secret") }, "/", http.StatusOK},
+		{"query not allowed", func(r *http.Request) {}, "/?token=secret", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(body))
+			tc.setup(req)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestServer_QueryTokenOptIn(t *testing.T) {
+	server := newTestServer(t, func(*message.Message) error { return nil })
+	server.AllowQueryToken = true
+
+	body := `{"sender": "102", "content": "` + maibMessage + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/?token=secret", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 with AllowQueryToken, got %d", rec.Code)
+	}
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	server := newTestServer(t, func(*message.Message) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Webhook-Token", "secret")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestServer_ClassifiesMessages(t *testing.T) {
+	var handled []*message.Message
+	server := newTestServer(t, func(msg *message.Message) error {
+		handled = append(handled, msg)
+		return nil
+	})
+
+	body := `[
+		{"sender": "102", "content": "` + maibMessage + `"},
+		{"sender": "102", "content": "Parola: 123456"},
+		{"sender": "102", "content": "Hello there"},
+		{"sender": "SPAM", "content": "` + maibMessage + `"}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Token", "secret")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	expected := Result{Accepted: 3, Ignored: 1, Unmatched: 1, UnknownSender: 1}
+	if result != expected {
+		t.Errorf("expected %+v, got %+v", expected, result)
+	}
+	// Unmatched messages are kept for missing_templates
+	if len(handled) != 3 || handled[2].Content != "Hello there" {
+		t.Errorf("expected the three messages from 102, got %v", handled)
+	}
+}
+
+func TestServer_BadPayload(t *testing.T) {
+	server := newTestServer(t, func(*message.Message) error { return nil })
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{"))
+	req.Header.Set("X-Webhook-Token", "secret")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestServer_HandlerError(t *testing.T) {
+	server := newTestServer(t, func(*message.Message) error { return errors.New("sync failed") })
+
+	body := `{"sender": "102", "content": "` + maibMessage + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Webhook-Token", "secret")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+)
+
+// Result is one output of Stream: the value fn returned for an item, or its
+// error.
+type Result[R any] struct {
+	Value R
+	Err   error
+}
+
+// Stream calls fn for the items read from in, at most p's workers at a
+// time, and sends the results to the returned channel in input order. At
+// most the pool size of results are held back waiting for an earlier item.
+// The channel is closed once in is closed and drained or ctx is done.
+func Stream[T, R any](ctx context.Context, p *Pool, in <-chan T, fn func(context.Context, T) (R, error)) <-chan Result[R] {
+	out := make(chan Result[R])
+	pending := make(chan chan Result[R], p.workers)
+
+	go func() {
+		defer close(pending)
+		for {
+			var item T
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+
+			done := make(chan Result[R], 1)
+			select {
+			case <-ctx.Done():
+				return
+			case pending <- done:
+			}
+			if !p.acquire(ctx) {
+				done <- Result[R]{Err: ctx.Err()}
+				return
+			}
+			go func() {
+				defer p.release()
+				value, err := fn(ctx, item)
+				done <- Result[R]{Value: value, Err: err}
+			}()
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		for done := range pending {
+			result := <-done
+			select {
+			case <-ctx.Done():
+				// Let the remaining calls finish without a reader
+				for range pending {
+				}
+				return
+			case out <- result:
+			}
+		}
+	}()
+
+	return out
+}
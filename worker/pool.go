@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"context"
 	"sync"
 )
 
@@ -42,3 +43,20 @@ func (p *Pool) Map(count int, fn func(i int)) {
 	}
 	p.Wait()
 }
+
+// acquire takes a worker slot, or reports false once ctx is done.
+func (p *Pool) acquire(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case p.sem <- struct{}{}:
+		return true
+	}
+}
+
+func (p *Pool) release() {
+	<-p.sem
+}
@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -111,3 +113,77 @@ func TestPool_Map(t *testing.T) {
 		}
 	}
 }
+
+func double(_ context.Context, n int) (int, error) {
+	// Later items finish first, so ordering is not a side effect of timing
+	time.Sleep(time.Duration(10-n) * time.Millisecond)
+	return n * 2, nil
+}
+
+func TestStream_OrderedAndBounded(t *testing.T) {
+	pool := NewPool(3)
+	in := make(chan int)
+	var current, max int64
+
+	out := Stream(context.Background(), pool, in, func(ctx context.Context, n int) (int, error) {
+		c := atomic.AddInt64(&current, 1)
+		for {
+			m := atomic.LoadInt64(&max)
+			if c <= m || atomic.CompareAndSwapInt64(&max, m, c) {
+				break
+			}
+		}
+		defer atomic.AddInt64(&current, -1)
+		if n == 4 {
+			return 0, errors.New("four")
+		}
+		return double(ctx, n)
+	})
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	i := 0
+	for result := range out {
+		if i == 4 {
+			if result.Err == nil {
+				t.Error("result 4 should carry the error")
+			}
+		} else if result.Err != nil || result.Value != i*2 {
+			t.Errorf("result %d = %+v, expected %d", i, result, i*2)
+		}
+		i++
+	}
+	if i != 10 {
+		t.Errorf("got %d results, expected 10", i)
+	}
+	if m := atomic.LoadInt64(&max); m > 3 {
+		t.Errorf("max concurrent should not exceed 3, got %d", m)
+	}
+}
+
+func TestStream_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+
+	out := Stream(ctx, NewPool(2), in, double)
+	in <- 1
+	if result := <-out; result.Value != 2 {
+		t.Errorf("first result = %+v, expected 2", result)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			for range out {
+			}
+		}
+	case <-time.After(time.Second):
+		t.Error("Stream() output was not closed after cancel")
+	}
+}
@@ -6,41 +6,81 @@ import (
 	"strings"
 
 	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
 	"github.com/apmyp/ynab_importer_go/template"
 )
 
+const (
+	defaultAccountType = "checking"
+	defaultAccountName = "Card {last4}"
+)
+
+// AccountOptions controls how accounts for new cards are found and created.
+// The zero value creates "checking" accounts named "Card 1234" without
+// asking.
+type AccountOptions struct {
+	Type         string
+	NameTemplate string
+	ExactMatch   bool
+	// Confirm is asked before an account is created; a card whose account
+	// is declined stays unmapped.
+	Confirm func(name, accountType string) bool
+}
+
 type AccountManager struct {
 	client     YNABClient
+	options    AccountOptions
 	last4Regex *regexp.Regexp
 }
 
-func NewAccountManager(client YNABClient) *AccountManager {
+func NewAccountManager(client YNABClient, options AccountOptions) *AccountManager {
+	if options.Type == "" {
+		options.Type = defaultAccountType
+	}
+	if options.NameTemplate == "" {
+		options.NameTemplate = defaultAccountName
+	}
 	return &AccountManager{
 		client:     client,
+		options:    options,
 		last4Regex: regexp.MustCompile(`\d{4}$`),
 	}
 }
 
+// AccountName renders the name template for a card.
+func (am *AccountManager) AccountName(sender, last4 string) string {
+	return strings.NewReplacer("{last4}", last4, "{sender}", sender).Replace(am.options.NameTemplate)
+}
+
+// EnsureAccounts returns the mappings with an account for every card in
+// transactions, looking up or creating YNAB accounts for cards without
+// one and for configured entries that have no account ID yet. messages
+// are the transactions' messages and scope cards to their senders; they
+// may be nil.
 func (am *AccountManager) EnsureAccounts(
 	budgetID string,
 	existingAccounts []config.YNABAccount,
+	messages []*message.Message,
 	transactions []*template.Transaction,
 ) ([]config.YNABAccount, error) {
-	accountMap := make(map[string]string)
-	for _, acc := range existingAccounts {
-		accountMap[acc.Last4] = acc.YNABAccountID
-	}
+	result := make([]config.YNABAccount, len(existingAccounts))
+	copy(result, existingAccounts)
 
-	uniqueLast4s := am.extractUniqueLast4s(transactions)
+	var pending []int
+	for i, acc := range result {
+		if acc.YNABAccountID == "" {
+			pending = append(pending, i)
+		}
+	}
 
-	var unmappedLast4s []string
-	for _, last4 := range uniqueLast4s {
-		if _, exists := accountMap[last4]; !exists {
-			unmappedLast4s = append(unmappedLast4s, last4)
+	var unmapped []cardKey
+	for _, card := range am.extractCards(messages, transactions) {
+		if findMapping(result, card) < 0 {
+			unmapped = append(unmapped, card)
 		}
 	}
 
-	if len(unmappedLast4s) == 0 {
+	if len(pending) == 0 && len(unmapped) == 0 {
 		return existingAccounts, nil
 	}
 
@@ -48,68 +88,140 @@ func (am *AccountManager) EnsureAccounts(
 	if err != nil {
 		return nil, fmt.Errorf("failed to get YNAB accounts: %w", err)
 	}
+	accounts := resp.Data.Accounts
 
-	result := make([]config.YNABAccount, len(existingAccounts))
-	copy(result, existingAccounts)
+	for _, i := range pending {
+		acc := &result[i]
+		name := acc.Name
+		if name == "" {
+			name = am.AccountName(acc.Sender, acc.Last4)
+		}
+		accountType := acc.Type
+		if accountType == "" {
+			accountType = am.options.Type
+		}
 
-	for _, last4 := range unmappedLast4s {
-		var foundAccount *Account
-		for i := range resp.Data.Accounts {
-			acc := &resp.Data.Accounts[i]
-			if acc.Closed || acc.Deleted {
-				continue
-			}
-			if strings.Contains(acc.Name, last4) {
-				foundAccount = acc
-				break
-			}
-		}
-
-		if foundAccount != nil {
-			result = append(result, config.YNABAccount{
-				YNABAccountID: foundAccount.ID,
-				Last4:         last4,
-			})
-		} else {
-			payload := CreateAccountPayload{
-				Name:    fmt.Sprintf("Card %s", last4),
-				Type:    "checking",
-				Balance: 0,
-			}
-
-			createResp, err := am.client.CreateAccount(budgetID, payload)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create account for card %s: %w", last4, err)
-			}
-
-			result = append(result, config.YNABAccount{
-				YNABAccountID: createResp.Data.Account.ID,
-				Last4:         last4,
-			})
+		id, err := am.findOrCreate(budgetID, &accounts, name, accountType, acc.Last4)
+		if err != nil {
+			return nil, err
+		}
+		acc.YNABAccountID = id
+	}
+
+	// Mappings are scoped to the card's sender, so another bank's card with
+	// the same digits gets its own, even if both share an account by name
+	for _, card := range unmapped {
+		id, err := am.findOrCreate(budgetID, &accounts, am.AccountName(card.sender, card.last4), am.options.Type, card.last4)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			result = append(result, config.YNABAccount{YNABAccountID: id, Last4: card.last4, Sender: card.sender})
 		}
 	}
 
 	return result, nil
 }
 
-func (am *AccountManager) extractUniqueLast4s(transactions []*template.Transaction) []string {
-	last4Set := make(map[string]bool)
-	var result []string
+// findOrCreate returns the ID of an open account matching the card, or of a
+// newly created one, which is added to accounts. It returns "" if creating
+// the account was declined.
+func (am *AccountManager) findOrCreate(budgetID string, accounts *[]Account, name, accountType, last4 string) (string, error) {
+	if acc := am.findAccount(*accounts, name, last4); acc != nil {
+		return acc.ID, nil
+	}
+
+	if am.options.Confirm != nil && !am.options.Confirm(name, accountType) {
+		return "", nil
+	}
 
-	for _, tx := range transactions {
+	createResp, err := am.client.CreateAccount(budgetID, CreateAccountPayload{
+		Name:    name,
+		Type:    accountType,
+		Balance: 0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create account for card %s: %w", last4, err)
+	}
+	*accounts = append(*accounts, createResp.Data.Account)
+	return createResp.Data.Account.ID, nil
+}
+
+// findAccount matches an account named exactly name, or, unless ExactMatch
+// is set, one whose name contains last4 as a separate number, so that card
+// 1234 does not pick "Savings 12345".
+func (am *AccountManager) findAccount(accounts []Account, name, last4 string) *Account {
+	last4InName := regexp.MustCompile(`(?:^|\D)` + regexp.QuoteMeta(last4) + `(?:\D|$)`)
+
+	var fallback *Account
+	for i := range accounts {
+		acc := &accounts[i]
+		if acc.Closed || acc.Deleted {
+			continue
+		}
+		if acc.Name == name {
+			return acc
+		}
+		if fallback == nil && !am.options.ExactMatch && last4InName.MatchString(acc.Name) {
+			fallback = acc
+		}
+	}
+	return fallback
+}
+
+// findMapping returns the index of the mapping for card, preferring one
+// scoped to the card's sender, or -1.
+func findMapping(accounts []config.YNABAccount, card cardKey) int {
+	unscoped := -1
+	for i, acc := range accounts {
+		if !hasCard(acc, card.last4) {
+			continue
+		}
+		if acc.Sender != "" && strings.EqualFold(acc.Sender, card.sender) {
+			return i
+		}
+		if acc.Sender == "" && unscoped < 0 {
+			unscoped = i
+		}
+	}
+	return unscoped
+}
+
+func hasCard(acc config.YNABAccount, last4 string) bool {
+	if acc.Last4 == last4 {
+		return true
+	}
+	for _, card := range acc.Cards {
+		if card == last4 {
+			return true
+		}
+	}
+	return false
+}
+
+func (am *AccountManager) extractCards(messages []*message.Message, transactions []*template.Transaction) []cardKey {
+	seen := make(map[cardKey]bool)
+	var result []cardKey
+
+	for i, tx := range transactions {
 		if tx.Card == "" {
 			continue
 		}
 
-		matches := am.last4Regex.FindString(tx.Card)
-		if matches == "" {
+		last4 := am.last4Regex.FindString(tx.Card)
+		if last4 == "" {
 			continue
 		}
 
-		last4 := matches
-		if !last4Set[last4] {
-			last4Set[last4] = true
-			result = append(result, last4)
+		var sender string
+		if i < len(messages) {
+			sender = messages[i].Sender
+		}
+
+		key := cardKey{sender, last4}
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, key)
 		}
 	}
 
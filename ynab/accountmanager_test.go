@@ -6,12 +6,13 @@ import (
 	"testing"
 
 	"github.com/apmyp/ynab_importer_go/config"
+	"github.com/apmyp/ynab_importer_go/message"
 	"github.com/apmyp/ynab_importer_go/template"
 )
 
 func TestAccountManager_EnsureAccounts_AllAccountsExist(t *testing.T) {
 	client := &mockClient{}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{
 		{YNABAccountID: "acc-1", Last4: "1234"},
@@ -23,7 +24,7 @@ func TestAccountManager_EnsureAccounts_AllAccountsExist(t *testing.T) {
 		{Card: "*5678"},
 	}
 
-	result, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	result, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err != nil {
 		t.Fatalf("EnsureAccounts() error = %v", err)
 	}
@@ -38,7 +39,8 @@ func TestAccountManager_EnsureAccounts_FindsExistingAccount(t *testing.T) {
 		getAccountsFunc: func(budgetID string) (*GetAccountsResponse, error) {
 			return &GetAccountsResponse{
 				Data: struct {
-					Accounts []Account `json:"accounts"`
+					Accounts        []Account `json:"accounts"`
+					ServerKnowledge int64     `json:"server_knowledge"`
 				}{
 					Accounts: []Account{
 						{ID: "found-acc", Name: "Card 9999", Type: "checking", Closed: false, Deleted: false},
@@ -47,7 +49,7 @@ func TestAccountManager_EnsureAccounts_FindsExistingAccount(t *testing.T) {
 			}, nil
 		},
 	}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{
 		{YNABAccountID: "acc-1", Last4: "1234"},
@@ -58,7 +60,7 @@ func TestAccountManager_EnsureAccounts_FindsExistingAccount(t *testing.T) {
 		{Card: "*9999"}, // This one needs to be found
 	}
 
-	result, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	result, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err != nil {
 		t.Fatalf("EnsureAccounts() error = %v", err)
 	}
@@ -85,7 +87,8 @@ func TestAccountManager_EnsureAccounts_CreatesNewAccount(t *testing.T) {
 		getAccountsFunc: func(budgetID string) (*GetAccountsResponse, error) {
 			return &GetAccountsResponse{
 				Data: struct {
-					Accounts []Account `json:"accounts"`
+					Accounts        []Account `json:"accounts"`
+					ServerKnowledge int64     `json:"server_knowledge"`
 				}{
 					Accounts: []Account{}, // No existing accounts with 9999
 				},
@@ -111,7 +114,7 @@ func TestAccountManager_EnsureAccounts_CreatesNewAccount(t *testing.T) {
 			}, nil
 		},
 	}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{
 		{YNABAccountID: "acc-1", Last4: "1234"},
@@ -122,7 +125,7 @@ func TestAccountManager_EnsureAccounts_CreatesNewAccount(t *testing.T) {
 		{Card: "*9999"}, // This one needs to be created
 	}
 
-	result, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	result, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err != nil {
 		t.Fatalf("EnsureAccounts() error = %v", err)
 	}
@@ -150,14 +153,14 @@ func TestAccountManager_EnsureAccounts_GetAccountsFails(t *testing.T) {
 			return nil, errors.New("API error")
 		},
 	}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{}
 	transactions := []*template.Transaction{
 		{Card: "*9999"},
 	}
 
-	_, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	_, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err == nil {
 		t.Error("EnsureAccounts() should fail when GetAccounts fails")
 	}
@@ -168,7 +171,8 @@ func TestAccountManager_EnsureAccounts_CreateAccountFails(t *testing.T) {
 		getAccountsFunc: func(budgetID string) (*GetAccountsResponse, error) {
 			return &GetAccountsResponse{
 				Data: struct {
-					Accounts []Account `json:"accounts"`
+					Accounts        []Account `json:"accounts"`
+					ServerKnowledge int64     `json:"server_knowledge"`
 				}{
 					Accounts: []Account{},
 				},
@@ -178,14 +182,14 @@ func TestAccountManager_EnsureAccounts_CreateAccountFails(t *testing.T) {
 			return nil, errors.New("API error")
 		},
 	}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{}
 	transactions := []*template.Transaction{
 		{Card: "*9999"},
 	}
 
-	_, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	_, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err == nil {
 		t.Error("EnsureAccounts() should fail when CreateAccount fails")
 	}
@@ -196,7 +200,8 @@ func TestAccountManager_EnsureAccounts_SkipsClosedAccounts(t *testing.T) {
 		getAccountsFunc: func(budgetID string) (*GetAccountsResponse, error) {
 			return &GetAccountsResponse{
 				Data: struct {
-					Accounts []Account `json:"accounts"`
+					Accounts        []Account `json:"accounts"`
+					ServerKnowledge int64     `json:"server_knowledge"`
 				}{
 					Accounts: []Account{
 						{ID: "closed-acc", Name: "Card 9999", Type: "checking", Closed: true, Deleted: false},
@@ -206,14 +211,14 @@ func TestAccountManager_EnsureAccounts_SkipsClosedAccounts(t *testing.T) {
 			}, nil
 		},
 	}
-	manager := NewAccountManager(client)
+	manager := NewAccountManager(client, AccountOptions{})
 
 	existingAccounts := []config.YNABAccount{}
 	transactions := []*template.Transaction{
 		{Card: "*9999"},
 	}
 
-	result, err := manager.EnsureAccounts("test-budget", existingAccounts, transactions)
+	result, err := manager.EnsureAccounts("test-budget", existingAccounts, nil, transactions)
 	if err != nil {
 		t.Fatalf("EnsureAccounts() error = %v", err)
 	}
@@ -226,3 +231,148 @@ func TestAccountManager_EnsureAccounts_SkipsClosedAccounts(t *testing.T) {
 		t.Errorf("Expected to use open account, got %s", result[0].YNABAccountID)
 	}
 }
+
+func accountsClient(accounts []Account, created *[]CreateAccountPayload) *mockClient {
+	return &mockClient{
+		getAccountsFunc: func(budgetID string) (*GetAccountsResponse, error) {
+			resp := &GetAccountsResponse{}
+			resp.Data.Accounts = accounts
+			return resp, nil
+		},
+		createAccountFunc: func(budgetID string, payload CreateAccountPayload) (*CreateAccountResponse, error) {
+			*created = append(*created, payload)
+			resp := &CreateAccountResponse{}
+			resp.Data.Account = Account{ID: "new-" + payload.Name, Name: payload.Name, Type: payload.Type}
+			return resp, nil
+		},
+	}
+}
+
+func TestAccountManager_EnsureAccounts_ConfiguredEntry(t *testing.T) {
+	var created []CreateAccountPayload
+	manager := NewAccountManager(accountsClient(nil, &created), AccountOptions{})
+
+	existing := []config.YNABAccount{
+		{Last4: "1234", Cards: []string{"5678"}, Type: "creditCard", Name: "MAIB Visa"},
+	}
+	transactions := []*template.Transaction{{Card: "*5678"}}
+
+	result, err := manager.EnsureAccounts("test-budget", existing, nil, transactions)
+	if err != nil {
+		t.Fatalf("EnsureAccounts() error = %v", err)
+	}
+
+	if len(created) != 1 || created[0].Name != "MAIB Visa" || created[0].Type != "creditCard" {
+		t.Errorf("created = %+v, want one creditCard account named MAIB Visa", created)
+	}
+	if len(result) != 1 || result[0].YNABAccountID != "new-MAIB Visa" {
+		t.Errorf("result = %+v, want the entry with its new account ID", result)
+	}
+}
+
+func TestAccountManager_EnsureAccounts_NameTemplateAndSender(t *testing.T) {
+	var created []CreateAccountPayload
+	manager := NewAccountManager(accountsClient(nil, &created), AccountOptions{
+		Type:         "creditCard",
+		NameTemplate: "{sender} {last4}",
+	})
+
+	existing := []config.YNABAccount{{YNABAccountID: "exim", Last4: "1234", Sender: "EXIMBANK"}}
+	messages := []*message.Message{{Sender: "EXIMBANK"}, {Sender: "102"}}
+	transactions := []*template.Transaction{{Card: "9..1234"}, {Card: "*1234"}}
+
+	result, err := manager.EnsureAccounts("test-budget", existing, messages, transactions)
+	if err != nil {
+		t.Fatalf("EnsureAccounts() error = %v", err)
+	}
+
+	if len(created) != 1 || created[0].Name != "102 1234" || created[0].Type != "creditCard" {
+		t.Errorf("created = %+v, want one creditCard account named \"102 1234\"", created)
+	}
+	if len(result) != 2 || result[1].Sender != "102" || result[1].Last4 != "1234" {
+		t.Errorf("result = %+v, want the scoped entry plus a new one for sender 102", result)
+	}
+}
+
+func TestAccountManager_EnsureAccounts_SameDigitsFromTwoSenders(t *testing.T) {
+	var created []CreateAccountPayload
+	manager := NewAccountManager(accountsClient(nil, &created), AccountOptions{})
+
+	messages := []*message.Message{{Sender: "EXIMBANK"}, {Sender: "102"}}
+	transactions := []*template.Transaction{{Card: "9..1234"}, {Card: "*1234"}}
+
+	result, err := manager.EnsureAccounts("test-budget", nil, messages, transactions)
+	if err != nil {
+		t.Fatalf("EnsureAccounts() error = %v", err)
+	}
+
+	if len(created) != 1 {
+		t.Errorf("created = %+v, want one shared \"Card 1234\" account", created)
+	}
+	if len(result) != 2 || result[0].Sender != "EXIMBANK" || result[1].Sender != "102" {
+		t.Fatalf("result = %+v, want a mapping per sender", result)
+	}
+	if result[0].YNABAccountID != result[1].YNABAccountID {
+		t.Errorf("mappings point at %s and %s, want the same account", result[0].YNABAccountID, result[1].YNABAccountID)
+	}
+}
+
+func TestAccountManager_EnsureAccounts_Matching(t *testing.T) {
+	accounts := []Account{
+		{ID: "savings", Name: "Savings 12345"},
+		{ID: "old", Name: "Old card 1234 (closed)", Closed: true},
+		{ID: "visa", Name: "Visa 1234"},
+	}
+	transactions := []*template.Transaction{{Card: "*1234"}}
+
+	tests := []struct {
+		name       string
+		exactMatch bool
+		wantID     string
+		wantCreate bool
+	}{
+		{"last4 as separate number", false, "visa", false},
+		{"exact name only", true, "new-Card 1234", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []CreateAccountPayload
+			manager := NewAccountManager(accountsClient(accounts, &created), AccountOptions{ExactMatch: tt.exactMatch})
+
+			result, err := manager.EnsureAccounts("test-budget", nil, nil, transactions)
+			if err != nil {
+				t.Fatalf("EnsureAccounts() error = %v", err)
+			}
+			if len(result) != 1 || result[0].YNABAccountID != tt.wantID {
+				t.Errorf("result = %+v, want account %s", result, tt.wantID)
+			}
+			if (len(created) > 0) != tt.wantCreate {
+				t.Errorf("created = %+v, want create %v", created, tt.wantCreate)
+			}
+		})
+	}
+}
+
+func TestAccountManager_EnsureAccounts_CreationDeclined(t *testing.T) {
+	var created []CreateAccountPayload
+	var asked []string
+	manager := NewAccountManager(accountsClient(nil, &created), AccountOptions{
+		Confirm: func(name, accountType string) bool {
+			asked = append(asked, name+" ("+accountType+")")
+			return false
+		},
+	})
+
+	result, err := manager.EnsureAccounts("test-budget", nil, nil, []*template.Transaction{{Card: "*9999"}})
+	if err != nil {
+		t.Fatalf("EnsureAccounts() error = %v", err)
+	}
+
+	if len(asked) != 1 || asked[0] != "Card 9999 (checking)" {
+		t.Errorf("asked = %v, want confirmation for Card 9999 (checking)", asked)
+	}
+	if len(created) != 0 || len(result) != 0 {
+		t.Errorf("created = %+v, result = %+v, want nothing after declining", created, result)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -35,11 +36,14 @@ func (c *HTTPClient) ClearAPIKey() {
 func (c *HTTPClient) doRequest(req *http.Request) ([]byte, error) {
 	req.Header.Set("Authorization", "Bearer "+string(c.apiKey))
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	slog.Debug("YNAB request", "method", req.Method, "path", req.URL.Path,
+		"status", resp.StatusCode, "duration", time.Since(start).Round(time.Millisecond))
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -99,8 +103,44 @@ func (c *HTTPClient) CreateTransactions(budgetID string, transactions []Transact
 	return &response, nil
 }
 
+func (c *HTTPClient) UpdateTransactions(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error) {
+	url := fmt.Sprintf("%s/budgets/%s/transactions", c.baseURL, budgetID)
+
+	requestBody := UpdateTransactionsRequest{
+		Transactions: transactions,
+	}
+
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("PATCH", url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response UpdateTransactionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &response, nil
+}
+
 func (c *HTTPClient) GetAccounts(budgetID string) (*GetAccountsResponse, error) {
-	url := fmt.Sprintf("%s/budgets/%s/accounts", c.baseURL, budgetID)
+	return c.GetAccountsSince(budgetID, 0)
+}
+
+// GetAccountsSince returns the accounts changed after the server knowledge
+// of an earlier response, or all accounts if lastKnowledge is 0.
+func (c *HTTPClient) GetAccountsSince(budgetID string, lastKnowledge int64) (*GetAccountsResponse, error) {
+	url := deltaURL(fmt.Sprintf("%s/budgets/%s/accounts", c.baseURL, budgetID), lastKnowledge)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -119,6 +159,36 @@ func (c *HTTPClient) GetAccounts(budgetID string) (*GetAccountsResponse, error)
 	return &response, nil
 }
 
+// GetTransactions returns the transactions changed after the server
+// knowledge of an earlier response, including deleted ones, or all
+// transactions if lastKnowledge is 0.
+func (c *HTTPClient) GetTransactions(budgetID string, lastKnowledge int64) (*GetTransactionsResponse, error) {
+	url := deltaURL(fmt.Sprintf("%s/budgets/%s/transactions", c.baseURL, budgetID), lastKnowledge)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetTransactionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &response, nil
+}
+
+func deltaURL(url string, lastKnowledge int64) string {
+	if lastKnowledge == 0 {
+		return url
+	}
+	return fmt.Sprintf("%s?last_knowledge_of_server=%d", url, lastKnowledge)
+}
+
 func (c *HTTPClient) GetBudgets() (*GetBudgetsResponse, error) {
 	url := fmt.Sprintf("%s/budgets", c.baseURL)
 
@@ -139,6 +209,26 @@ func (c *HTTPClient) GetBudgets() (*GetBudgetsResponse, error) {
 	return &response, nil
 }
 
+func (c *HTTPClient) GetCategories(budgetID string) (*GetCategoriesResponse, error) {
+	url := fmt.Sprintf("%s/budgets/%s/categories", c.baseURL, budgetID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetCategoriesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &response, nil
+}
+
 func (c *HTTPClient) CreateAccount(budgetID string, payload CreateAccountPayload) (*CreateAccountResponse, error) {
 	url := fmt.Sprintf("%s/budgets/%s/accounts", c.baseURL, budgetID)
 
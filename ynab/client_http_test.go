@@ -195,7 +195,8 @@ func TestClient_GetAccounts_Success(t *testing.T) {
 
 		response := GetAccountsResponse{
 			Data: struct {
-				Accounts []Account `json:"accounts"`
+				Accounts        []Account `json:"accounts"`
+				ServerKnowledge int64     `json:"server_knowledge"`
 			}{
 				Accounts: []Account{
 					{ID: "account-1", Name: "Card 1234", Type: "checking", Balance: 100000, Closed: false, Deleted: false},
@@ -421,3 +422,67 @@ func TestClient_GetBudgets_ServerError(t *testing.T) {
 		t.Error("GetBudgets() should fail on 500 errors")
 	}
 }
+
+func TestClient_GetCategories_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/budgets/test-budget/categories" {
+			t.Errorf("Expected /v1/budgets/test-budget/categories, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":{"category_groups":[{"id":"g1","name":"Monthly","categories":[
+			{"id":"c1","name":"Bank Fees","deleted":true},
+			{"id":"c2","name":"Bank fees"},
+			{"id":"c3","name":"Groceries"}]}]}}`))
+	}))
+	defer server.Close()
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v1",
+		apiKey:     []byte("test-api-key"),
+		httpClient: server.Client(),
+	}
+
+	response, err := client.GetCategories("test-budget")
+	if err != nil {
+		t.Fatalf("GetCategories() error = %v", err)
+	}
+
+	id, err := response.FindCategoryID("bank FEES")
+	if err != nil || id != "c2" {
+		t.Errorf("FindCategoryID() = %q, %v, want c2 (skipping deleted)", id, err)
+	}
+	if _, err := response.FindCategoryID("Missing"); err == nil {
+		t.Error("FindCategoryID() expected error for unknown category")
+	}
+}
+
+func TestClient_GetTransactions_Delta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/budgets/test-budget/transactions" {
+			t.Errorf("Expected /v1/budgets/test-budget/transactions, got %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("last_knowledge_of_server"); got != "42" {
+			t.Errorf("Expected last_knowledge_of_server=42, got %q", got)
+		}
+		w.Write([]byte(`{"data":{"server_knowledge":43,"transactions":[
+			{"id":"t1","date":"2026-01-10","amount":-10000,"cleared":"uncleared","account_id":"a1","payee_name":"Shop"},
+			{"id":"t2","deleted":true}]}}`))
+	}))
+	defer server.Close()
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v1",
+		apiKey:     []byte("test-api-key"),
+		httpClient: server.Client(),
+	}
+
+	response, err := client.GetTransactions("test-budget", 42)
+	if err != nil {
+		t.Fatalf("GetTransactions() error = %v", err)
+	}
+	if response.Data.ServerKnowledge != 43 {
+		t.Errorf("ServerKnowledge = %d, want 43", response.Data.ServerKnowledge)
+	}
+	if len(response.Data.Transactions) != 2 || !response.Data.Transactions[1].Deleted {
+		t.Errorf("Transactions = %+v", response.Data.Transactions)
+	}
+}
@@ -0,0 +1,143 @@
+package ynab
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const lifecycleSection = "ynab_lifecycle"
+
+// Later events are only matched to purchases at most this old.
+const LifecycleWindow = 30 * 24 * time.Hour
+
+// LifecycleRecord follows one purchase through its events and the YNAB
+// transaction created for the first one.
+type LifecycleRecord struct {
+	Key           string           `json:"key"`
+	BudgetID      string           `json:"budget_id"`
+	TransactionID string           `json:"transaction_id,omitempty"`
+	ImportID      string           `json:"import_id"`
+	Stage         template.Stage   `json:"stage"`
+	Amount        int64            `json:"amount"`
+	Split         bool             `json:"split,omitempty"`
+	StartedAt     time.Time        `json:"started_at"`
+	Events        []LifecycleEvent `json:"events"`
+}
+
+type LifecycleEvent struct {
+	Stage    template.Stage `json:"stage"`
+	ImportID string         `json:"import_id"`
+	Amount   int64          `json:"amount"`
+	At       time.Time      `json:"at"`
+}
+
+// accepts reports whether a purchase in the record's stage can move on to
+// stage. A second authorization is a new purchase, not an update.
+func (r *LifecycleRecord) accepts(stage template.Stage) bool {
+	switch r.Stage {
+	case template.StageAuthorized:
+		return stage == template.StageSettled || stage == template.StageDeclined || stage == template.StageReversed
+	case template.StageSettled:
+		return stage == template.StageReversed
+	}
+	return false
+}
+
+type LifecycleStore struct {
+	filePath string
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func NewLifecycleStore(filePath string) *LifecycleStore {
+	return &LifecycleStore{filePath: filePath, now: time.Now}
+}
+
+func (s *LifecycleStore) read() ([]LifecycleRecord, error) {
+	var records []LifecycleRecord
+	if err := datastore.ReadSection(s.filePath, lifecycleSection, &records); err != nil {
+		return nil, fmt.Errorf("failed to read lifecycle records: %w", err)
+	}
+	return records, nil
+}
+
+// Records returns the purchases followed in the budget.
+func (s *LifecycleStore) Records(budgetID string) ([]*LifecycleRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var result []*LifecycleRecord
+	for i := range records {
+		if records[i].BudgetID == budgetID {
+			result = append(result, &records[i])
+		}
+	}
+	return result, nil
+}
+
+// Replace stores records as the budget's purchases. Purchases too old for
+// later events to match them are dropped.
+func (s *LifecycleStore) Replace(budgetID string, records []*LifecycleRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	cutoff := s.now().Add(-LifecycleWindow)
+	result := make([]LifecycleRecord, 0, len(existing)+len(records))
+	for _, r := range existing {
+		if r.BudgetID != budgetID && !r.StartedAt.Before(cutoff) {
+			result = append(result, r)
+		}
+	}
+	for _, r := range records {
+		if !r.StartedAt.Before(cutoff) {
+			result = append(result, *r)
+		}
+	}
+
+	if err := datastore.WriteSection(s.filePath, lifecycleSection, result); err != nil {
+		return fmt.Errorf("failed to write lifecycle records: %w", err)
+	}
+	return nil
+}
+
+// findOpen returns the oldest purchase with the key that an event of the
+// given stage at the given time continues, or nil.
+func findOpen(records []*LifecycleRecord, key string, stage template.Stage, at time.Time) *LifecycleRecord {
+	for _, r := range records {
+		if r.Key != key || !r.accepts(stage) {
+			continue
+		}
+		if at.Before(r.StartedAt) || at.Sub(r.StartedAt) > LifecycleWindow {
+			continue
+		}
+		return r
+	}
+	return nil
+}
+
+// PurchaseKey identifies the events of one purchase: same sender, card,
+// merchant and amount in the original currency. The converted amount may
+// change between authorization and settlement.
+func PurchaseKey(sender string, tx *template.Transaction) string {
+	return fmt.Sprintf("%s:%s:%s:%.2f:%s",
+		strings.ToLower(sender),
+		cardLast4.FindString(tx.Card),
+		strings.ToLower(strings.TrimSpace(tx.Address)),
+		tx.Original.Value,
+		strings.ToUpper(tx.Original.Currency),
+	)
+}
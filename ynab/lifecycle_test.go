@@ -0,0 +1,266 @@
+package ynab
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+type lifecycleClient struct {
+	mockClient
+	created []TransactionPayload
+	updates []TransactionUpdate
+}
+
+func newLifecycleClient() *lifecycleClient {
+	c := &lifecycleClient{}
+	c.createTransactionsFunc = func(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error) {
+		resp := &CreateTransactionsResponse{}
+		for _, tx := range transactions {
+			c.created = append(c.created, tx)
+			resp.Data.Transactions = append(resp.Data.Transactions, struct {
+				ID       string `json:"id"`
+				ImportID string `json:"import_id"`
+			}{ID: "ynab-" + tx.ImportID, ImportID: tx.ImportID})
+		}
+		return resp, nil
+	}
+	c.updateTransactionsFunc = func(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error) {
+		c.updates = append(c.updates, transactions...)
+		return &UpdateTransactionsResponse{}, nil
+	}
+	return c
+}
+
+func purchaseEvent(hour int, status string, mdl float64) (*message.Message, *template.Transaction) {
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, hour, 0, 0, 0, time.UTC), Sender: "102"}
+	tx := &template.Transaction{
+		Card:      "*1234",
+		Status:    status,
+		Address:   "AMAZON",
+		Direction: template.DirectionDebit,
+		Original:  template.Amount{Value: 10, Currency: "EUR"},
+		Converted: template.Amount{Value: mdl, Currency: "MDL"},
+	}
+	return msg, tx
+}
+
+func newLifecycleSyncer(t *testing.T, dir string, client YNABClient) *Syncer {
+	store, err := NewSyncStore(dir + "/data.json")
+	if err != nil {
+		t.Fatalf("NewSyncStore() error = %v", err)
+	}
+	mapper := NewMapper([]YNABAccount{{YNABAccountID: "acc-1", Last4: "1234"}})
+	syncer := NewSyncer(store, client, mapper, "budget", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	lifecycle := NewLifecycleStore(dir + "/data.json")
+	lifecycle.now = func() time.Time { return time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC) }
+	syncer.SetLifecycleStore(lifecycle)
+	return syncer
+}
+
+func TestSyncer_Lifecycle_SettlementUpdatesAuthorization(t *testing.T) {
+	dir := t.TempDir()
+	client := newLifecycleClient()
+
+	authMsg, auth := purchaseEvent(10, "Odobrena", 195)
+	result, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{authMsg}, []*template.Transaction{auth})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Synced != 1 || len(client.created) != 1 || client.created[0].Cleared != "uncleared" {
+		t.Fatalf("authorization: result = %+v, created = %+v, want one uncleared transaction", result, client.created)
+	}
+
+	settleMsg, settle := purchaseEvent(20, "", 197.5)
+	result, err = newLifecycleSyncer(t, dir, client).Sync([]*message.Message{settleMsg}, []*template.Transaction{settle})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Updated != 1 || result.Synced != 0 || len(client.created) != 1 {
+		t.Fatalf("settlement: result = %+v, want one update and no new transaction", result)
+	}
+	update := client.updates[0]
+	if update.ID != "ynab-"+client.created[0].ImportID || update.Amount == nil || *update.Amount != -197500 || update.Cleared != "cleared" {
+		t.Errorf("update = %+v, want settled amount -197500 cleared", update)
+	}
+
+	records, err := NewLifecycleStore(dir + "/data.json").Records("budget")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Records() = %v, %v", records, err)
+	}
+	if records[0].Stage != template.StageSettled || len(records[0].Events) != 2 {
+		t.Errorf("record = %+v, want settled with two events", records[0])
+	}
+
+	// A second identical authorization is a new purchase
+	againMsg, again := purchaseEvent(21, "Odobrena", 195)
+	result, err = newLifecycleSyncer(t, dir, client).Sync([]*message.Message{againMsg}, []*template.Transaction{again})
+	if err != nil || result.Synced != 1 {
+		t.Errorf("new authorization: result = %+v, err = %v, want it created", result, err)
+	}
+}
+
+func TestSyncer_Lifecycle_KeepsCreatedBatchesOnError(t *testing.T) {
+	dir := t.TempDir()
+	client := newLifecycleClient()
+	create := client.createTransactionsFunc
+	calls := 0
+	client.createTransactionsFunc = func(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error) {
+		if calls++; calls > 1 {
+			return nil, errors.New("rate limited")
+		}
+		return create(budgetID, transactions)
+	}
+
+	// 101 purchases take two batches, and the second one fails
+	var messages []*message.Message
+	var transactions []*template.Transaction
+	for i := 0; i < 101; i++ {
+		msg, tx := purchaseEvent(10, "Odobrena", 195)
+		tx.Address = fmt.Sprintf("SHOP %d", i)
+		messages = append(messages, msg)
+		transactions = append(transactions, tx)
+	}
+	if _, err := newLifecycleSyncer(t, dir, client).Sync(messages, transactions); err == nil {
+		t.Fatal("Sync() expected error for the failed batch")
+	}
+
+	records, err := NewLifecycleStore(dir + "/data.json").Records("budget")
+	if err != nil || len(records) != 100 {
+		t.Fatalf("Records() = %d records, %v, want the 100 created purchases", len(records), err)
+	}
+	if records[0].TransactionID == "" {
+		t.Errorf("record = %+v, want the created transaction ID", records[0])
+	}
+
+	// The settlement of a purchase from the created batch updates it
+	client.createTransactionsFunc = create
+	settleMsg, settle := purchaseEvent(20, "", 197.5)
+	settle.Address = "SHOP 0"
+	result, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{settleMsg}, []*template.Transaction{settle})
+	if err != nil || result.Updated != 1 || result.Synced != 0 {
+		t.Errorf("settlement: result = %+v, err = %v, want one update", result, err)
+	}
+}
+
+func TestSyncer_Lifecycle_EventsInOneRun(t *testing.T) {
+	client := newLifecycleClient()
+
+	authMsg, auth := purchaseEvent(10, "Odobrena", 195)
+	declineMsg, decline := purchaseEvent(11, "Decline", 195)
+	result, err := newLifecycleSyncer(t, t.TempDir(), client).Sync(
+		[]*message.Message{authMsg, declineMsg},
+		[]*template.Transaction{auth, decline},
+	)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(client.created) != 1 || len(client.updates) != 0 {
+		t.Fatalf("created = %d, updates = %d, want one transaction", len(client.created), len(client.updates))
+	}
+	if client.created[0].Amount != 0 || client.created[0].Cleared != "cleared" {
+		t.Errorf("created = %+v, want a zeroed cleared transaction", client.created[0])
+	}
+	if result.Synced != 1 {
+		t.Errorf("Synced = %d, want 1", result.Synced)
+	}
+}
+
+func TestSyncer_Lifecycle_SkipsLoneDecline(t *testing.T) {
+	client := newLifecycleClient()
+
+	msg, decline := purchaseEvent(10, "Decline§TranNotPermToCardHolder", 195)
+	result, err := newLifecycleSyncer(t, t.TempDir(), client).Sync([]*message.Message{msg}, []*template.Transaction{decline})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Skipped != 1 || len(client.created) != 0 {
+		t.Errorf("result = %+v, created = %d, want the decline skipped", result, len(client.created))
+	}
+}
+
+func TestSyncer_Lifecycle_KeepsSplitAmount(t *testing.T) {
+	dir := t.TempDir()
+	client := newLifecycleClient()
+
+	authMsg, auth := purchaseEvent(10, "Odobrena", 195)
+	auth.Components = []template.Component{{
+		Kind:      template.KindFee,
+		Original:  template.Amount{Value: 5, Currency: "MDL"},
+		Converted: template.Amount{Value: 5, Currency: "MDL"},
+	}}
+	if _, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{authMsg}, []*template.Transaction{auth}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(client.created) != 1 || len(client.created[0].Subtransactions) != 2 {
+		t.Fatalf("created = %+v, want one split transaction", client.created)
+	}
+
+	settleMsg, settle := purchaseEvent(20, "", 197.5)
+	result, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{settleMsg}, []*template.Transaction{settle})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.Updated != 1 || len(client.updates) != 1 {
+		t.Fatalf("result = %+v, want the split updated", result)
+	}
+	if update := client.updates[0]; update.Amount != nil || update.Cleared != "cleared" {
+		t.Errorf("update = %+v, want only the cleared state changed", update)
+	}
+}
+
+func TestLifecycleStore_DropsOldPurchases(t *testing.T) {
+	store := NewLifecycleStore(t.TempDir() + "/data.json")
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	records := []*LifecycleRecord{
+		{Key: "old", BudgetID: "budget", StartedAt: now.Add(-LifecycleWindow - time.Hour)},
+		{Key: "recent", BudgetID: "budget", StartedAt: now.Add(-time.Hour)},
+	}
+	if err := store.Replace("budget", records); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	got, err := store.Records("budget")
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "recent" {
+		t.Errorf("Records() = %+v, want only the recent purchase", got)
+	}
+}
+
+func TestSyncer_Lifecycle_UnresolvableTransactionFailsOnce(t *testing.T) {
+	dir := t.TempDir()
+	client := newLifecycleClient()
+	client.createTransactionsFunc = func(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error) {
+		// YNAB returns no ID for an import ID it already has
+		return &CreateTransactionsResponse{}, nil
+	}
+
+	authMsg, auth := purchaseEvent(10, "Odobrena", 195)
+	if _, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{authMsg}, []*template.Transaction{auth}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	settleMsg, settle := purchaseEvent(20, "", 197.5)
+	for run := 0; run < 2; run++ {
+		result, err := newLifecycleSyncer(t, dir, client).Sync([]*message.Message{settleMsg}, []*template.Transaction{settle})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if wantFailed := 1 - run; len(result.Failed) != wantFailed || result.Skipped != 1 {
+			t.Errorf("run %d: result = %+v, want %d failures and the event skipped", run, result, wantFailed)
+		}
+	}
+
+	records, err := NewLifecycleStore(dir + "/data.json").Records("budget")
+	if err != nil || len(records) != 0 {
+		t.Errorf("Records() = %+v, %v, want the purchase no longer followed", records, err)
+	}
+}
@@ -0,0 +1,72 @@
+package ynab
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Words that appear in many merchant names and say nothing about which
+// merchant it is.
+var genericPayeeWords = map[string]bool{
+	"srl": true, "ltd": true, "llc": true, "inc": true, "shop": true,
+	"chisinau": true, "kishinev": true, "moldova": true, "mda": true,
+	"www": true, "com": true, "unknown": true,
+}
+
+// ManualMatches returns the transactions entered by hand that payload may
+// be a duplicate of: not yet linked to an import, in the same account, for
+// the same amount, at most windowDays apart and with a similar payee.
+func ManualMatches(candidates []Transaction, payload *TransactionPayload, windowDays int) []Transaction {
+	date, err := time.Parse("2006-01-02", payload.Date)
+	if err != nil {
+		return nil
+	}
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	var matches []Transaction
+	for _, tx := range candidates {
+		if tx.ImportID != "" || tx.Deleted || tx.AccountID != payload.AccountID || tx.Amount != payload.Amount {
+			continue
+		}
+		txDate, err := time.Parse("2006-01-02", tx.Date)
+		if err != nil {
+			continue
+		}
+		if diff := txDate.Sub(date); diff > window || diff < -window {
+			continue
+		}
+		if !similarPayee(tx.PayeeName, payload.PayeeName) {
+			continue
+		}
+		matches = append(matches, tx)
+	}
+	return matches
+}
+
+// similarPayee reports whether two payee names share a distinctive word,
+// so "Linella" matches "LINELLA SRL CHISINAU MD".
+func similarPayee(a, b string) bool {
+	words := make(map[string]bool)
+	for _, w := range payeeWords(a) {
+		words[w] = true
+	}
+	for _, w := range payeeWords(b) {
+		if words[w] {
+			return true
+		}
+	}
+	return false
+}
+
+func payeeWords(name string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 && !genericPayeeWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
@@ -0,0 +1,40 @@
+package ynab
+
+import "testing"
+
+func TestManualMatches(t *testing.T) {
+	payload := &TransactionPayload{AccountID: "acc-1", Date: "2026-01-10", Amount: -150000, PayeeName: "LINELLA SRL CHISINAU"}
+
+	candidates := []Transaction{
+		{ID: "match", AccountID: "acc-1", Date: "2026-01-09", Amount: -150000, PayeeName: "Linella"},
+		{ID: "imported", AccountID: "acc-1", Date: "2026-01-10", Amount: -150000, PayeeName: "Linella", ImportID: "YNAB:abc"},
+		{ID: "other-account", AccountID: "acc-2", Date: "2026-01-10", Amount: -150000, PayeeName: "Linella"},
+		{ID: "other-amount", AccountID: "acc-1", Date: "2026-01-10", Amount: -140000, PayeeName: "Linella"},
+		{ID: "too-late", AccountID: "acc-1", Date: "2026-01-14", Amount: -150000, PayeeName: "Linella"},
+		{ID: "other-payee", AccountID: "acc-1", Date: "2026-01-10", Amount: -150000, PayeeName: "Chisinau Market SRL"},
+	}
+
+	matches := ManualMatches(candidates, payload, 3)
+	if len(matches) != 1 || matches[0].ID != "match" {
+		t.Errorf("ManualMatches() = %+v, want only \"match\"", matches)
+	}
+}
+
+func TestSimilarPayee(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Linella", "LINELLA SRL CHISINAU MD", true},
+		{"Kaufland Botanica", "KAUFLAND 1234", true},
+		{"Nr1 SRL", "Linella SRL", false},
+		{"", "Linella", false},
+		{"Unknown", "Unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := similarPayee(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarPayee(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -13,23 +13,64 @@ import (
 )
 
 type Mapper struct {
-	accountsByLast4 map[string]string
-	last4Regex      *regexp.Regexp
+	accountsByCard map[cardKey]string
+	last4Regex     *regexp.Regexp
+	categories     map[template.Kind]string
+	accountStates  map[string]State
+	stateRules     []StateRule
+	memoTemplates  map[string]*MemoTemplate
+}
+
+// cardKey identifies a card by the last 4 digits and, for sender-scoped
+// mappings, the lower-cased sender.
+type cardKey struct {
+	sender string
+	last4  string
 }
 
 func NewMapper(accounts []YNABAccount) *Mapper {
-	accountsByLast4 := make(map[string]string)
+	accountsByCard := make(map[cardKey]string)
+	accountStates := make(map[string]State)
 	for _, acc := range accounts {
-		accountsByLast4[acc.Last4] = acc.YNABAccountID
+		sender := strings.ToLower(acc.Sender)
+		for _, last4 := range append([]string{acc.Last4}, acc.Cards...) {
+			accountsByCard[cardKey{sender, last4}] = acc.YNABAccountID
+		}
+		if acc.State != (State{}) {
+			accountStates[acc.YNABAccountID] = acc.State
+		}
 	}
 
 	return &Mapper{
-		accountsByLast4: accountsByLast4,
-		last4Regex:      regexp.MustCompile(`\d{4}$`),
+		accountsByCard: accountsByCard,
+		last4Regex:     regexp.MustCompile(`\d{4}$`),
+		categories:     make(map[template.Kind]string),
+		accountStates:  accountStates,
+		memoTemplates:  make(map[string]*MemoTemplate),
 	}
 }
 
-func (m *Mapper) MatchAccount(tx *template.Transaction) (string, error) {
+// SetMemoTemplate formats the memos of the account's transactions, or of
+// all accounts without their own template if accountID is empty.
+func (m *Mapper) SetMemoTemplate(accountID string, memo *MemoTemplate) {
+	m.memoTemplates[accountID] = memo
+}
+
+// SetStateRules sets rules that adjust the state of matching transactions
+// after the account's state is applied. Later rules win.
+func (m *Mapper) SetStateRules(rules []StateRule) {
+	m.stateRules = rules
+}
+
+// SetCategory sets the YNAB category for the split part of transaction
+// components of the given kind, such as template.KindFee.
+func (m *Mapper) SetCategory(kind template.Kind, categoryID string) {
+	m.categories[kind] = categoryID
+}
+
+// MatchAccount returns the account of the transaction's card, preferring a
+// mapping scoped to the sender over an unscoped one.
+func (m *Mapper) MatchAccount(sender string, tx *template.Transaction) (string, error) {
 	if tx.Card == "" {
 		return "", errors.New("transaction has no card information")
 	}
@@ -41,7 +82,10 @@ func (m *Mapper) MatchAccount(tx *template.Transaction) (string, error) {
 	}
 
 	last4 := matches
-	accountID, found := m.accountsByLast4[last4]
+	if accountID, found := m.accountsByCard[cardKey{strings.ToLower(sender), last4}]; found {
+		return accountID, nil
+	}
+	accountID, found := m.accountsByCard[cardKey{"", last4}]
 	if !found {
 		return "", fmt.Errorf("no account found for card ending in %s", last4)
 	}
@@ -49,7 +93,8 @@ func (m *Mapper) MatchAccount(tx *template.Transaction) (string, error) {
 	return accountID, nil
 }
 
-func (m *Mapper) GenerateImportID(msg *message.Message, tx *template.Transaction) string {
+// GenerateImportID derives the import ID of a transaction from its message.
+func GenerateImportID(msg *message.Message, tx *template.Transaction) string {
 	// Format: timestamp:card:amount:payee
 	data := fmt.Sprintf("%d:%s:%.2f:%s",
 		msg.Timestamp.Unix(),
@@ -65,28 +110,39 @@ func (m *Mapper) GenerateImportID(msg *message.Message, tx *template.Transaction
 }
 
 func (m *Mapper) MapTransaction(msg *message.Message, tx *template.Transaction) (*TransactionPayload, error) {
-	accountID, err := m.MatchAccount(tx)
+	accountID, err := m.MatchAccount(msg.Sender, tx)
 	if err != nil {
 		return nil, err
 	}
 
-	importID := m.GenerateImportID(msg, tx)
+	importID := GenerateImportID(msg, tx)
 	date := msg.Timestamp.Format("2006-01-02")
 
-	// Amount in milliunits (multiply by 1000), negative for debits
-	amountMilliunits := int64(tx.Converted.Value * 1000)
-	if isDebit(tx.Operation) {
-		amountMilliunits = -amountMilliunits
+	amount, err := SignedAmount(tx)
+	if err != nil {
+		return nil, err
 	}
 
+	// Amount in milliunits (multiply by 1000), negative for debits
+	amountMilliunits := int64(amount * 1000)
+
 	payeeName := tx.Address
 	if payeeName == "" {
 		payeeName = "Unknown"
 	}
 
 	memo := buildMemo(tx)
+	memoTemplate := m.memoTemplates[accountID]
+	if memoTemplate == nil {
+		memoTemplate = m.memoTemplates[""]
+	}
+	if memoTemplate != nil {
+		if memo, err = memoTemplate.Render(msg, tx); err != nil {
+			return nil, err
+		}
+	}
 
-	return &TransactionPayload{
+	payload := &TransactionPayload{
 		AccountID: accountID,
 		Date:      date,
 		Amount:    amountMilliunits,
@@ -94,7 +150,85 @@ func (m *Mapper) MapTransaction(msg *message.Message, tx *template.Transaction)
 		Memo:      memo,
 		Cleared:   "cleared",
 		ImportID:  importID,
-	}, nil
+	}
+	// An authorization only holds the money until the purchase settles
+	if template.StageOf(tx) == template.StageAuthorized {
+		payload.Cleared = "uncleared"
+	}
+	m.accountStates[accountID].apply(payload)
+	for i := range m.stateRules {
+		if m.stateRules[i].Matches(msg.Sender, tx) {
+			m.stateRules[i].State.apply(payload)
+		}
+	}
+
+	// Fees and cashback turn the transaction into a split: the main amount
+	// stays uncategorized and each component gets its own category.
+	if len(tx.Components) > 0 {
+		payload.Subtransactions = []SubTransactionPayload{{Amount: amountMilliunits}}
+		for _, c := range tx.Components {
+			componentAmount, err := ComponentAmount(c)
+			if err != nil {
+				return nil, err
+			}
+			componentMilliunits := int64(componentAmount * 1000)
+			payload.Amount += componentMilliunits
+			payload.Subtransactions = append(payload.Subtransactions, SubTransactionPayload{
+				Amount:     componentMilliunits,
+				CategoryID: m.categories[c.Kind],
+				Memo:       componentMemos[c.Kind],
+			})
+		}
+	}
+
+	return payload, nil
+}
+
+var componentMemos = map[template.Kind]string{
+	template.KindFee:      "Fee",
+	template.KindCashback: "Cashback",
+}
+
+// ComponentAmount returns the converted component amount: negative for
+// fees, positive for cashback.
+func ComponentAmount(c template.Component) (float64, error) {
+	switch c.Kind {
+	case template.KindFee:
+		return -c.Converted.Value, nil
+	case template.KindCashback:
+		return c.Converted.Value, nil
+	}
+	return 0, fmt.Errorf("unknown component kind %q", c.Kind)
+}
+
+// TotalAmount returns the signed amount including fees and cashback, i.e.
+// how much the account balance changes.
+func TotalAmount(tx *template.Transaction) (float64, error) {
+	total, err := SignedAmount(tx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range tx.Components {
+		amount, err := ComponentAmount(c)
+		if err != nil {
+			return 0, err
+		}
+		total += amount
+	}
+	return total, nil
+}
+
+// SignedAmount returns the converted amount, negative for debits and for
+// transfers out of the card's account. Transactions without a known
+// direction are rejected rather than guessed.
+func SignedAmount(tx *template.Transaction) (float64, error) {
+	switch tx.Direction {
+	case template.DirectionDebit, template.DirectionTransfer:
+		return -tx.Converted.Value, nil
+	case template.DirectionCredit, template.DirectionReversal:
+		return tx.Converted.Value, nil
+	}
+	return 0, fmt.Errorf("unknown direction %q for operation %q", tx.Direction, tx.Operation)
 }
 
 func buildMemo(tx *template.Transaction) string {
@@ -142,19 +276,3 @@ func buildMemo(tx *template.Transaction) string {
 
 	return strings.Join(memoParts, " - ")
 }
-
-func isDebit(operation string) bool {
-	debitOperations := []string{
-		"Debitare",
-		"Tovary i uslugi",
-		"Tranzactie reusita",
-	}
-
-	for _, op := range debitOperations {
-		if strings.Contains(operation, op) {
-			return true
-		}
-	}
-
-	return false
-}
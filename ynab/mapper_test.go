@@ -1,6 +1,7 @@
 package ynab
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -75,7 +76,7 @@ func TestMapper_MatchAccount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tx := &template.Transaction{Card: tt.card}
-			got, err := mapper.MatchAccount(tx)
+			got, err := mapper.MatchAccount("", tx)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MatchAccount() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -87,9 +88,7 @@ func TestMapper_MatchAccount(t *testing.T) {
 	}
 }
 
-func TestMapper_GenerateImportID(t *testing.T) {
-	mapper := NewMapper([]YNABAccount{})
-
+func TestGenerateImportID(t *testing.T) {
 	msg := &message.Message{
 		Timestamp: time.Date(2026, 1, 10, 15, 30, 45, 0, time.UTC),
 		Sender:    "102",
@@ -106,8 +105,8 @@ func TestMapper_GenerateImportID(t *testing.T) {
 	}
 
 	// Generate import ID twice - should be same (deterministic)
-	id1 := mapper.GenerateImportID(msg, tx)
-	id2 := mapper.GenerateImportID(msg, tx)
+	id1 := GenerateImportID(msg, tx)
+	id2 := GenerateImportID(msg, tx)
 
 	if id1 != id2 {
 		t.Error("GenerateImportID() should be deterministic")
@@ -132,7 +131,7 @@ func TestMapper_GenerateImportID(t *testing.T) {
 		Address: "Another Merchant",
 	}
 
-	id3 := mapper.GenerateImportID(msg, tx2)
+	id3 := GenerateImportID(msg, tx2)
 	if id1 == id3 {
 		t.Error("Different transactions should generate different import IDs")
 	}
@@ -151,6 +150,7 @@ func TestMapper_MapTransaction(t *testing.T) {
 
 	tx := &template.Transaction{
 		Operation: "Debitare",
+		Direction: template.DirectionDebit,
 		Card:      "9..1234",
 		Status:    "Odobrena",
 		Original: template.Amount{
@@ -186,8 +186,9 @@ func TestMapper_MapTransaction(t *testing.T) {
 		t.Errorf("PayeeName = %v, want Test Merchant", payload.PayeeName)
 	}
 
-	if payload.Cleared != "cleared" {
-		t.Errorf("Cleared = %v, want cleared", payload.Cleared)
+	// Odobrena is an authorization, cleared once it settles
+	if payload.Cleared != "uncleared" {
+		t.Errorf("Cleared = %v, want uncleared", payload.Cleared)
 	}
 
 	if payload.ImportID == "" {
@@ -207,6 +208,7 @@ func TestMapper_MapTransaction_Suplinire(t *testing.T) {
 
 	tx := &template.Transaction{
 		Operation: "Suplinire",
+		Direction: template.DirectionCredit,
 		Card:      "9..1234",
 		Converted: template.Amount{
 			Value:    1000.00,
@@ -327,6 +329,7 @@ func TestMapper_MapTransaction_StandardMemoIsEmpty(t *testing.T) {
 
 	tx := &template.Transaction{
 		Operation: "Tovary i uslugi",
+		Direction: template.DirectionDebit,
 		Status:    "Odobrena",
 		Card:      "9..1234",
 		Converted: template.Amount{
@@ -345,3 +348,126 @@ func TestMapper_MapTransaction_StandardMemoIsEmpty(t *testing.T) {
 		t.Errorf("Memo = %q, want empty string for standard transaction", payload.Memo)
 	}
 }
+
+func TestSignedAmount_Directions(t *testing.T) {
+	tests := []struct {
+		direction template.Direction
+		want      float64
+	}{
+		{template.DirectionDebit, -10},
+		{template.DirectionTransfer, -10},
+		{template.DirectionCredit, 10},
+		{template.DirectionReversal, 10},
+	}
+
+	for _, tt := range tests {
+		tx := &template.Transaction{Direction: tt.direction, Converted: template.Amount{Value: 10, Currency: "MDL"}}
+		got, err := SignedAmount(tx)
+		if err != nil {
+			t.Fatalf("SignedAmount(%s) error = %v", tt.direction, err)
+		}
+		if got != tt.want {
+			t.Errorf("SignedAmount(%s) = %v, want %v", tt.direction, got, tt.want)
+		}
+	}
+}
+
+func TestMapper_MapTransaction_UnknownDirection(t *testing.T) {
+	mapper := NewMapper([]YNABAccount{{YNABAccountID: "account-1", Last4: "1234"}})
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 15, 30, 45, 0, time.UTC)}
+	tx := &template.Transaction{
+		Operation: "Otmena",
+		Card:      "9..1234",
+		Converted: template.Amount{Value: 100, Currency: "MDL"},
+	}
+
+	_, err := mapper.MapTransaction(msg, tx)
+	if err == nil || !strings.Contains(err.Error(), "unknown direction") {
+		t.Errorf("MapTransaction() error = %v, want unknown direction", err)
+	}
+}
+
+func TestMapper_MapTransaction_Split(t *testing.T) {
+	mapper := NewMapper([]YNABAccount{{YNABAccountID: "account-1", Last4: "1234"}})
+	mapper.SetCategory(template.KindFee, "fees-category")
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 15, 30, 45, 0, time.UTC)}
+	tx := &template.Transaction{
+		Operation: "Snyatie nalichnyh",
+		Direction: template.DirectionDebit,
+		Card:      "9..1234",
+		Converted: template.Amount{Value: 1000, Currency: "MDL"},
+		Address:   "ATM",
+		Components: []template.Component{
+			{Kind: template.KindFee, Converted: template.Amount{Value: 15, Currency: "MDL"}},
+			{Kind: template.KindCashback, Converted: template.Amount{Value: 2.5, Currency: "MDL"}},
+		},
+	}
+
+	payload, err := mapper.MapTransaction(msg, tx)
+	if err != nil {
+		t.Fatalf("MapTransaction() error = %v", err)
+	}
+
+	if payload.Amount != -1012500 {
+		t.Errorf("Amount = %d, want -1012500", payload.Amount)
+	}
+	want := []SubTransactionPayload{
+		{Amount: -1000000},
+		{Amount: -15000, CategoryID: "fees-category", Memo: "Fee"},
+		{Amount: 2500, Memo: "Cashback"},
+	}
+	if len(payload.Subtransactions) != len(want) {
+		t.Fatalf("Subtransactions = %+v, want %+v", payload.Subtransactions, want)
+	}
+	var sum int64
+	for i, sub := range payload.Subtransactions {
+		if sub != want[i] {
+			t.Errorf("Subtransactions[%d] = %+v, want %+v", i, sub, want[i])
+		}
+		sum += sub.Amount
+	}
+	if sum != payload.Amount {
+		t.Errorf("subtransactions add up to %d, want %d", sum, payload.Amount)
+	}
+
+	total, err := TotalAmount(tx)
+	if err != nil || total != -1012.5 {
+		t.Errorf("TotalAmount() = %v, %v, want -1012.5", total, err)
+	}
+}
+
+func TestMapper_MapTransaction_NoComponentsNoSplit(t *testing.T) {
+	mapper := NewMapper([]YNABAccount{{YNABAccountID: "account-1", Last4: "1234"}})
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 15, 30, 45, 0, time.UTC)}
+	tx := &template.Transaction{Direction: template.DirectionDebit, Card: "9..1234", Converted: template.Amount{Value: 10, Currency: "MDL"}}
+
+	payload, err := mapper.MapTransaction(msg, tx)
+	if err != nil {
+		t.Fatalf("MapTransaction() error = %v", err)
+	}
+	if payload.Subtransactions != nil {
+		t.Errorf("Subtransactions = %+v, want none", payload.Subtransactions)
+	}
+}
+
+func TestMapper_MatchAccount_CardsAndSenders(t *testing.T) {
+	mapper := NewMapper([]YNABAccount{
+		{YNABAccountID: "maib", Last4: "1234", Cards: []string{"5678"}},
+		{YNABAccountID: "exim", Last4: "1234", Sender: "EXIMBANK"},
+	})
+
+	tests := []struct {
+		sender, card, want string
+	}{
+		{"102", "*1234", "maib"},
+		{"102", "*5678", "maib"},
+		{"eximbank", "9..1234", "exim"},
+		{"EXIMBANK", "9..5678", "maib"},
+	}
+	for _, tt := range tests {
+		got, err := mapper.MatchAccount(tt.sender, &template.Transaction{Card: tt.card})
+		if err != nil || got != tt.want {
+			t.Errorf("MatchAccount(%s, %s) = %q, %v, want %q", tt.sender, tt.card, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ynab
+
+import (
+	"fmt"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+// YNAB rejects longer memos
+const maxMemoLength = 200
+
+// MemoData is what a memo template is executed with. All Transaction fields
+// are available directly, e.g. {{.Operation}} or {{.Original.Value}}.
+type MemoData struct {
+	*template.Transaction
+	Sender string
+	Time   time.Time
+	// SMS is the full text of the message
+	SMS   string
+	Last4 string
+	// Foreign is set when the amount was converted from another currency at
+	// Rate units of the converted currency per original unit, taken from
+	// RateSource.
+	Foreign    bool
+	Rate       float64
+	RateSource string
+}
+
+type MemoTemplate struct {
+	template *texttemplate.Template
+}
+
+// NewMemoTemplate parses a text/template memo format, e.g.
+// `{{printf "%.2f" .Original.Value}} {{.Original.Currency}} · card ..{{.Last4}}`.
+func NewMemoTemplate(text string) (*MemoTemplate, error) {
+	t, err := texttemplate.New("memo").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memo template: %w", err)
+	}
+	return &MemoTemplate{template: t}, nil
+}
+
+func (t *MemoTemplate) Render(msg *message.Message, tx *template.Transaction) (string, error) {
+	data := MemoData{
+		Transaction: tx,
+		Sender:      msg.Sender,
+		Time:        msg.Timestamp,
+		SMS:         msg.Content,
+		Last4:       cardLast4.FindString(tx.Card),
+		Rate:        1,
+	}
+	if tx.Original.Currency != "" && tx.Converted.Currency != "" &&
+		!strings.EqualFold(tx.Original.Currency, tx.Converted.Currency) && tx.Original.Value != 0 {
+		data.Foreign = true
+		data.Rate = tx.Converted.Value / tx.Original.Value
+		data.RateSource = "BNM"
+	}
+
+	var b strings.Builder
+	if err := t.template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render memo: %w", err)
+	}
+
+	memo := strings.TrimSpace(b.String())
+	if runes := []rune(memo); len(runes) > maxMemoLength {
+		memo = string(runes[:maxMemoLength])
+	}
+	return memo, nil
+}
@@ -0,0 +1,92 @@
+package ynab
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+const exampleMemo = `{{if .Foreign}}{{printf "%.2f" .Original.Value}} {{.Original.Currency}} @ {{printf "%.2f" .Rate}} ({{.RateSource}}) · {{end}}card ..{{.Last4}}`
+
+func TestMemoTemplate_Render(t *testing.T) {
+	memo, err := NewMemoTemplate(exampleMemo)
+	if err != nil {
+		t.Fatalf("NewMemoTemplate() error = %v", err)
+	}
+
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC), Sender: "102", Content: "Op: Tovary i uslugi"}
+	tx := &template.Transaction{
+		Card:      "*1234",
+		Original:  template.Amount{Value: 12.5, Currency: "EUR"},
+		Converted: template.Amount{Value: 243.125, Currency: "MDL"},
+	}
+
+	got, err := memo.Render(msg, tx)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "12.50 EUR @ 19.45 (BNM) · card ..1234"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	tx.Original = template.Amount{Value: 100, Currency: "MDL"}
+	tx.Converted = tx.Original
+	if got, _ := memo.Render(msg, tx); got != "card ..1234" {
+		t.Errorf("Render() = %q, want %q for a local purchase", got, "card ..1234")
+	}
+
+	raw, _ := NewMemoTemplate(`{{.Sender}}: {{.SMS}}`)
+	if got, _ := raw.Render(msg, tx); got != "102: Op: Tovary i uslugi" {
+		t.Errorf("Render() = %q", got)
+	}
+}
+
+func TestMemoTemplate_Errors(t *testing.T) {
+	if _, err := NewMemoTemplate("{{.Operation"); err == nil {
+		t.Error("NewMemoTemplate() expected parse error")
+	}
+
+	memo, err := NewMemoTemplate("{{.NoSuchField}}")
+	if err != nil {
+		t.Fatalf("NewMemoTemplate() error = %v", err)
+	}
+	if _, err := memo.Render(&message.Message{}, &template.Transaction{}); err == nil {
+		t.Error("Render() expected error for unknown field")
+	}
+
+	long, _ := NewMemoTemplate(`{{.SMS}}`)
+	got, _ := long.Render(&message.Message{Content: strings.Repeat("x", 300)}, &template.Transaction{})
+	if len(got) != maxMemoLength {
+		t.Errorf("Render() length = %d, want %d", len(got), maxMemoLength)
+	}
+}
+
+func TestMapper_MapTransaction_MemoTemplates(t *testing.T) {
+	mapper := NewMapper([]YNABAccount{
+		{YNABAccountID: "acc-1", Last4: "1234"},
+		{YNABAccountID: "acc-2", Last4: "5678"},
+	})
+	defaultMemo, _ := NewMemoTemplate(`{{.Operation}}`)
+	cardMemo, _ := NewMemoTemplate(`card ..{{.Last4}}`)
+	mapper.SetMemoTemplate("", defaultMemo)
+	mapper.SetMemoTemplate("acc-2", cardMemo)
+
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC), Sender: "102"}
+	for card, want := range map[string]string{"*1234": "Tovary i uslugi", "*5678": "card ..5678"} {
+		payload, err := mapper.MapTransaction(msg, &template.Transaction{
+			Operation: "Tovary i uslugi",
+			Card:      card,
+			Direction: template.DirectionDebit,
+			Converted: template.Amount{Value: 10, Currency: "MDL"},
+		})
+		if err != nil {
+			t.Fatalf("MapTransaction() error = %v", err)
+		}
+		if payload.Memo != want {
+			t.Errorf("card %s: Memo = %q, want %q", card, payload.Memo, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package ynab
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
+)
+
+const mirrorSection = "ynab_mirror"
+
+type MirrorClient interface {
+	GetAccountsSince(budgetID string, lastKnowledge int64) (*GetAccountsResponse, error)
+	GetTransactions(budgetID string, lastKnowledge int64) (*GetTransactionsResponse, error)
+}
+
+// MirrorBudget is the local copy of one budget's accounts and transactions,
+// along with the server knowledge each was last fetched at.
+type MirrorBudget struct {
+	AccountsKnowledge     int64         `json:"accounts_server_knowledge"`
+	TransactionsKnowledge int64         `json:"transactions_server_knowledge"`
+	Accounts              []Account     `json:"accounts"`
+	Transactions          []Transaction `json:"transactions"`
+	RefreshedAt           time.Time     `json:"refreshed_at"`
+}
+
+// Mirror keeps a copy of YNAB data in the data file. After the first full
+// fetch only the changes since the stored server knowledge are requested.
+type Mirror struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewMirror(filePath string) *Mirror {
+	return &Mirror{filePath: filePath}
+}
+
+func (m *Mirror) read() (map[string]*MirrorBudget, error) {
+	budgets := map[string]*MirrorBudget{}
+	if err := datastore.ReadSection(m.filePath, mirrorSection, &budgets); err != nil {
+		return nil, fmt.Errorf("failed to read YNAB mirror: %w", err)
+	}
+	return budgets, nil
+}
+
+// Budget returns the mirrored data of a budget, empty if it was never
+// refreshed.
+func (m *Mirror) Budget(budgetID string) (*MirrorBudget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	budgets, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+	if budget := budgets[budgetID]; budget != nil {
+		return budget, nil
+	}
+	return &MirrorBudget{}, nil
+}
+
+// Refresh applies the changes since the last refresh to the mirrored
+// budget and returns it with the number of changed transactions.
+func (m *Mirror) Refresh(client MirrorClient, budgetID string) (*MirrorBudget, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	budgets, err := m.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	budget := budgets[budgetID]
+	if budget == nil {
+		budget = &MirrorBudget{}
+		budgets[budgetID] = budget
+	}
+
+	accounts, err := client.GetAccountsSince(budgetID, budget.AccountsKnowledge)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	transactions, err := client.GetTransactions(budgetID, budget.TransactionsKnowledge)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	budget.Accounts = mergeAccounts(budget.Accounts, accounts.Data.Accounts)
+	budget.Transactions = mergeTransactions(budget.Transactions, transactions.Data.Transactions)
+	budget.AccountsKnowledge = accounts.Data.ServerKnowledge
+	budget.TransactionsKnowledge = transactions.Data.ServerKnowledge
+	budget.RefreshedAt = time.Now().UTC()
+
+	if err := datastore.WriteSection(m.filePath, mirrorSection, budgets); err != nil {
+		return nil, 0, fmt.Errorf("failed to write YNAB mirror: %w", err)
+	}
+	return budget, len(transactions.Data.Transactions), nil
+}
+
+func mergeAccounts(current, changed []Account) []Account {
+	index := make(map[string]int, len(current))
+	for i, acc := range current {
+		index[acc.ID] = i
+	}
+	for _, acc := range changed {
+		if i, ok := index[acc.ID]; ok {
+			current[i] = acc
+			continue
+		}
+		index[acc.ID] = len(current)
+		current = append(current, acc)
+	}
+
+	result := current[:0]
+	for _, acc := range current {
+		if !acc.Deleted {
+			result = append(result, acc)
+		}
+	}
+	return result
+}
+
+func mergeTransactions(current, changed []Transaction) []Transaction {
+	index := make(map[string]int, len(current))
+	for i, tx := range current {
+		index[tx.ID] = i
+	}
+	for _, tx := range changed {
+		if i, ok := index[tx.ID]; ok {
+			current[i] = tx
+			continue
+		}
+		index[tx.ID] = len(current)
+		current = append(current, tx)
+	}
+
+	result := current[:0]
+	for _, tx := range current {
+		if !tx.Deleted {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package ynab
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type mockMirrorClient struct {
+	accounts     map[int64][]Account
+	transactions map[int64][]Transaction
+	knowledge    int64
+	requested    []int64
+}
+
+func (m *mockMirrorClient) GetAccountsSince(budgetID string, lastKnowledge int64) (*GetAccountsResponse, error) {
+	resp := &GetAccountsResponse{}
+	resp.Data.Accounts = m.accounts[lastKnowledge]
+	resp.Data.ServerKnowledge = m.knowledge
+	return resp, nil
+}
+
+func (m *mockMirrorClient) GetTransactions(budgetID string, lastKnowledge int64) (*GetTransactionsResponse, error) {
+	m.requested = append(m.requested, lastKnowledge)
+	resp := &GetTransactionsResponse{}
+	resp.Data.Transactions = m.transactions[lastKnowledge]
+	resp.Data.ServerKnowledge = m.knowledge
+	return resp, nil
+}
+
+func TestMirror_Refresh_AppliesDelta(t *testing.T) {
+	mirror := NewMirror(filepath.Join(t.TempDir(), "data.json"))
+	client := &mockMirrorClient{
+		knowledge: 10,
+		accounts: map[int64][]Account{
+			0: {{ID: "acc-1", Name: "Card 1234"}},
+		},
+		transactions: map[int64][]Transaction{
+			0: {
+				{ID: "tx-1", Amount: -10000, PayeeName: "Shop"},
+				{ID: "tx-2", Amount: -20000, PayeeName: "Cafe"},
+			},
+			10: {
+				{ID: "tx-1", Amount: -15000, PayeeName: "Shop"},
+				{ID: "tx-2", Deleted: true},
+				{ID: "tx-3", Amount: 5000, PayeeName: "Refund"},
+			},
+		},
+	}
+
+	if _, changed, err := mirror.Refresh(client, "budget-1"); err != nil || changed != 2 {
+		t.Fatalf("Refresh() = %d, %v; want 2 changes", changed, err)
+	}
+
+	client.knowledge = 12
+	budget, changed, err := mirror.Refresh(client, "budget-1")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if changed != 3 {
+		t.Errorf("changed = %d, want 3", changed)
+	}
+	if client.requested[1] != 10 {
+		t.Errorf("second refresh requested knowledge %d, want 10", client.requested[1])
+	}
+
+	stored, err := mirror.Budget("budget-1")
+	if err != nil {
+		t.Fatalf("Budget() error = %v", err)
+	}
+	for _, b := range []*MirrorBudget{budget, stored} {
+		if b.TransactionsKnowledge != 12 || b.AccountsKnowledge != 12 {
+			t.Errorf("knowledge = %d/%d, want 12", b.TransactionsKnowledge, b.AccountsKnowledge)
+		}
+		if len(b.Accounts) != 1 {
+			t.Errorf("accounts = %d, want 1", len(b.Accounts))
+		}
+		if len(b.Transactions) != 2 || b.Transactions[0].Amount != -15000 || b.Transactions[1].ID != "tx-3" {
+			t.Errorf("transactions = %+v", b.Transactions)
+		}
+	}
+}
+
+func TestMirror_Budget_Unknown(t *testing.T) {
+	mirror := NewMirror(filepath.Join(t.TempDir(), "data.json"))
+
+	budget, err := mirror.Budget("missing")
+	if err != nil {
+		t.Fatalf("Budget() error = %v", err)
+	}
+	if budget.TransactionsKnowledge != 0 || len(budget.Transactions) != 0 {
+		t.Errorf("Budget() = %+v, want empty", budget)
+	}
+}
@@ -0,0 +1,121 @@
+package ynab
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+var (
+	clearedValues = map[string]bool{"cleared": true, "uncleared": true}
+	flagColors    = map[string]bool{
+		"red": true, "orange": true, "yellow": true, "green": true, "blue": true, "purple": true,
+	}
+)
+
+// State is how an imported transaction shows up for review in YNAB. Empty
+// fields keep the value set before, ending with cleared, YNAB's default
+// approval and no flag.
+type State struct {
+	Cleared   string
+	Approved  *bool
+	FlagColor string
+}
+
+func (s State) Validate() error {
+	if s.Cleared != "" && !clearedValues[s.Cleared] {
+		return fmt.Errorf("invalid cleared %q (want cleared or uncleared)", s.Cleared)
+	}
+	if s.FlagColor != "" && !flagColors[s.FlagColor] {
+		return fmt.Errorf("invalid flag color %q (want red, orange, yellow, green, blue or purple)", s.FlagColor)
+	}
+	return nil
+}
+
+func (s State) apply(payload *TransactionPayload) {
+	if s.Cleared != "" {
+		payload.Cleared = s.Cleared
+	}
+	if s.Approved != nil {
+		approved := *s.Approved
+		payload.Approved = &approved
+	}
+	if s.FlagColor != "" {
+		payload.FlagColor = s.FlagColor
+	}
+}
+
+// StateRule sets the State of transactions matching all of its non-empty
+// conditions.
+type StateRule struct {
+	Sender string
+	Cards  []string
+	// Kind "unknown" matches transactions no kind was recognized for
+	Kind      string
+	Direction string
+	Status    string
+	// MinAmount compares with the converted amount, ignoring its sign
+	MinAmount float64
+	// Foreign matches transactions not made in the converted currency
+	Foreign bool
+	State   State
+}
+
+func (r *StateRule) Validate() error {
+	if r.Kind != "" && r.Kind != "unknown" {
+		if _, err := template.ParseKind(r.Kind); err != nil {
+			return err
+		}
+	}
+	if r.Direction != "" {
+		if _, err := template.ParseDirection(r.Direction); err != nil {
+			return err
+		}
+	}
+	return r.State.Validate()
+}
+
+func (r *StateRule) Matches(sender string, tx *template.Transaction) bool {
+	if r.Sender != "" && !strings.EqualFold(r.Sender, sender) {
+		return false
+	}
+	if len(r.Cards) > 0 && !containsCard(r.Cards, cardLast4.FindString(tx.Card)) {
+		return false
+	}
+	if r.Kind != "" {
+		kind := string(tx.Kind)
+		if kind == "" {
+			kind = "unknown"
+		}
+		if r.Kind != kind {
+			return false
+		}
+	}
+	if r.Direction != "" && r.Direction != string(tx.Direction) {
+		return false
+	}
+	if r.Status != "" && !strings.EqualFold(r.Status, tx.Status) {
+		return false
+	}
+	if r.MinAmount > 0 && math.Abs(tx.Converted.Value) < r.MinAmount {
+		return false
+	}
+	if r.Foreign && (tx.Original.Currency == "" || strings.EqualFold(tx.Original.Currency, tx.Converted.Currency)) {
+		return false
+	}
+	return true
+}
+
+var cardLast4 = regexp.MustCompile(`\d{4}$`)
+
+func containsCard(cards []string, last4 string) bool {
+	for _, card := range cards {
+		if last4 != "" && card == last4 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package ynab
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apmyp/ynab_importer_go/message"
+	"github.com/apmyp/ynab_importer_go/template"
+)
+
+func TestStateRule_Matches(t *testing.T) {
+	tx := &template.Transaction{
+		Card:      "9..1234",
+		Kind:      template.KindPurchase,
+		Direction: template.DirectionDebit,
+		Status:    "Odobrena",
+		Original:  template.Amount{Value: 100, Currency: "EUR"},
+		Converted: template.Amount{Value: 2000, Currency: "MDL"},
+	}
+
+	tests := []struct {
+		name string
+		rule StateRule
+		want bool
+	}{
+		{"empty rule", StateRule{}, true},
+		{"sender", StateRule{Sender: "maib"}, false},
+		{"card", StateRule{Cards: []string{"5678", "1234"}}, true},
+		{"other card", StateRule{Cards: []string{"5678"}}, false},
+		{"kind", StateRule{Kind: "purchase"}, true},
+		{"unknown kind", StateRule{Kind: "unknown"}, false},
+		{"direction", StateRule{Direction: "credit"}, false},
+		{"status", StateRule{Status: "odobrena"}, true},
+		{"min amount", StateRule{MinAmount: 1500}, true},
+		{"below min amount", StateRule{MinAmount: 2500}, false},
+		{"foreign", StateRule{Foreign: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches("102", tx); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	local := &template.Transaction{Original: template.Amount{Value: 5, Currency: "MDL"}, Converted: template.Amount{Value: 5, Currency: "MDL"}}
+	if (&StateRule{Foreign: true}).Matches("102", local) {
+		t.Error("Foreign rule matched a local currency transaction")
+	}
+	if !(&StateRule{Kind: "unknown"}).Matches("102", local) {
+		t.Error("unknown kind rule should match a transaction without kind")
+	}
+}
+
+func TestStateRule_Validate(t *testing.T) {
+	valid := []StateRule{
+		{Kind: "unknown", State: State{Cleared: "uncleared"}},
+		{Direction: "debit", State: State{FlagColor: "red"}},
+	}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", r, err)
+		}
+	}
+
+	invalid := []StateRule{
+		{Kind: "groceries"},
+		{Direction: "out"},
+		{State: State{Cleared: "reconciled"}},
+		{State: State{FlagColor: "pink"}},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected error", r)
+		}
+	}
+}
+
+func TestMapper_MapTransaction_States(t *testing.T) {
+	notApproved := false
+	mapper := NewMapper([]YNABAccount{
+		{YNABAccountID: "acc-1", Last4: "1234", State: State{Cleared: "uncleared"}},
+		{YNABAccountID: "acc-2", Last4: "5678"},
+	})
+	mapper.SetStateRules([]StateRule{
+		{MinAmount: 1000, State: State{FlagColor: "red"}},
+		{Kind: "unknown", State: State{Approved: &notApproved}},
+		{MinAmount: 5000, State: State{FlagColor: "purple"}},
+	})
+
+	msg := &message.Message{Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC), Sender: "102"}
+
+	payload, err := mapper.MapTransaction(msg, &template.Transaction{
+		Card:      "9..1234",
+		Kind:      template.KindPurchase,
+		Direction: template.DirectionDebit,
+		Converted: template.Amount{Value: 1500, Currency: "MDL"},
+	})
+	if err != nil {
+		t.Fatalf("MapTransaction() error = %v", err)
+	}
+	if payload.Cleared != "uncleared" || payload.FlagColor != "red" || payload.Approved != nil {
+		t.Errorf("payload state = %s/%v/%s, want uncleared, default approval, red", payload.Cleared, payload.Approved, payload.FlagColor)
+	}
+
+	payload, err = mapper.MapTransaction(msg, &template.Transaction{
+		Card:      "9..5678",
+		Direction: template.DirectionDebit,
+		Converted: template.Amount{Value: 6000, Currency: "MDL"},
+	})
+	if err != nil {
+		t.Fatalf("MapTransaction() error = %v", err)
+	}
+	if payload.Cleared != "cleared" || payload.FlagColor != "purple" || payload.Approved == nil || *payload.Approved {
+		t.Errorf("payload state = %s/%v/%s, want cleared, not approved, purple", payload.Cleared, payload.Approved, payload.FlagColor)
+	}
+}
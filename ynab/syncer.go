@@ -2,6 +2,7 @@ package ynab
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/apmyp/ynab_importer_go/message"
@@ -12,6 +13,7 @@ type YNABClient interface {
 	CreateTransactions(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error)
 	GetAccounts(budgetID string) (*GetAccountsResponse, error)
 	CreateAccount(budgetID string, payload CreateAccountPayload) (*CreateAccountResponse, error)
+	UpdateTransactions(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error)
 }
 
 type Syncer struct {
@@ -20,6 +22,10 @@ type Syncer struct {
 	mapper    *Mapper
 	budgetID  string
 	startDate time.Time
+
+	manual      []Transaction
+	matchWindow int
+	lifecycle   *LifecycleStore
 }
 
 type SyncResult struct {
@@ -27,6 +33,13 @@ type SyncResult struct {
 	Synced  int
 	Skipped int
 	Failed  []string
+	// Linked counts transactions entered by hand in YNAB that were marked
+	// cleared and tied to their SMS instead of creating a duplicate.
+	Linked    int
+	Ambiguous []string
+	// Updated counts YNAB transactions changed by a later event of the same
+	// purchase, such as the settlement of an authorization.
+	Updated int
 }
 
 func NewSyncer(store *SyncStore, client YNABClient, mapper *Mapper, budgetID string, startDate time.Time) *Syncer {
@@ -39,8 +52,22 @@ func NewSyncer(store *SyncStore, client YNABClient, mapper *Mapper, budgetID str
 	}
 }
 
-func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Transaction) (*SyncResult, error) {
-	result := &SyncResult{
+// SetManualTransactions gives the budget's existing YNAB transactions, so
+// that ones entered by hand are linked to their SMS instead of duplicated.
+func (s *Syncer) SetManualTransactions(transactions []Transaction, windowDays int) {
+	s.manual = transactions
+	s.matchWindow = windowDays
+}
+
+// SetLifecycleStore enables following purchases from authorization to
+// settlement, decline or reversal. Later events update the YNAB transaction
+// created for the first one instead of adding another.
+func (s *Syncer) SetLifecycleStore(store *LifecycleStore) {
+	s.lifecycle = store
+}
+
+func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Transaction) (result *SyncResult, err error) {
+	result = &SyncResult{
 		Total: len(transactions),
 	}
 
@@ -48,8 +75,25 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 		return nil, fmt.Errorf("messages and transactions length mismatch: %d vs %d", len(messages), len(transactions))
 	}
 
+	var records []*LifecycleRecord
+	if s.lifecycle != nil {
+		if records, err = s.lifecycle.Records(s.budgetID); err != nil {
+			return nil, err
+		}
+	}
+
 	var toSync []TransactionPayload
 	var toSyncImportIDs []string
+	var toUpdate []TransactionUpdate
+	var toUpdateImportIDs []string
+	// Where the YNAB change of a purchase seen in this run is queued, so
+	// that its later events in the same run amend it.
+	creating := make(map[*LifecycleRecord]int)
+	updating := make(map[*LifecycleRecord]int)
+	// Events folded into another queued change, by import ID, with the
+	// purchase whose change they amend
+	folded := make(map[string]*LifecycleRecord)
+	claimed := make(map[string]bool)
 
 	for i := 0; i < len(transactions); i++ {
 		msg := messages[i]
@@ -60,7 +104,7 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 			continue
 		}
 
-		importID := s.mapper.GenerateImportID(msg, tx)
+		importID := GenerateImportID(msg, tx)
 
 		synced, err := s.store.IsSynced(importID)
 		if err != nil {
@@ -71,6 +115,16 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 			continue
 		}
 
+		stage := template.StageOf(tx)
+		key := PurchaseKey(msg.Sender, tx)
+		open := findOpen(records, key, stage, msg.Timestamp)
+		// A decline only matters when it ends an earlier authorization
+		if open == nil && stage == template.StageDeclined {
+			slog.Debug("skipping decline without an authorization", "import_id", importID)
+			result.Skipped++
+			continue
+		}
+
 		payload, err := s.mapper.MapTransaction(msg, tx)
 		if err != nil {
 			result.Skipped++
@@ -78,14 +132,116 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 			continue
 		}
 
+		if open != nil {
+			slog.Debug("purchase moved on", "import_id", importID, "first_import_id", open.ImportID,
+				"from", open.Stage, "to", stage, "amount", payload.Amount)
+			if idx, ok := creating[open]; ok {
+				setAmount(&toSync[idx], advance(open, stage, importID, payload.Amount, msg.Timestamp))
+				toSync[idx].Cleared = payload.Cleared
+				folded[importID] = open
+				continue
+			}
+			if idx, ok := updating[open]; ok {
+				amount := advance(open, stage, importID, payload.Amount, msg.Timestamp)
+				toUpdate[idx].Amount = updatedAmount(open, amount)
+				toUpdate[idx].Cleared = payload.Cleared
+				folded[importID] = open
+				continue
+			}
+			if err := s.resolveTransactionID(open); err != nil {
+				// It would fail the same way on every run, so the purchase
+				// is no longer followed and the event is not retried
+				records = removeRecord(records, open)
+				folded[importID] = nil
+				result.Skipped++
+				result.Failed = append(result.Failed, fmt.Sprintf("Failed to apply %s event: %v", stage, err))
+				continue
+			}
+			amount := advance(open, stage, importID, payload.Amount, msg.Timestamp)
+			updating[open] = len(toUpdate)
+			toUpdate = append(toUpdate, TransactionUpdate{
+				ID:      open.TransactionID,
+				Amount:  updatedAmount(open, amount),
+				Cleared: payload.Cleared,
+			})
+			toUpdateImportIDs = append(toUpdateImportIDs, importID)
+			result.Updated++
+			continue
+		}
+
+		var record *LifecycleRecord
+		if s.lifecycle != nil && (stage == template.StageAuthorized || stage == template.StageSettled) {
+			record = &LifecycleRecord{
+				Key:       key,
+				BudgetID:  s.budgetID,
+				ImportID:  importID,
+				Stage:     stage,
+				Amount:    payload.Amount,
+				Split:     len(payload.Subtransactions) > 0,
+				StartedAt: msg.Timestamp,
+				Events:    []LifecycleEvent{{Stage: stage, ImportID: importID, Amount: payload.Amount, At: msg.Timestamp}},
+			}
+			records = append(records, record)
+		}
+
+		var matches []Transaction
+		for _, match := range ManualMatches(s.manual, payload, s.matchWindow) {
+			if !claimed[match.ID] {
+				matches = append(matches, match)
+			}
+		}
+		if len(matches) == 1 {
+			slog.Debug("linking to a transaction entered by hand", "import_id", importID,
+				"transaction_id", matches[0].ID, "amount", payload.Amount)
+			claimed[matches[0].ID] = true
+			if record != nil {
+				record.TransactionID = matches[0].ID
+				record.Split = false
+				updating[record] = len(toUpdate)
+			}
+			toUpdate = append(toUpdate, TransactionUpdate{
+				ID:       matches[0].ID,
+				Cleared:  payload.Cleared,
+				ImportID: importID,
+			})
+			toUpdateImportIDs = append(toUpdateImportIDs, importID)
+			result.Linked++
+			continue
+		}
+		if len(matches) > 1 {
+			result.Ambiguous = append(result.Ambiguous, fmt.Sprintf("%s %s %.2f matches %d transactions entered by hand; created a new one",
+				payload.Date, payload.PayeeName, float64(payload.Amount)/1000, len(matches)))
+		}
+
+		if record != nil {
+			creating[record] = len(toSync)
+		}
 		toSync = append(toSync, *payload)
 		toSyncImportIDs = append(toSyncImportIDs, importID)
 	}
 
-	if len(toSync) == 0 {
-		return result, nil
+	if len(toUpdate) > 0 {
+		if _, err := s.client.UpdateTransactions(s.budgetID, toUpdate); err != nil {
+			result.Linked, result.Updated = 0, 0
+			return result, fmt.Errorf("failed to update transactions: %w", err)
+		}
+		for _, importID := range toUpdateImportIDs {
+			if err := s.recordSync(importID); err != nil {
+				return result, err
+			}
+		}
 	}
 
+	// YNAB transaction IDs by import ID, for the batches that were created
+	created := make(map[string]string)
+	// What reached YNAB is recorded even when a later batch fails, so that
+	// later events of those purchases update them instead of duplicating
+	defer func() {
+		if finishErr := s.finish(records, creating, created, folded); finishErr != nil && err == nil {
+			err = finishErr
+		}
+	}()
+
 	// YNAB API limit: 100 transactions per request
 	batchSize := 100
 	for i := 0; i < len(toSync); i += batchSize {
@@ -97,18 +253,21 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 		batch := toSync[i:end]
 		batchImportIDs := toSyncImportIDs[i:end]
 
-		_, err := s.client.CreateTransactions(s.budgetID, batch)
+		response, err := s.client.CreateTransactions(s.budgetID, batch)
 		if err != nil {
 			return result, fmt.Errorf("failed to create transactions: %w", err)
 		}
+		createdIDs := make(map[string]string)
+		if response != nil {
+			for _, tx := range response.Data.Transactions {
+				createdIDs[tx.ImportID] = tx.ID
+			}
+		}
 
 		for _, importID := range batchImportIDs {
-			record := &SyncRecord{
-				ImportID: importID,
-				SyncedAt: time.Now().UTC(),
-			}
-			if err := s.store.RecordSync(record); err != nil {
-				return result, fmt.Errorf("failed to record sync: %w", err)
+			created[importID] = createdIDs[importID]
+			if err := s.recordSync(importID); err != nil {
+				return result, err
 			}
 			result.Synced++
 		}
@@ -116,3 +275,116 @@ func (s *Syncer) Sync(messages []*message.Message, transactions []*template.Tran
 
 	return result, nil
 }
+
+// finish records the events folded into changes that reached YNAB and
+// saves the purchases being followed. A purchase whose transaction was not
+// created is dropped, so its events are synced afresh on the next run.
+func (s *Syncer) finish(records []*LifecycleRecord, creating map[*LifecycleRecord]int, created map[string]string, folded map[string]*LifecycleRecord) error {
+	applied := func(record *LifecycleRecord) bool {
+		if record == nil {
+			return true
+		}
+		if _, pending := creating[record]; !pending {
+			return true
+		}
+		_, ok := created[record.ImportID]
+		return ok
+	}
+
+	for importID, record := range folded {
+		if !applied(record) {
+			continue
+		}
+		if err := s.recordSync(importID); err != nil {
+			return err
+		}
+	}
+
+	if s.lifecycle == nil {
+		return nil
+	}
+	var kept []*LifecycleRecord
+	for _, record := range records {
+		if !applied(record) {
+			continue
+		}
+		if _, ok := creating[record]; ok {
+			record.TransactionID = created[record.ImportID]
+		}
+		kept = append(kept, record)
+	}
+	return s.lifecycle.Replace(s.budgetID, kept)
+}
+
+func (s *Syncer) recordSync(importID string) error {
+	record := &SyncRecord{
+		ImportID: importID,
+		SyncedAt: time.Now().UTC(),
+	}
+	if err := s.store.RecordSync(record); err != nil {
+		return fmt.Errorf("failed to record sync: %w", err)
+	}
+	return nil
+}
+
+func removeRecord(records []*LifecycleRecord, record *LifecycleRecord) []*LifecycleRecord {
+	for i, r := range records {
+		if r == record {
+			return append(records[:i], records[i+1:]...)
+		}
+	}
+	return records
+}
+
+// resolveTransactionID looks up the YNAB transaction of a purchase whose ID
+// was not returned on creation by its import ID in the mirror.
+func (s *Syncer) resolveTransactionID(record *LifecycleRecord) error {
+	if record.TransactionID != "" {
+		return nil
+	}
+	for _, tx := range s.manual {
+		if tx.ImportID == record.ImportID {
+			record.TransactionID = tx.ID
+			return nil
+		}
+	}
+	return fmt.Errorf("no YNAB transaction found with import ID %s", record.ImportID)
+}
+
+// advance moves the purchase to stage and returns the amount its YNAB
+// transaction should have: the new amount, or zero once declined or
+// reversed.
+func advance(record *LifecycleRecord, stage template.Stage, importID string, amount int64, at time.Time) int64 {
+	if stage == template.StageDeclined || stage == template.StageReversed {
+		amount = 0
+	}
+	record.Stage = stage
+	record.Amount = amount
+	record.Events = append(record.Events, LifecycleEvent{Stage: stage, ImportID: importID, Amount: amount, At: at})
+	return amount
+}
+
+// updatedAmount returns the amount to send for the purchase's existing
+// YNAB transaction. YNAB cannot change the subtransactions of an existing
+// split, so a split keeps its amount and is left to be corrected by hand.
+func updatedAmount(record *LifecycleRecord, amount int64) *int64 {
+	if record.Split {
+		slog.Warn("not updating the amount of a split transaction; change it in YNAB",
+			"import_id", record.ImportID, "transaction_id", record.TransactionID, "amount", amount)
+		return nil
+	}
+	return &amount
+}
+
+// setAmount changes the amount of a queued payload, keeping the split
+// parts adding up to it. A cancelled purchase drops the split.
+func setAmount(payload *TransactionPayload, amount int64) {
+	if len(payload.Subtransactions) > 0 {
+		if amount == 0 {
+			payload.Subtransactions = nil
+		} else {
+			payload.Subtransactions[0].Amount += amount - payload.Amount
+		}
+	}
+	payload.Amount = amount
+}
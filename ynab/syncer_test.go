@@ -14,6 +14,7 @@ type mockClient struct {
 	createTransactionsFunc func(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error)
 	getAccountsFunc        func(budgetID string) (*GetAccountsResponse, error)
 	createAccountFunc      func(budgetID string, payload CreateAccountPayload) (*CreateAccountResponse, error)
+	updateTransactionsFunc func(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error)
 }
 
 func (m *mockClient) CreateTransactions(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error) {
@@ -23,6 +24,13 @@ func (m *mockClient) CreateTransactions(budgetID string, transactions []Transact
 	return &CreateTransactionsResponse{}, nil
 }
 
+func (m *mockClient) UpdateTransactions(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error) {
+	if m.updateTransactionsFunc != nil {
+		return m.updateTransactionsFunc(budgetID, transactions)
+	}
+	return &UpdateTransactionsResponse{}, nil
+}
+
 func (m *mockClient) GetAccounts(budgetID string) (*GetAccountsResponse, error) {
 	if m.getAccountsFunc != nil {
 		return m.getAccountsFunc(budgetID)
@@ -85,9 +93,9 @@ func TestSyncer_Sync_FiltersByDate(t *testing.T) {
 	}
 
 	transactions := []*template.Transaction{
-		{Card: "9..1234", Converted: template.Amount{Value: 100, Currency: "MDL"}, Operation: "Debitare"},
-		{Card: "9..1234", Converted: template.Amount{Value: 200, Currency: "MDL"}, Operation: "Debitare"},
-		{Card: "9..1234", Converted: template.Amount{Value: 300, Currency: "MDL"}, Operation: "Debitare"},
+		{Card: "9..1234", Converted: template.Amount{Value: 100, Currency: "MDL"}, Operation: "Debitare", Direction: template.DirectionDebit},
+		{Card: "9..1234", Converted: template.Amount{Value: 200, Currency: "MDL"}, Operation: "Debitare", Direction: template.DirectionDebit},
+		{Card: "9..1234", Converted: template.Amount{Value: 300, Currency: "MDL"}, Operation: "Debitare", Direction: template.DirectionDebit},
 	}
 
 	result, err := syncer.Sync(messages, transactions)
@@ -125,10 +133,11 @@ func TestSyncer_Sync_SkipsAlreadySynced(t *testing.T) {
 		Card:      "9..1234",
 		Converted: template.Amount{Value: 100, Currency: "MDL"},
 		Operation: "Debitare",
+		Direction: template.DirectionDebit,
 	}
 
 	// Record as already synced
-	importID := mapper.GenerateImportID(msg, tx)
+	importID := GenerateImportID(msg, tx)
 	store.RecordSync(&SyncRecord{ImportID: importID, SyncedAt: time.Now()})
 
 	client := &mockClient{
@@ -175,6 +184,7 @@ func TestSyncer_Sync_HandlesAPIError(t *testing.T) {
 		Card:      "9..1234",
 		Converted: template.Amount{Value: 100, Currency: "MDL"},
 		Operation: "Debitare",
+		Direction: template.DirectionDebit,
 	}
 
 	_, err := syncer.Sync([]*message.Message{msg}, []*template.Transaction{tx})
@@ -226,6 +236,7 @@ func TestSyncer_Sync_BatchesTransactions(t *testing.T) {
 			Card:      "9..1234",
 			Converted: template.Amount{Value: float64(100 + i), Currency: "MDL"},
 			Operation: "Debitare",
+			Direction: template.DirectionDebit,
 		})
 	}
 
@@ -243,3 +254,62 @@ func TestSyncer_Sync_BatchesTransactions(t *testing.T) {
 		t.Errorf("Synced = %d, want 150", result.Synced)
 	}
 }
+
+func TestSyncer_Sync_LinksManualTransactions(t *testing.T) {
+	store, _ := NewSyncStore(t.TempDir() + "/data.json")
+	defer store.Close()
+
+	mapper := NewMapper([]YNABAccount{{YNABAccountID: "acc-1", Last4: "1234"}})
+	startDate, _ := time.Parse("2006-01-02", "2026-01-01")
+
+	messages := []*message.Message{
+		{Timestamp: time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC), Sender: "102"},
+		{Timestamp: time.Date(2026, 1, 11, 10, 0, 0, 0, time.UTC), Sender: "102"},
+	}
+	transactions := []*template.Transaction{
+		{Card: "9..1234", Address: "LINELLA SRL", Converted: template.Amount{Value: 150, Currency: "MDL"}, Direction: template.DirectionDebit},
+		{Card: "9..1234", Address: "KFC Botanica", Converted: template.Amount{Value: 80, Currency: "MDL"}, Direction: template.DirectionDebit},
+	}
+
+	var updates []TransactionUpdate
+	var created []TransactionPayload
+	client := &mockClient{
+		updateTransactionsFunc: func(budgetID string, transactions []TransactionUpdate) (*UpdateTransactionsResponse, error) {
+			updates = append(updates, transactions...)
+			return &UpdateTransactionsResponse{}, nil
+		},
+		createTransactionsFunc: func(budgetID string, transactions []TransactionPayload) (*CreateTransactionsResponse, error) {
+			created = append(created, transactions...)
+			return &CreateTransactionsResponse{}, nil
+		},
+	}
+
+	syncer := NewSyncer(store, client, mapper, "test-budget", startDate)
+	syncer.SetManualTransactions([]Transaction{
+		{ID: "manual-1", AccountID: "acc-1", Date: "2026-01-09", Amount: -150000, PayeeName: "Linella", Cleared: "uncleared"},
+		{ID: "manual-2", AccountID: "acc-1", Date: "2026-01-11", Amount: -80000, PayeeName: "KFC"},
+		{ID: "manual-3", AccountID: "acc-1", Date: "2026-01-12", Amount: -80000, PayeeName: "KFC"},
+	}, 3)
+
+	result, err := syncer.Sync(messages, transactions)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if result.Linked != 1 || len(updates) != 1 {
+		t.Fatalf("Linked = %d, updates = %+v, want one link", result.Linked, updates)
+	}
+	if updates[0].ID != "manual-1" || updates[0].Cleared != "cleared" || updates[0].ImportID != GenerateImportID(messages[0], transactions[0]) {
+		t.Errorf("update = %+v", updates[0])
+	}
+	if synced, _ := store.IsSynced(updates[0].ImportID); !synced {
+		t.Error("linked transaction should be recorded as synced")
+	}
+
+	if len(created) != 1 || result.Synced != 1 {
+		t.Errorf("created = %d, Synced = %d, want the ambiguous one created", len(created), result.Synced)
+	}
+	if len(result.Ambiguous) != 1 {
+		t.Errorf("Ambiguous = %v, want one entry", result.Ambiguous)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"os"
 	"sync"
+
+	"github.com/apmyp/ynab_importer_go/datastore"
 )
 
 type SyncStore struct {
@@ -55,12 +57,7 @@ func (s *SyncStore) readFile() (*dataFile, error) {
 }
 
 func (s *SyncStore) writeFile(data *dataFile) error {
-	content, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(s.filePath, content, 0600)
+	return datastore.WriteSection(s.filePath, "ynab_synced_transactions", data.YNABSyncedTransactions)
 }
 
 func (s *SyncStore) IsSynced(importID string) (bool, error) {
@@ -1,6 +1,10 @@
 package ynab
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type TransactionPayload struct {
 	AccountID string `json:"account_id"`
@@ -9,7 +13,20 @@ type TransactionPayload struct {
 	PayeeName string `json:"payee_name,omitempty"`
 	Memo      string `json:"memo,omitempty"`
 	Cleared   string `json:"cleared"`
+	Approved  *bool  `json:"approved,omitempty"`
+	FlagColor string `json:"flag_color,omitempty"`
 	ImportID  string `json:"import_id,omitempty"`
+
+	Subtransactions []SubTransactionPayload `json:"subtransactions,omitempty"`
+}
+
+// SubTransactionPayload is one part of a split transaction. The amounts of
+// all parts add up to the transaction's amount.
+type SubTransactionPayload struct {
+	Amount     int64  `json:"amount"`
+	PayeeName  string `json:"payee_name,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+	Memo       string `json:"memo,omitempty"`
 }
 
 type SyncRecord struct {
@@ -17,9 +34,15 @@ type SyncRecord struct {
 	SyncedAt time.Time `json:"synced_at"`
 }
 
+// YNABAccount maps the cards ending in Last4 or in any of Cards to a YNAB
+// account. With Sender set it only applies to that sender's messages.
+// State applies to all transactions of the account.
 type YNABAccount struct {
-	YNABAccountID string `json:"ynab_account_id"`
-	Last4         string `json:"last4"`
+	YNABAccountID string   `json:"ynab_account_id"`
+	Last4         string   `json:"last4"`
+	Cards         []string `json:"cards,omitempty"`
+	Sender        string   `json:"sender,omitempty"`
+	State         State    `json:"-"`
 }
 
 type CreateTransactionsRequest struct {
@@ -36,6 +59,24 @@ type CreateTransactionsResponse struct {
 	} `json:"data"`
 }
 
+// TransactionUpdate changes an existing transaction, identified by ID.
+type TransactionUpdate struct {
+	ID       string `json:"id"`
+	Amount   *int64 `json:"amount,omitempty"`
+	Cleared  string `json:"cleared,omitempty"`
+	ImportID string `json:"import_id,omitempty"`
+}
+
+type UpdateTransactionsRequest struct {
+	Transactions []TransactionUpdate `json:"transactions"`
+}
+
+type UpdateTransactionsResponse struct {
+	Data struct {
+		TransactionIDs []string `json:"transaction_ids"`
+	} `json:"data"`
+}
+
 type ErrorResponse struct {
 	Error struct {
 		ID     string `json:"id"`
@@ -55,7 +96,30 @@ type Account struct {
 
 type GetAccountsResponse struct {
 	Data struct {
-		Accounts []Account `json:"accounts"`
+		Accounts        []Account `json:"accounts"`
+		ServerKnowledge int64     `json:"server_knowledge"`
+	} `json:"data"`
+}
+
+// Transaction is a transaction as stored in YNAB, whether imported or
+// entered by hand.
+type Transaction struct {
+	ID        string `json:"id"`
+	Date      string `json:"date"`
+	Amount    int64  `json:"amount"`
+	Memo      string `json:"memo,omitempty"`
+	Cleared   string `json:"cleared"`
+	Approved  bool   `json:"approved"`
+	AccountID string `json:"account_id"`
+	PayeeName string `json:"payee_name,omitempty"`
+	ImportID  string `json:"import_id,omitempty"`
+	Deleted   bool   `json:"deleted"`
+}
+
+type GetTransactionsResponse struct {
+	Data struct {
+		Transactions    []Transaction `json:"transactions"`
+		ServerKnowledge int64         `json:"server_knowledge"`
 	} `json:"data"`
 }
 
@@ -75,6 +139,38 @@ type CreateAccountResponse struct {
 	} `json:"data"`
 }
 
+type Category struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Hidden  bool   `json:"hidden"`
+	Deleted bool   `json:"deleted"`
+}
+
+type CategoryGroup struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Categories []Category `json:"categories"`
+}
+
+type GetCategoriesResponse struct {
+	Data struct {
+		CategoryGroups []CategoryGroup `json:"category_groups"`
+	} `json:"data"`
+}
+
+// FindCategoryID returns the ID of the category with the given name,
+// ignoring case and deleted categories.
+func (r *GetCategoriesResponse) FindCategoryID(name string) (string, error) {
+	for _, group := range r.Data.CategoryGroups {
+		for _, category := range group.Categories {
+			if !category.Deleted && strings.EqualFold(category.Name, name) {
+				return category.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("category %q not found", name)
+}
+
 type Budget struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`